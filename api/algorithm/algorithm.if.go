@@ -16,4 +16,14 @@ type IAlgorithmV1 interface {
 	GetOne(ctx context.Context, req *v1.GetOneReq) (res *v1.GetOneRes, err error)
 	Update(ctx context.Context, req *v1.UpdateReq) (res *v1.UpdateRes, err error)
 	Delete(ctx context.Context, req *v1.DeleteReq) (res *v1.DeleteRes, err error)
+	Start(ctx context.Context, req *v1.StartReq) (res *v1.StartRes, err error)
+	Stop(ctx context.Context, req *v1.StopReq) (res *v1.StopRes, err error)
+	Restart(ctx context.Context, req *v1.RestartReq) (res *v1.RestartRes, err error)
+	GetConfig(ctx context.Context, req *v1.GetConfigReq) (res *v1.GetConfigRes, err error)
+	SetConfig(ctx context.Context, req *v1.SetConfigReq) (res *v1.SetConfigRes, err error)
+	BatchAdd(ctx context.Context, req *v1.BatchAddReq) (res *v1.BatchAddRes, err error)
+	BatchDelete(ctx context.Context, req *v1.BatchDeleteReq) (res *v1.BatchDeleteRes, err error)
+	BatchActivate(ctx context.Context, req *v1.BatchActivateReq) (res *v1.BatchActivateRes, err error)
+	GetBatch(ctx context.Context, req *v1.GetBatchReq) (res *v1.GetBatchRes, err error)
+	Upload(ctx context.Context, req *v1.UploadReq) (res *v1.UploadRes, err error)
 }
@@ -1,25 +1,22 @@
 package v1
 
 import (
+	"demo/internal/model"
 	"demo/internal/model/entity"
 
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
 // AddReq 添加算法请求 (对应算法下发payload)
 type AddReq struct {
-	g.Meta             `path:"/algorithm" method:"post" tags:"Algorithm" summary:"Add algorithm from payload"`
-	CmdId              string `json:"cmdId" v:"required" dc:"Command ID"`
-	Version            string `json:"version" v:"required" dc:"Protocol version"`
-	Method             string `json:"method" v:"required" dc:"Method name"`
-	Timestamp          string `json:"timestamp" v:"required" dc:"Timestamp"`
-	AlgorithmId        string `json:"algorithmId" v:"required" dc:"Algorithm unique ID"`
-	AlgorithmName      string `json:"algorithmName" v:"required" dc:"Algorithm name"`
-	AlgorithmVersion   string `json:"algorithmVersion" v:"required" dc:"Algorithm version"`
-	AlgorithmVersionId string `json:"algorithmVersionId" v:"required" dc:"Algorithm version ID"`
-	AlgorithmDataUrl   string `json:"algorithmDataUrl" v:"required|url" dc:"Algorithm download URL"`
-	FileSize           int64  `json:"fileSize" v:"required|min:1" dc:"File size in bytes"`
-	Md5                string `json:"md5" v:"required|length:32,32" dc:"MD5 checksum"`
+	g.Meta    `path:"/algorithm" method:"post" tags:"Algorithm" summary:"Add algorithm from payload"`
+	CmdId     string `json:"cmdId" v:"required" dc:"Command ID"`
+	Version   string `json:"version" v:"required" dc:"Protocol version"`
+	Method    string `json:"method" v:"required" dc:"Method name"`
+	Timestamp string `json:"timestamp" v:"required" dc:"Timestamp"`
+	model.AlgorithmAddInput
 }
 
 type AddRes struct {
@@ -75,3 +72,120 @@ type DeleteRes struct {
 	Success bool   `json:"success" dc:"Delete result"`
 	Message string `json:"message" dc:"Result message"`
 }
+
+// StartReq 启动算法请求
+type StartReq struct {
+	g.Meta `path:"/algorithm/{id}/start" method:"post" tags:"Algorithm" summary:"Start algorithm"`
+	Id     int64 `v:"required" dc:"Algorithm record ID"`
+}
+
+type StartRes struct {
+	Success bool   `json:"success" dc:"Start result"`
+	Message string `json:"message" dc:"Result message"`
+}
+
+// StopReq 停止算法请求
+type StopReq struct {
+	g.Meta  `path:"/algorithm/{id}/stop" method:"post" tags:"Algorithm" summary:"Stop algorithm"`
+	Id      int64 `v:"required" dc:"Algorithm record ID"`
+	Timeout int   `v:"min:0" dc:"Seconds to wait after SIGTERM before SIGKILL, 0 uses runtime.stopTimeout"`
+}
+
+type StopRes struct {
+	Success bool   `json:"success" dc:"Stop result"`
+	Message string `json:"message" dc:"Result message"`
+}
+
+// RestartReq 重启算法请求
+type RestartReq struct {
+	g.Meta  `path:"/algorithm/{id}/restart" method:"post" tags:"Algorithm" summary:"Restart algorithm"`
+	Id      int64 `v:"required" dc:"Algorithm record ID"`
+	Timeout int   `v:"min:0" dc:"Seconds to wait after SIGTERM before SIGKILL, 0 uses runtime.stopTimeout"`
+}
+
+type RestartRes struct {
+	Success bool   `json:"success" dc:"Restart result"`
+	Message string `json:"message" dc:"Result message"`
+}
+
+// GetConfigReq 获取算法配置参数请求
+type GetConfigReq struct {
+	g.Meta   `path:"/algorithm/{id}/config" method:"get" tags:"Algorithm" summary:"Get algorithm config"`
+	Id       int64 `v:"required" dc:"Algorithm record ID"`
+	Revision int   `v:"min:0" dc:"Config revision, 0 for the latest"`
+}
+
+type GetConfigRes struct {
+	AlgorithmId string      `json:"algorithmId" dc:"Algorithm unique ID"`
+	Revision    int         `json:"revision" dc:"Config revision, 0 if never configured"`
+	Config      interface{} `json:"config" dc:"Config document"`
+	CreatedAt   *gtime.Time `json:"createdAt" dc:"Revision creation time"`
+}
+
+// SetConfigReq 更新算法配置参数请求
+type SetConfigReq struct {
+	g.Meta `path:"/algorithm/{id}/config" method:"put" tags:"Algorithm" summary:"Set algorithm config"`
+	Id     int64       `v:"required" dc:"Algorithm record ID"`
+	Config interface{} `json:"config" v:"required" dc:"Config document, validated against the package config schema"`
+}
+
+type SetConfigRes struct {
+	Revision int `json:"revision" dc:"New config revision"`
+}
+
+// BatchAddReq 批量添加算法请求
+type BatchAddReq struct {
+	g.Meta `path:"/algorithm/batch" method:"post" tags:"Algorithm" summary:"Add algorithms in batch"`
+	Items  []model.AlgorithmAddInput `json:"items" v:"required" dc:"Algorithms to install, at most 100"`
+	Wait   bool                      `json:"wait" dc:"Respond after all items finish instead of immediately"`
+}
+
+type BatchAddRes struct {
+	*model.BatchJob
+}
+
+// BatchDeleteReq 批量删除算法请求
+type BatchDeleteReq struct {
+	g.Meta       `path:"/algorithm/batch/delete" method:"post" tags:"Algorithm" summary:"Delete algorithms in batch"`
+	AlgorithmIds []string `json:"algorithmIds" v:"required" dc:"Algorithm unique IDs, at most 100"`
+	Wait         bool     `json:"wait" dc:"Respond after all items finish instead of immediately"`
+}
+
+type BatchDeleteRes struct {
+	*model.BatchJob
+}
+
+// BatchActivateReq 批量启动算法请求
+type BatchActivateReq struct {
+	g.Meta       `path:"/algorithm/batch/activate" method:"post" tags:"Algorithm" summary:"Activate algorithms in batch"`
+	AlgorithmIds []string `json:"algorithmIds" v:"required" dc:"Algorithm unique IDs, at most 100"`
+	Wait         bool     `json:"wait" dc:"Respond after all items finish instead of immediately"`
+}
+
+type BatchActivateRes struct {
+	*model.BatchJob
+}
+
+// GetBatchReq 查询批量任务进度请求
+type GetBatchReq struct {
+	g.Meta `path:"/algorithm/batch/{jobId}" method:"get" tags:"Algorithm" summary:"Get batch job progress"`
+	JobId  string `json:"jobId" v:"required" dc:"Batch job ID"`
+}
+
+type GetBatchRes struct {
+	*model.BatchJob
+}
+
+// UploadReq 上传算法包请求，用于无法访问下载地址的离线站点。
+// metadata 为与 AddReq 算法字段相同的 JSON，可作为表单字段或文件上传
+type UploadReq struct {
+	g.Meta   `path:"/algorithm/upload" method:"post" mime:"multipart/form-data" tags:"Algorithm" summary:"Upload algorithm package"`
+	File     *ghttp.UploadFile `json:"file" type:"file" v:"required" dc:"Algorithm package (zip)"`
+	Metadata string            `json:"metadata" dc:"Metadata sidecar JSON with the AddReq algorithm fields"`
+}
+
+type UploadRes struct {
+	Id      int64  `json:"id" dc:"Algorithm record ID"`
+	Success bool   `json:"success" dc:"Operation result"`
+	Message string `json:"message" dc:"Result message"`
+}
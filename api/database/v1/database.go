@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// GetBackupsReq 获取数据库备份列表请求
+type GetBackupsReq struct {
+	g.Meta `path:"/database/backups" method:"get" tags:"Database" summary:"Get database backups"`
+}
+
+type GetBackupsRes struct {
+	List []model.DatabaseBackup `json:"list" dc:"Backups, newest first"`
+}
+
+// CreateBackupReq 备份数据库请求
+type CreateBackupReq struct {
+	g.Meta `path:"/database/backups" method:"post" tags:"Database" summary:"Back up database" dc:"Makes a consistent copy with VACUUM INTO while the service keeps running."`
+}
+
+type CreateBackupRes struct {
+	*model.DatabaseBackup
+}
+
+// DownloadBackupReq 下载数据库备份请求
+type DownloadBackupReq struct {
+	g.Meta `path:"/database/backups/{name}" method:"get" tags:"Database" summary:"Download database backup"`
+	Name   string `v:"required" dc:"Backup file name"`
+}
+
+type DownloadBackupRes struct{}
+
+// DeleteBackupReq 删除数据库备份请求
+type DeleteBackupReq struct {
+	g.Meta `path:"/database/backups/{name}" method:"delete" tags:"Database" summary:"Delete database backup"`
+	Name   string `v:"required" dc:"Backup file name"`
+}
+
+type DeleteBackupRes struct{}
+
+// UploadBackupReq 上传数据库备份请求，如换卡后上传之前下载的备份再恢复
+type UploadBackupReq struct {
+	g.Meta `path:"/database/backups/upload" method:"post" mime:"multipart/form-data" tags:"Database" summary:"Upload database backup" dc:"The backup is checked like a restore and stored in the backup directory."`
+	File   *ghttp.UploadFile `json:"file" type:"file" v:"required" dc:"Backup file (.db)"`
+}
+
+type UploadBackupRes struct {
+	*model.DatabaseBackup
+}
+
+// RestoreReq 恢复数据库请求
+type RestoreReq struct {
+	g.Meta `path:"/database/restore" method:"post" tags:"Database" summary:"Restore database from backup" dc:"The current database is backed up first. The service restarts after restoring."`
+	Name   string `json:"name" v:"required" dc:"Backup file name"`
+}
+
+type RestoreRes struct {
+	*model.DatabaseRestoreOutput
+}
+
+// GetTablesReq 获取数据库表请求
+type GetTablesReq struct {
+	g.Meta `path:"/database/tables" method:"get" tags:"Database" summary:"Get database tables"`
+}
+
+type GetTablesRes struct {
+	List []model.DatabaseTable `json:"list" dc:"Tables sorted by name"`
+}
+
+// ExportTableReq 导出表请求
+type ExportTableReq struct {
+	g.Meta `path:"/database/tables/{table}/export" method:"get" tags:"Database" summary:"Export table as JSON or CSV"`
+	Table  string `v:"required" dc:"Table name"`
+	Format string `d:"json" v:"in:json,csv" dc:"json or csv"`
+}
+
+type ExportTableRes struct{}
+
+// ImportTableReq 导入表请求
+type ImportTableReq struct {
+	g.Meta `path:"/database/tables/{table}/import" method:"post" mime:"multipart/form-data" tags:"Database" summary:"Import table from JSON or CSV"`
+	Table  string            `v:"required" dc:"Table name"`
+	File   *ghttp.UploadFile `json:"file" type:"file" v:"required" dc:"File in the export format"`
+	Format string            `json:"format" d:"json" v:"in:json,csv" dc:"json or csv"`
+	Mode   string            `json:"mode" d:"append" v:"in:append,replace" dc:"append keeps existing rows, replace clears the table first"`
+}
+
+type ImportTableRes struct {
+	Rows int `json:"rows" dc:"Imported rows"`
+}
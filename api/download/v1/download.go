@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/model"
+)
+
+// GetThrottleReq 获取下载限速状态请求
+type GetThrottleReq struct {
+	g.Meta `path:"/download/throttle" method:"get" tags:"Download" summary:"Get download rate limit and active downloads"`
+}
+
+type GetThrottleRes struct {
+	*model.DownloadThrottleStatus
+}
+
+// SetThrottleReq 实时调整下载限速请求，用请求中的设置替换当前设置，重启后恢复配置文件的设置
+type SetThrottleReq struct {
+	g.Meta    `path:"/download/throttle" method:"put" tags:"Download" summary:"Adjust download rate limit live"`
+	RateLimit int64                  `json:"rateLimit" v:"min:0" dc:"Default limit in bytes per second outside windows, 0 means unlimited"`
+	Windows   []model.ThrottleWindow `json:"windows"             dc:"Time-of-day windows overriding the default limit"`
+	Reset     bool                   `json:"reset"               dc:"Restore the settings from the config file, other fields are ignored"`
+}
+
+type SetThrottleRes struct {
+	*model.DownloadThrottleStatus
+}
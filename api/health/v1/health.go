@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// LivenessReq 存活检查请求，失败时返回 503
+type LivenessReq struct {
+	g.Meta `path:"/healthz" method:"get" tags:"Health" summary:"Liveness probe"`
+}
+
+type LivenessRes struct {
+	*model.HealthReport
+}
+
+// ReadinessReq 就绪检查请求，失败时返回 503
+type ReadinessReq struct {
+	g.Meta `path:"/readyz" method:"get" tags:"Health" summary:"Readiness probe"`
+}
+
+type ReadinessRes struct {
+	*model.HealthReport
+}
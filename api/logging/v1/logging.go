@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetLevelsReq 获取各子系统日志级别请求
+type GetLevelsReq struct {
+	g.Meta `path:"/log/levels" method:"get" tags:"Logging" summary:"Get log level of each subsystem"`
+}
+
+type GetLevelsRes struct {
+	List []model.LogLevel `json:"list" dc:"Log levels"`
+}
+
+// SetLevelReq 修改子系统日志级别请求，立即生效，重启后恢复为配置值
+type SetLevelReq struct {
+	g.Meta    `path:"/log/levels" method:"put" tags:"Logging" summary:"Set log level of a subsystem"`
+	Subsystem string `v:"required" dc:"Log subsystem, e.g. mqtt, download, command"`
+	Level     string `v:"required|in:debug,info,notice,warning,error,critical" dc:"Log level"`
+}
+
+type SetLevelRes struct {
+	List []model.LogLevel `json:"list" dc:"Log levels after the change"`
+}
@@ -0,0 +1,16 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetListReq 获取局域网内发现的设备请求
+type GetListReq struct {
+	g.Meta `path:"/peers" method:"get" tags:"Peer" summary:"Get peer devices discovered via mDNS"`
+}
+
+type GetListRes struct {
+	List []model.Peer `json:"list" dc:"Peer devices sorted by device ID"`
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// CreateReq 新增算法运行时间窗请求
+type CreateReq struct {
+	g.Meta      `path:"/schedule" method:"post" tags:"Schedule" summary:"Create algorithm schedule"`
+	AlgorithmId string `json:"algorithmId" v:"required" dc:"Algorithm unique ID"`
+	model.ScheduleInput
+}
+
+type CreateRes struct {
+	Id int64 `json:"id" dc:"Schedule ID"`
+}
+
+// UpdateReq 修改算法运行时间窗请求
+type UpdateReq struct {
+	g.Meta `path:"/schedule/{id}" method:"put" tags:"Schedule" summary:"Update algorithm schedule"`
+	Id     int64 `v:"required" dc:"Schedule ID"`
+	model.ScheduleInput
+}
+
+type UpdateRes struct{}
+
+// DeleteReq 删除算法运行时间窗请求
+type DeleteReq struct {
+	g.Meta `path:"/schedule/{id}" method:"delete" tags:"Schedule" summary:"Delete algorithm schedule"`
+	Id     int64 `v:"required" dc:"Schedule ID"`
+}
+
+type DeleteRes struct{}
+
+// GetListReq 获取算法运行时间窗列表请求
+type GetListReq struct {
+	g.Meta      `path:"/schedule" method:"get" tags:"Schedule" summary:"Get algorithm schedules"`
+	AlgorithmId string `v:"" dc:"Algorithm unique ID filter"`
+}
+
+type GetListRes struct {
+	List []model.ScheduleItem `json:"list" dc:"Schedule list with current window state"`
+}
+
+// GetTransitionsReq 获取计划启停动作请求
+type GetTransitionsReq struct {
+	g.Meta      `path:"/schedule/transitions" method:"get" tags:"Schedule" summary:"Get next planned start/stop transitions"`
+	AlgorithmId string `v:"" dc:"Algorithm unique ID filter"`
+}
+
+type GetTransitionsRes struct {
+	List []model.ScheduleTransition `json:"list" dc:"Planned transitions ordered by time"`
+}
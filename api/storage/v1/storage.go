@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetSummaryReq 获取算法存储概况请求
+type GetSummaryReq struct {
+	g.Meta `path:"/storage" method:"get" tags:"Storage" summary:"Get algorithm store usage"`
+}
+
+type GetSummaryRes struct {
+	*model.StorageSummary
+}
+
+// PruneReq 清理算法存储请求
+type PruneReq struct {
+	g.Meta      `path:"/storage/prune" method:"post" tags:"Storage" summary:"Prune old algorithm versions"`
+	AlgorithmId string `v:"regex:^[A-Za-z0-9._-]+$|not-in:.,.." dc:"Only prune this algorithm, empty for all"`
+	Keep        *int   `v:"min:0" dc:"Previous versions to keep per algorithm, defaults to storage.keepVersions"`
+}
+
+type PruneRes struct {
+	*model.StoragePruneOutput
+}
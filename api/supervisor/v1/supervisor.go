@@ -0,0 +1,17 @@
+package v1
+
+import (
+	"demo/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetStatusReq 获取算法进程运行状态请求
+type GetStatusReq struct {
+	g.Meta      `path:"/supervisor" method:"get" tags:"Supervisor" summary:"Get algorithm process status"`
+	AlgorithmId string `v:"" dc:"Algorithm unique ID filter"`
+}
+
+type GetStatusRes struct {
+	List []model.AlgorithmRuntimeStatus `json:"list" dc:"Algorithm process status list"`
+}
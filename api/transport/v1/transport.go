@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetStatusReq 获取命令通道状态请求
+type GetStatusReq struct {
+	g.Meta `path:"/transport" method:"get" tags:"Transport" summary:"Get command transport status"`
+}
+
+type GetStatusRes struct {
+	Type      string `json:"type"      dc:"Transport type: mqtt or http"`
+	Enabled   bool   `json:"enabled"   dc:"Whether the transport is configured"`
+	Connected bool   `json:"connected" dc:"Whether the last exchange with the cloud succeeded"`
+}
+
+// WebhookReq 云端推送命令请求，请求体为完整的命令 JSON，与 MQTT 命令主题的消息相同
+type WebhookReq struct {
+	g.Meta `path:"/transport/webhook" method:"post" tags:"Transport" summary:"Receive a command pushed by the cloud" dc:"Requires transport.type http with transport.http.mode webhook. The reply is posted to the cloud replies endpoint."`
+}
+
+type WebhookRes struct {
+	Accepted bool `json:"accepted" dc:"The command was accepted and is being executed"`
+}
@@ -0,0 +1,26 @@
+// fakecloud 本地模拟云端 HTTP 命令通道，用于测试 transport.type=http：
+//
+//	go run ./cmd/fakecloud -addr :18080 -token secret
+//	curl -X POST localhost:18080/devices/dev1/commands -d '{"cmdId":"1","version":"1.0","method":"getSchedule","timestamp":"0","algorithmId":"a1"}'
+//	curl localhost:18080/devices/dev1/replies
+//
+// 指定 -webhook 时命令直接推送到设备的 /transport/webhook，否则进入队列等待设备长轮询。
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"demo/internal/fakecloud"
+)
+
+func main() {
+	addr := flag.String("addr", ":18080", "listen address")
+	token := flag.String("token", "", "bearer token required from devices, empty to disable")
+	webhook := flag.String("webhook", "", "push commands to this device webhook URL instead of queueing them")
+	flag.Parse()
+
+	log.Printf("fake cloud listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fakecloud.New(*token, *webhook).Handler()))
+}
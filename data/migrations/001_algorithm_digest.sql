@@ -0,0 +1,3 @@
+-- 算法表增加通用摘要字段，记录实际用于校验的摘要算法
+ALTER TABLE `algorithm` ADD COLUMN `digest_algo` TEXT NOT NULL DEFAULT 'md5';
+ALTER TABLE `algorithm` ADD COLUMN `digest` TEXT NOT NULL DEFAULT '';
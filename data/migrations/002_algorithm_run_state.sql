@@ -0,0 +1,2 @@
+-- 算法期望运行状态，重启后按该状态恢复算法进程
+ALTER TABLE `algorithm` ADD COLUMN `run_state` TEXT NOT NULL DEFAULT 'running';
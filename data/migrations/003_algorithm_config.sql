@@ -0,0 +1,9 @@
+-- 算法配置表，每次修改生成新的版本，最新版本为当前生效配置
+CREATE TABLE IF NOT EXISTS `algorithm_config` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `algorithm_id` TEXT NOT NULL,
+  `revision` INTEGER NOT NULL,
+  `content` TEXT NOT NULL,
+  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (`algorithm_id`, `revision`)
+);
@@ -0,0 +1,11 @@
+-- 算法运行时间窗：按 cron 表达式开始运行，持续 duration 后停止
+CREATE TABLE IF NOT EXISTS `algorithm_schedule` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `algorithm_id` TEXT NOT NULL,
+  `cron` TEXT NOT NULL,
+  `duration` TEXT NOT NULL,
+  `timezone` TEXT NOT NULL DEFAULT 'Local',
+  `enabled` INTEGER NOT NULL DEFAULT 1,
+  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_algorithm_schedule_algorithm_id` ON `algorithm_schedule` (`algorithm_id`);
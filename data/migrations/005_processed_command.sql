@@ -0,0 +1,10 @@
+-- 已处理的云端命令：按 cmd_id 去重，重复下发时返回缓存的执行结果而不重新执行
+CREATE TABLE IF NOT EXISTS `processed_command` (
+  `cmd_id` TEXT PRIMARY KEY,
+  `method` TEXT NOT NULL,
+  `status` TEXT NOT NULL DEFAULT 'running',
+  `reply` TEXT NOT NULL DEFAULT '',
+  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS `idx_processed_command_created_at` ON `processed_command` (`created_at`);
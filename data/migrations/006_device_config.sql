@@ -0,0 +1,10 @@
+-- 云端下发的设备配置，每次下发生成新的版本，变更后在回滚时间窗内失去连接则回滚到上一个已确认的版本
+CREATE TABLE IF NOT EXISTS `device_config` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `revision` INTEGER NOT NULL UNIQUE,
+  `content` TEXT NOT NULL,
+  `status` TEXT NOT NULL DEFAULT 'pending',
+  `reason` TEXT NOT NULL DEFAULT '',
+  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/entity"
+	"demo/internal/service"
+)
+
+var (
+	Algorithm = gcmd.Command{
+		Name:  "algorithm",
+		Usage: "algorithm list|get|verify|install|activate",
+		Brief: "inspect and manage installed algorithms",
+	}
+
+	AlgorithmList = gcmd.Command{
+		Name:      "list",
+		Usage:     "algorithm list [-f table|json|yaml]",
+		Brief:     "list installed algorithms",
+		Arguments: []gcmd.Argument{formatArgument},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			var list []entity.Algorithm
+			if err = dao.Algorithm.Ctx(ctx).OrderAsc(dao.Algorithm.Columns().Id).Scan(&list); err != nil {
+				return err
+			}
+			return render(parser, list, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tALGORITHM ID\tNAME\tVERSION\tVERSION ID\tRUN STATE\tDIGEST")
+				for _, a := range list {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", a.Id, a.AlgorithmId, a.AlgorithmName, a.AlgorithmVersion,
+						a.AlgorithmVersionId, cell(a.RunState), cell(a.DigestAlgo))
+				}
+			})
+		},
+	}
+
+	AlgorithmGet = gcmd.Command{
+		Name:  "get",
+		Usage: "algorithm get ALGORITHM_ID [-f table|json|yaml]",
+		Brief: "show an installed algorithm",
+		Arguments: []gcmd.Argument{
+			{Name: "algorithmId", IsArg: true, Brief: "algorithm unique ID"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			algorithm, err := service.Algorithm().GetByAlgorithmId(ctx, parser.GetArg(3).String())
+			if err != nil {
+				return err
+			}
+			return render(parser, algorithm, func(w io.Writer) {
+				writeFields(w, algorithm)
+			})
+		},
+	}
+
+	AlgorithmVerify = gcmd.Command{
+		Name:  "verify",
+		Usage: "algorithm verify [ALGORITHM_ID...] [-f table|json|yaml]",
+		Brief: "re-check package digests and entrypoints of installed algorithms",
+		Description: "Hashes the package kept with each installed version against the digest recorded at install " +
+			"and checks the entrypoint. Verifies all algorithms when no ID is given. Fails if any check fails.",
+		Arguments: []gcmd.Argument{
+			{Name: "algorithmId", IsArg: true, Brief: "algorithm unique IDs, default all"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			ids := parser.GetArgAll()[3:]
+			if len(ids) == 0 {
+				values, err := dao.Algorithm.Ctx(ctx).OrderAsc(dao.Algorithm.Columns().Id).Array(dao.Algorithm.Columns().AlgorithmId)
+				if err != nil {
+					return err
+				}
+				for _, value := range values {
+					ids = append(ids, value.String())
+				}
+			}
+			results := make([]*model.AlgorithmVerifyResult, 0, len(ids))
+			failed := 0
+			for _, id := range ids {
+				result, err := service.Algorithm().Verify(ctx, id)
+				if err != nil {
+					return err
+				}
+				if !result.Ok {
+					failed++
+				}
+				results = append(results, result)
+			}
+			err = render(parser, results, func(w io.Writer) {
+				fmt.Fprintln(w, "ALGORITHM ID\tVERSION ID\tDIGEST\tRESULT")
+				for _, result := range results {
+					status := "ok"
+					if !result.Ok {
+						status = fmt.Sprint(result.Problems)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.AlgorithmId, result.AlgorithmVersionId, cell(result.Digest), status)
+				}
+			})
+			if err == nil && failed > 0 {
+				err = gerror.NewCodef(gcode.CodeValidationFailed, "%d of %d algorithms failed verification", failed, len(results))
+			}
+			return err
+		},
+	}
+
+	AlgorithmInstall = gcmd.Command{
+		Name:  "install",
+		Usage: "algorithm install METADATA [--package FILE] [-s URL] [-f table|json|yaml]",
+		Brief: "install an algorithm through the running service",
+		Description: "METADATA is a JSON file with the fields of POST /algorithm/batch items, including algorithmDataUrl. " +
+			"With --package the local zip is uploaded instead and algorithmDataUrl is not needed. " +
+			"Waits until the package is downloaded, verified and started.",
+		Arguments: []gcmd.Argument{
+			{Name: "metadata", IsArg: true, Brief: "metadata JSON file"},
+			{Name: "package", Short: "p", Brief: "local algorithm package (zip) to upload"},
+			serverArgument,
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			metadataFile := parser.GetArg(3).String()
+			if metadataFile == "" {
+				return gerror.NewCode(gcode.CodeMissingParameter, "metadata file required")
+			}
+			metadata := gfile.GetBytes(metadataFile)
+			if len(metadata) == 0 {
+				return gerror.NewCodef(gcode.CodeInvalidParameter, "metadata file %s not found or empty", metadataFile)
+			}
+			if packageFile := parser.GetOpt("package").String(); packageFile != "" {
+				if !gfile.IsFile(packageFile) {
+					return gerror.NewCodef(gcode.CodeNotFound, "package %s not found", packageFile)
+				}
+				var res v1.UploadRes
+				err = doService(ctx, parser, g.Client(), http.MethodPost, "/algorithm/upload", g.Map{
+					"file":     "@file:" + packageFile,
+					"metadata": string(metadata),
+				}, &res)
+				if err != nil {
+					return err
+				}
+				return render(parser, res, func(w io.Writer) {
+					writeFields(w, res)
+				})
+			}
+			var item model.AlgorithmAddInput
+			if err = gjson.DecodeTo(metadata, &item); err != nil {
+				return gerror.WrapCodef(gcode.CodeInvalidParameter, err, "parse %s failed", metadataFile)
+			}
+			return batchJob(ctx, parser, "/algorithm/batch", g.Map{"items": []model.AlgorithmAddInput{item}, "wait": true})
+		},
+	}
+
+	AlgorithmActivate = gcmd.Command{
+		Name:  "activate",
+		Usage: "algorithm activate ALGORITHM_ID... [-s URL] [-f table|json|yaml]",
+		Brief: "start algorithms through the running service",
+		Arguments: []gcmd.Argument{
+			{Name: "algorithmId", IsArg: true, Brief: "algorithm unique IDs"},
+			serverArgument,
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			ids := parser.GetArgAll()[3:]
+			if len(ids) == 0 {
+				return gerror.NewCode(gcode.CodeMissingParameter, "algorithm ID required")
+			}
+			return batchJob(ctx, parser, "/algorithm/batch/activate", g.Map{"algorithmIds": ids, "wait": true})
+		},
+	}
+)
+
+// batchJob 提交批量任务并等待完成，输出各条目结果，有条目失败时返回错误
+func batchJob(ctx context.Context, parser *gcmd.Parser, path string, body g.Map) error {
+	var job model.BatchJob
+	if err := callService(ctx, parser, http.MethodPost, path, body, &job); err != nil {
+		return err
+	}
+	err := render(parser, job, func(w io.Writer) {
+		fmt.Fprintln(w, "ALGORITHM ID\tSTATUS\tMESSAGE")
+		for _, item := range job.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", item.AlgorithmId, item.Status, cell(item.Message))
+		}
+	})
+	if err == nil && job.Failed > 0 {
+		err = gerror.NewCodef(gcode.CodeOperationFailed, "%d of %d items failed", job.Failed, job.Total)
+	}
+	return err
+}
+
+func init() {
+	for _, command := range []*gcmd.Command{&AlgorithmList, &AlgorithmGet, &AlgorithmVerify, &AlgorithmInstall, &AlgorithmActivate} {
+		if err := Algorithm.AddCommand(command); err != nil {
+			panic(err)
+		}
+	}
+	if err := Main.AddCommand(&Algorithm); err != nil {
+		panic(err)
+	}
+}
@@ -6,10 +6,19 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gcmd"
-	"github.com/gogf/gf/v2/os/gfile"
 
 	"demo/internal/controller/algorithm"
+	"demo/internal/controller/database"
+	"demo/internal/controller/download"
+	"demo/internal/controller/health"
+	"demo/internal/controller/logging"
+	"demo/internal/controller/peer"
+	"demo/internal/controller/schedule"
+	"demo/internal/controller/storage"
+	"demo/internal/controller/supervisor"
+	"demo/internal/controller/transport"
 	"demo/internal/controller/user"
+	"demo/internal/service"
 )
 
 var (
@@ -18,15 +27,77 @@ var (
 		Usage: "main",
 		Brief: "start http server",
 		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			// 合并配置文件、默认值和环境变量并校验，需在其他服务读取配置前执行
+			loadErr := service.Config().Load(ctx)
+			// 检查上次自升级，新程序未通过健康检查就退出时回滚到旧程序。
+			// 配置校验失败时也要检查，新程序不接受现有配置同样需要回滚
+			service.Upgrade().Check(ctx)
+			if loadErr != nil {
+				return loadErr
+			}
+
+			// 日志格式和各子系统级别
+			if err = service.Logging().Setup(ctx); err != nil {
+				return err
+			}
+
+			// 链路追踪导出，需在数据库和 HTTP 服务之前设置
+			if err = service.Tracing().Setup(ctx); err != nil {
+				return err
+			}
+			defer service.Tracing().Shutdown(context.WithoutCancel(ctx))
+
 			// 初始化数据库
 			initDatabase(ctx)
+			// 定时备份数据库
+			service.Backup().Start(ctx)
+
+			// 启动已安装的算法
+			service.Supervisor().StartAll(ctx)
+			defer service.Supervisor().StopAll(ctx)
+			service.Schedule().Start(ctx)
+			// 扫描离线导入目录
+			service.Import().Start(ctx)
+
+			// 应用云端下发的设备配置
+			service.DeviceConfig().Start(ctx)
+
+			// 连接云端命令通道(MQTT 或 HTTP)，接收命令，上报注册信息并开始上报心跳
+			if service.Transport().Enabled() {
+				if err = service.Command().Start(ctx); err != nil {
+					g.Log().Errorf(ctx, "Start %s command transport failed: %v", service.Transport().Name(), err)
+				}
+				service.Device().Register(ctx)
+				service.Heartbeat().Start(ctx)
+			}
+			// 自升级后的新程序在时限内需通过就绪检查并连上云端，否则回滚
+			service.Upgrade().Verify(ctx)
+
+			// 在局域网内宣告本机并发现其他设备
+			if service.Discovery().Enabled() {
+				if err = service.Discovery().Start(ctx); err != nil {
+					g.Log().Errorf(ctx, "Start discovery failed: %v", err)
+				}
+				defer service.Discovery().Stop(context.WithoutCancel(ctx))
+			}
 
 			s := g.Server()
+			s.BindHandler("GET:/metrics", ghttp.WrapH(service.Metrics().Handler()))
+			s.BindHandler("GET:"+service.MirrorPathPrefix+"/{algo}/{hex}", service.Mirror().Serve)
 			s.Group("/", func(group *ghttp.RouterGroup) {
-				group.Middleware(ghttp.MiddlewareHandlerResponse)
+				group.Middleware(service.Logging().Middleware, service.Metrics().Middleware, ghttp.MiddlewareHandlerResponse)
 				group.Bind(
+					health.NewV1(),
+					logging.NewV1(),
 					user.NewV1(),
 					algorithm.NewV1(),
+					supervisor.NewV1(),
+					schedule.NewV1(),
+					storage.NewV1(),
+					transport.NewV1(),
+					peer.NewV1(),
+					download.NewV1(),
+					database.NewV1(),
 				)
 			})
 			s.Run()
@@ -35,25 +106,9 @@ var (
 	}
 )
 
-// initDatabase 初始化数据库表结构
+// initDatabase 初始化数据库表结构并执行增量迁移
 func initDatabase(ctx context.Context) {
-	// 读取初始化SQL文件
-	sqlFile := "data/init.sql"
-	if !gfile.Exists(sqlFile) {
-		g.Log().Warning(ctx, "SQL init file not found:", sqlFile)
-		return
-	}
-
-	sqlContent := gfile.GetContents(sqlFile)
-	if sqlContent == "" {
-		g.Log().Warning(ctx, "SQL init file is empty:", sqlFile)
-		return
-	}
-
-	// 执行SQL初始化
-	db := g.DB()
-	_, err := db.Exec(ctx, sqlContent)
-	if err != nil {
+	if err := service.Database().Init(ctx); err != nil {
 		g.Log().Errorf(ctx, "Failed to initialize database: %v", err)
 		return
 	}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/service"
+)
+
+var (
+	Config = gcmd.Command{
+		Name:  "config",
+		Usage: "config print [-f yaml|json]",
+		Brief: "inspect runtime configuration",
+	}
+
+	ConfigPrint = gcmd.Command{
+		Name:  "print",
+		Usage: "config print [-f yaml|json]",
+		Brief: "print effective configuration with secrets masked",
+		Description: "Prints the configuration file merged with defaults and " + service.ConfigEnvPrefix +
+			"* environment variable overrides. Validation errors are reported after the output.",
+		Arguments: []gcmd.Argument{
+			{Name: "format", Short: "f", Brief: "output format: yaml or json, default yaml"},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			// 校验失败时仍输出配置，便于定位
+			loadErr := service.Config().Load(ctx)
+			content, err := service.Config().Print(ctx, parser.GetOpt("format").String())
+			if err != nil {
+				return err
+			}
+			fmt.Print(content)
+			return loadErr
+		},
+	}
+)
+
+func init() {
+	if err := Config.AddCommand(&ConfigPrint); err != nil {
+		panic(err)
+	}
+	if err := Main.AddCommand(&Config); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/service"
+)
+
+var (
+	Db = gcmd.Command{
+		Name:  "db",
+		Usage: "db backup|list|restore|tables|show|export|import",
+		Brief: "back up, restore, export and import the database",
+	}
+
+	DbBackup = gcmd.Command{
+		Name:  "backup",
+		Usage: "db backup",
+		Brief: "back up the database to backup.path",
+		Description: "Makes a consistent copy with VACUUM INTO. " +
+			"Safe to run while the service is running.",
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			backup, err := service.Backup().Create(ctx, service.BackupManual)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s (%d bytes, schema %s)\n", backup.Name, backup.Size, backup.Schema)
+			return nil
+		},
+	}
+
+	DbList = gcmd.Command{
+		Name:      "list",
+		Usage:     "db list [-f table|json|yaml]",
+		Brief:     "list backups in backup.path",
+		Arguments: []gcmd.Argument{formatArgument},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			list, err := service.Backup().List(ctx)
+			if err != nil {
+				return err
+			}
+			return render(parser, list, func(w io.Writer) {
+				fmt.Fprintln(w, "NAME\tSIZE\tSCHEMA\tCREATED")
+				for _, backup := range list {
+					fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", backup.Name, backup.Size, cell(backup.Schema), backup.CreatedAt)
+				}
+			})
+		},
+	}
+
+	DbRestore = gcmd.Command{
+		Name:  "restore",
+		Usage: "db restore FILE [--force]",
+		Brief: "restore the database from a backup file",
+		Description: "FILE is a path or a name in backup.path. The backup must pass an integrity check and " +
+			"must not contain migrations unknown to this version. The current database is backed up first. " +
+			"Stop the service before restoring, or use POST /database/restore which restarts it.",
+		Arguments: []gcmd.Argument{
+			{Name: "file", IsArg: true, Brief: "backup file path or name in backup.path"},
+			{Name: "force", Brief: "restore even if the service seems to be running", Orphan: true},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			file, err := backupFile(parser.GetArg(3).String())
+			if err != nil {
+				return err
+			}
+			// 运行中的服务仍持有旧数据库文件，替换后它的写入会丢失
+			address := service.Config().Get().Server.Address
+			if parser.GetOpt("force").IsNil() && serviceRunning(address) {
+				return gerror.NewCodef(gcode.CodeOperationFailed,
+					"%s is in use, the service seems to be running; stop it first or use --force", address)
+			}
+			out, err := service.Backup().Restore(ctx, file, false)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored %s\n", out.Restored)
+			if out.PreRestore != "" {
+				fmt.Printf("Previous database saved as %s\n", out.PreRestore)
+			}
+			for _, version := range out.Migrations {
+				fmt.Printf("Applied migration %s\n", version)
+			}
+			return nil
+		},
+	}
+
+	DbTables = gcmd.Command{
+		Name:      "tables",
+		Usage:     "db tables [-f table|json|yaml]",
+		Brief:     "list tables and row counts",
+		Arguments: []gcmd.Argument{formatArgument},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			list, err := service.Backup().Tables(ctx)
+			if err != nil {
+				return err
+			}
+			return render(parser, list, func(w io.Writer) {
+				fmt.Fprintln(w, "TABLE\tROWS")
+				for _, table := range list {
+					fmt.Fprintf(w, "%s\t%d\n", table.Name, table.Rows)
+				}
+			})
+		},
+	}
+
+	DbShow = gcmd.Command{
+		Name:  "show",
+		Usage: "db show TABLE [-n LIMIT] [-f table|json|yaml]",
+		Brief: "show columns and the first rows of a table",
+		Arguments: []gcmd.Argument{
+			{Name: "table", IsArg: true, Brief: "table name"},
+			{Name: "limit", Short: "n", Brief: "number of rows, default 5"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			detail, err := service.Backup().Describe(ctx, parser.GetArg(3).String(), parser.GetOpt("limit", 5).Int())
+			if err != nil {
+				return err
+			}
+			return render(parser, detail, func(w io.Writer) {
+				fmt.Fprintf(w, "%s: %d rows\n\n", detail.Name, detail.Rows)
+				fmt.Fprintln(w, "COLUMN\tTYPE\tNULL\tKEY\tDEFAULT")
+				for _, column := range detail.Columns {
+					fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n", column.Name, column.Type, column.Null, cell(column.Key), cell(column.Default))
+				}
+				if len(detail.Sample) == 0 {
+					return
+				}
+				fmt.Fprintln(w)
+				for i, column := range detail.Columns {
+					if i > 0 {
+						fmt.Fprint(w, "\t")
+					}
+					fmt.Fprint(w, strings.ToUpper(column.Name))
+				}
+				fmt.Fprintln(w)
+				for _, row := range detail.Sample {
+					for i, column := range detail.Columns {
+						if i > 0 {
+							fmt.Fprint(w, "\t")
+						}
+						fmt.Fprint(w, cell(row[column.Name]))
+					}
+					fmt.Fprintln(w)
+				}
+			})
+		},
+	}
+
+	DbExport = gcmd.Command{
+		Name:  "export",
+		Usage: "db export TABLE [-f json|csv] [-o FILE]",
+		Brief: "export a table as JSON or CSV",
+		Arguments: []gcmd.Argument{
+			{Name: "table", IsArg: true, Brief: "table name"},
+			{Name: "format", Short: "f", Brief: "json or csv, default json"},
+			{Name: "output", Short: "o", Brief: "output file, default stdout"},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			var w io.Writer = os.Stdout
+			if output := parser.GetOpt("output").String(); output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			return service.Backup().Export(ctx, parser.GetArg(3).String(), parser.GetOpt("format", service.TableFormatJson).String(), w)
+		},
+	}
+
+	DbImport = gcmd.Command{
+		Name:  "import",
+		Usage: "db import TABLE FILE [-f json|csv] [--replace]",
+		Brief: "import rows into a table from JSON or CSV",
+		Description: "Rows are inserted in one transaction. Empty CSV values become NULL for nullable columns. " +
+			"Restart the service afterwards so it reloads the imported rows.",
+		Arguments: []gcmd.Argument{
+			{Name: "table", IsArg: true, Brief: "table name"},
+			{Name: "file", IsArg: true, Brief: "file in the export format"},
+			{Name: "format", Short: "f", Brief: "json or csv, default json"},
+			{Name: "replace", Brief: "delete existing rows first", Orphan: true},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			f, err := os.Open(parser.GetArg(4).String())
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			rows, err := service.Backup().Import(ctx, parser.GetArg(3).String(), parser.GetOpt("format", service.TableFormatJson).String(),
+				f, !parser.GetOpt("replace").IsNil())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Imported %d rows\n", rows)
+			return nil
+		},
+	}
+)
+
+// backupFile 参数为已存在的路径时直接使用，否则作为备份目录中的名称
+func backupFile(arg string) (string, error) {
+	if arg == "" {
+		return "", gerror.NewCode(gcode.CodeMissingParameter, "backup file required")
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, nil
+	}
+	return service.Backup().File(arg)
+}
+
+// serviceRunning 服务监听地址已被占用时认为服务正在运行
+func serviceRunning(address string) bool {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return true
+	}
+	_ = listener.Close()
+	return false
+}
+
+func init() {
+	for _, command := range []*gcmd.Command{&DbBackup, &DbList, &DbRestore, &DbTables, &DbShow, &DbExport, &DbImport} {
+		if err := Db.AddCommand(command); err != nil {
+			panic(err)
+		}
+	}
+	if err := Main.AddCommand(&Db); err != nil {
+		panic(err)
+	}
+}
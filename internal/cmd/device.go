@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"io"
+
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/service"
+)
+
+var (
+	Device = gcmd.Command{
+		Name:      "device",
+		Usage:     "device [-f table|json|yaml]",
+		Brief:     "show device identity and connection settings",
+		Arguments: []gcmd.Argument{formatArgument},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			identity := service.Device().Identity()
+			return render(parser, identity, func(w io.Writer) {
+				writeFields(w, identity)
+			})
+		},
+	}
+)
+
+func init() {
+	if err := Main.AddCommand(&Device); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/dao"
+	"demo/internal/model/entity"
+	"demo/internal/service"
+)
+
+var (
+	Message = gcmd.Command{
+		Name:  "message",
+		Usage: "message list|get",
+		Brief: "inspect stored MQTT messages",
+	}
+
+	MessageList = gcmd.Command{
+		Name:  "list",
+		Usage: "message list [--topic TOPIC] [-n LIMIT] [-f table|json|yaml]",
+		Brief: "list the latest stored MQTT messages",
+		Arguments: []gcmd.Argument{
+			{Name: "topic", Short: "t", Brief: "only messages on this topic"},
+			{Name: "limit", Short: "n", Brief: "number of messages, default 20"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			columns := dao.MqttMessage.Columns()
+			m := dao.MqttMessage.Ctx(ctx)
+			if topic := parser.GetOpt("topic").String(); topic != "" {
+				m = m.Where(columns.Topic, topic)
+			}
+			var list []entity.MqttMessage
+			if err = m.OrderDesc(columns.Id).Limit(parser.GetOpt("limit", 20).Int()).Scan(&list); err != nil {
+				return err
+			}
+			return render(parser, list, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tTOPIC\tQOS\tRETAINED\tCREATED\tPAYLOAD")
+				for _, msg := range list {
+					payload := msg.Payload
+					if runes := []rune(payload); len(runes) > 60 {
+						payload = string(runes[:57]) + "..."
+					}
+					fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%s\n", msg.Id, msg.Topic, msg.Qos, msg.Retained, cell(msg.CreatedAt), cell(payload))
+				}
+			})
+		},
+	}
+
+	MessageGet = gcmd.Command{
+		Name:  "get",
+		Usage: "message get ID [-f table|json|yaml]",
+		Brief: "show a stored MQTT message with the full payload",
+		Arguments: []gcmd.Argument{
+			{Name: "id", IsArg: true, Brief: "message ID"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			id := parser.GetArg(3).Int64()
+			var msg *entity.MqttMessage
+			if err = dao.MqttMessage.Ctx(ctx).WherePri(id).Scan(&msg); err != nil {
+				return err
+			}
+			if msg == nil {
+				return gerror.NewCodef(gcode.CodeNotFound, "message %d not found", id)
+			}
+			return render(parser, msg, func(w io.Writer) {
+				writeFields(w, msg)
+			})
+		},
+	}
+)
+
+func init() {
+	for _, command := range []*gcmd.Command{&MessageList, &MessageGet} {
+		if err := Message.AddCommand(command); err != nil {
+			panic(err)
+		}
+	}
+	if err := Main.AddCommand(&Message); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/service"
+)
+
+var (
+	Mqtt = gcmd.Command{
+		Name:  "mqtt",
+		Usage: "mqtt publish",
+		Brief: "test the MQTT connection",
+	}
+
+	MqttPublish = gcmd.Command{
+		Name:  "publish",
+		Usage: "mqtt publish TOPIC PAYLOAD [-q QOS] [--retain]",
+		Brief: "publish a test message to the configured broker",
+		Description: "Connects to mqtt.broker with the configured credentials using a separate client ID, " +
+			"so the running service stays connected. PAYLOAD - reads the payload from stdin. " +
+			"Uses MQTT v3.1.1, which v5 brokers also accept.",
+		Arguments: []gcmd.Argument{
+			{Name: "topic", IsArg: true, Brief: "topic, %s is replaced by the device ID"},
+			{Name: "payload", IsArg: true, Brief: "message payload, - for stdin"},
+			{Name: "qos", Short: "q", Brief: "QoS 0, 1 or 2, default 1"},
+			{Name: "retain", Brief: "publish as retained message", Orphan: true},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			topic := parser.GetArg(3).String()
+			if topic == "" {
+				return gerror.NewCode(gcode.CodeMissingParameter, "topic required")
+			}
+			if strings.Contains(topic, "%s") {
+				topic = fmt.Sprintf(topic, service.Device().Id())
+			}
+			payload := []byte(parser.GetArg(4).String())
+			if string(payload) == "-" {
+				if payload, err = io.ReadAll(os.Stdin); err != nil {
+					return err
+				}
+			}
+			qos := parser.GetOpt("qos", 1).Int()
+			if qos < 0 || qos > 2 {
+				return gerror.NewCodef(gcode.CodeInvalidParameter, "invalid qos %d", qos)
+			}
+
+			config := service.Config().Get().Mqtt
+			clientId := config.ClientId
+			if clientId == "" {
+				clientId = service.Device().Id()
+			}
+			// 与服务使用相同的客户端 ID 会把服务的连接挤掉
+			clientId = fmt.Sprintf("%s-admin-%d", clientId, os.Getpid())
+			opts := mqtt.NewClientOptions().
+				AddBroker(config.Broker).
+				SetClientID(clientId).
+				SetUsername(config.Username).
+				SetPassword(config.Password).
+				SetConnectTimeout(config.Timeout).
+				SetAutoReconnect(false)
+			client := mqtt.NewClient(opts)
+			if token := client.Connect(); !token.WaitTimeout(config.Timeout) {
+				return gerror.NewCodef(gcode.CodeOperationFailed, "connect to %s timed out after %s", config.Broker, config.Timeout)
+			} else if err = token.Error(); err != nil {
+				return gerror.WrapCodef(gcode.CodeOperationFailed, err, "connect to %s failed", config.Broker)
+			}
+			defer client.Disconnect(250)
+			token := client.Publish(topic, byte(qos), !parser.GetOpt("retain").IsNil(), payload)
+			if !token.WaitTimeout(config.Timeout) {
+				return gerror.NewCodef(gcode.CodeOperationFailed, "publish to %s timed out after %s", topic, config.Timeout)
+			}
+			if err = token.Error(); err != nil {
+				return gerror.Wrapf(err, "publish to %s failed", topic)
+			}
+			fmt.Printf("Published %d bytes to %s on %s as %s\n", len(payload), topic, config.Broker, clientId)
+			return nil
+		},
+	}
+)
+
+func init() {
+	if err := Mqtt.AddCommand(&MqttPublish); err != nil {
+		panic(err)
+	}
+	if err := Main.AddCommand(&Mqtt); err != nil {
+		panic(err)
+	}
+}
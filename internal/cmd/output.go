@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/gclient"
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"demo/internal/service"
+)
+
+// 查询命令的输出格式，-f 选择
+const (
+	outputTable = "table"
+	outputJson  = "json"
+	outputYaml  = "yaml"
+)
+
+var (
+	// formatArgument 查询命令共用的输出格式选项
+	formatArgument = gcmd.Argument{Name: "format", Short: "f", Brief: "output format: table, json or yaml, default table"}
+	// serverArgument 通过本机服务执行的命令共用的服务地址选项
+	serverArgument = gcmd.Argument{Name: "server", Short: "s", Brief: "service URL, default http://127.0.0.1 with the port of server.address"}
+)
+
+// render 按 -f 输出 data，table 格式由 table 写出以制表符分隔的表头和各行
+func render(parser *gcmd.Parser, data interface{}, table func(w io.Writer)) error {
+	switch format := parser.GetOpt("format", outputTable).String(); format {
+	case outputJson:
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+	case outputYaml:
+		// 经 JSON 转换，字段名和时间格式与 json 输出一致
+		content, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		j, err := gjson.LoadJson(content)
+		if err != nil {
+			return err
+		}
+		yaml, err := j.ToYamlString()
+		if err != nil {
+			return err
+		}
+		fmt.Print(yaml)
+	case outputTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported format %s, use table, json or yaml", format)
+	}
+	return nil
+}
+
+// writeFields 按结构体字段顺序输出两列的名称和值，嵌入的结构体展开，其他结构和列表输出为 JSON
+func writeFields(w io.Writer, data interface{}) {
+	value := reflect.Indirect(reflect.ValueOf(data))
+	if value.Kind() != reflect.Struct {
+		fmt.Fprintf(w, "%v\n", data)
+		return
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			writeFields(w, value.Field(i).Interface())
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, cell(value.Field(i).Interface()))
+	}
+}
+
+// cell 返回表格中显示的值，空值显示为 -
+func cell(v interface{}) string {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() || (value.Kind() == reflect.Pointer || value.Kind() == reflect.Map || value.Kind() == reflect.Slice) && value.IsNil() {
+		return "-"
+	}
+	var s string
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		content, _ := json.Marshal(v)
+		s = string(content)
+	case reflect.Pointer, reflect.Struct:
+		if stringer, ok := v.(fmt.Stringer); ok {
+			s = stringer.String()
+		} else {
+			content, _ := json.Marshal(v)
+			s = string(content)
+		}
+	default:
+		s = gconv.String(v)
+	}
+	if s == "" {
+		return "-"
+	}
+	// 换行和制表符会打乱表格
+	return strings.NewReplacer("\n", " ", "\t", " ").Replace(s)
+}
+
+// serviceUrl 返回本机运行中服务的地址
+func serviceUrl(parser *gcmd.Parser) string {
+	if url := parser.GetOpt("server").String(); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	host, port, err := net.SplitHostPort(service.Config().Get().Server.Address)
+	if err != nil {
+		host, port = "", "8000"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// callService 以 JSON 调用本机运行中服务的接口，out 接收响应中的 data。
+// 安装、启动算法需由服务执行，进程才在服务的守护下运行
+func callService(ctx context.Context, parser *gcmd.Parser, method, path string, body interface{}, out interface{}) error {
+	return doService(ctx, parser, g.Client().ContentJson(), method, path, body, out)
+}
+
+// doService 使用 client 调用本机服务接口并解析统一的 {code,message,data} 响应
+func doService(ctx context.Context, parser *gcmd.Parser, client *gclient.Client, method, path string, body interface{}, out interface{}) error {
+	url := serviceUrl(parser) + path
+	response, err := client.DoRequest(ctx, method, url, body)
+	if err != nil {
+		return gerror.WrapCodef(gcode.CodeOperationFailed, err, "call %s failed, is the service running?", url)
+	}
+	defer response.Close()
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	content := response.ReadAll()
+	if err = json.Unmarshal(content, &result); err != nil {
+		return gerror.NewCodef(gcode.CodeOperationFailed, "%s returned %s: %s", url, response.Status, content)
+	}
+	if result.Code != 0 {
+		return gerror.NewCode(gcode.New(result.Code, "", nil), result.Message)
+	}
+	if out != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
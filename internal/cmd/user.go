@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/dao"
+	"demo/internal/model/entity"
+	"demo/internal/service"
+)
+
+var (
+	User = gcmd.Command{
+		Name:  "user",
+		Usage: "user list|get",
+		Brief: "inspect users",
+	}
+
+	UserList = gcmd.Command{
+		Name:      "list",
+		Usage:     "user list [-f table|json|yaml]",
+		Brief:     "list users",
+		Arguments: []gcmd.Argument{formatArgument},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			var list []entity.User
+			if err = dao.User.Ctx(ctx).OrderAsc(dao.User.Columns().Id).Scan(&list); err != nil {
+				return err
+			}
+			return render(parser, list, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tNAME\tSTATUS\tAGE\tCREATED")
+				for _, u := range list {
+					fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", u.Id, u.Name, u.Status, u.Age, cell(u.CreatedAt))
+				}
+			})
+		},
+	}
+
+	UserGet = gcmd.Command{
+		Name:  "get",
+		Usage: "user get ID [-f table|json|yaml]",
+		Brief: "show a user",
+		Arguments: []gcmd.Argument{
+			{Name: "id", IsArg: true, Brief: "user ID"},
+			formatArgument,
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			if err = service.Config().Load(ctx); err != nil {
+				return err
+			}
+			id := parser.GetArg(3).Int64()
+			var user *entity.User
+			if err = dao.User.Ctx(ctx).WherePri(id).Scan(&user); err != nil {
+				return err
+			}
+			if user == nil {
+				return gerror.NewCodef(gcode.CodeNotFound, "user %d not found", id)
+			}
+			return render(parser, user, func(w io.Writer) {
+				writeFields(w, user)
+			})
+		},
+	}
+)
+
+func init() {
+	for _, command := range []*gcmd.Command{&UserList, &UserGet} {
+		if err := User.AddCommand(command); err != nil {
+			panic(err)
+		}
+	}
+	if err := Main.AddCommand(&User); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogf/gf/v2/os/gcmd"
+
+	"demo/internal/consts"
+)
+
+var (
+	Version = gcmd.Command{
+		Name:  "version",
+		Usage: "version",
+		Brief: "print application version",
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			fmt.Println(consts.Version)
+			return nil
+		},
+	}
+)
+
+func init() {
+	if err := Main.AddCommand(&Version); err != nil {
+		panic(err)
+	}
+}
@@ -1 +1,93 @@
 package consts
+
+import "github.com/gogf/gf/v2/os/gctx"
+
+// Version 应用版本，发布构建时通过 -ldflags "-X demo/internal/consts.Version=x.y.z" 注入
+var Version = "dev"
+
+// MQTT 主题，%s 为设备ID
+const (
+	TopicHeartbeat = "i800/%s/heartbeat" // 设备心跳
+	TopicCommand   = "i800/%s/command"   // 云端下发命令
+	TopicReply     = "i800/%s/reply"     // 命令执行结果
+	TopicRegister  = "i800/%s/register"  // 设备注册信息，保留消息
+)
+
+// AlgorithmSourceLocal 离线上传或从导入目录安装的算法，记录在 algorithm_data_url 中代替下载地址
+const AlgorithmSourceLocal = "local"
+
+// 算法期望运行状态，持久化在 algorithm.run_state
+const (
+	RunStateRunning = "running" // 期望运行
+	RunStateStopped = "stopped" // 期望停止
+)
+
+// 算法进程状态
+const (
+	ProcessStateStarting = "starting" // 启动中
+	ProcessStateRunning  = "running"  // 运行中
+	ProcessStateCrashed  = "crashed"  // 启动失败或意外退出，等待重启
+	ProcessStateStopped  = "stopped"  // 已停止
+)
+
+// 命令处理状态，持久化在 processed_command.status
+const (
+	CommandStatusRunning = "running" // 执行中，重复下发时不再执行也不回复
+	CommandStatusDone    = "done"    // 已完成，重复下发时返回缓存的结果
+)
+
+// 云端下发的设备配置版本状态，持久化在 device_config.status
+const (
+	DeviceConfigPending    = "pending"    // 已应用，回滚时间窗内失去连接则回滚
+	DeviceConfigConfirmed  = "confirmed"  // 回滚时间窗内连接正常，已确认
+	DeviceConfigReverted   = "reverted"   // 已回滚到上一个已确认的版本
+	DeviceConfigSuperseded = "superseded" // 确认前被新版本替换
+)
+
+// 程序自升级状态
+const (
+	UpgradeDownloading = "downloading" // 正在下载新程序
+	UpgradeRestarting  = "restarting"  // 已替换程序，正在重启
+	UpgradeVerifying   = "verifying"   // 新程序已启动，等待通过健康检查
+	UpgradeSucceeded   = "succeeded"   // 新程序通过健康检查
+	UpgradeRolledBack  = "rolledBack"  // 新程序未通过健康检查，已恢复旧程序
+	UpgradeFailed      = "failed"      // 下载或替换失败，未重启
+)
+
+// 批量操作任务状态
+const (
+	BatchJobRunning = "running" // 执行中
+	BatchJobDone    = "done"    // 全部条目已结束
+)
+
+// 批量操作条目状态
+const (
+	BatchItemPending = "pending" // 等待执行
+	BatchItemRunning = "running" // 执行中
+	BatchItemSuccess = "success" // 成功
+	BatchItemFailed  = "failed"  // 失败
+)
+
+// 健康检查状态
+const (
+	HealthStatusOk   = "ok"   // 正常
+	HealthStatusWarn = "warn" // 可选依赖异常，不影响就绪
+	HealthStatusFail = "fail" // 异常
+)
+
+// 日志子系统，对应 g.Log(name) 的日志实例名，级别可通过 log.levels.<name> 配置或运行时修改
+const (
+	LoggerApp        = "app"        // 默认日志实例，启动、HTTP 和框架日志
+	LoggerAlgorithm  = "algorithm"  // 算法安装与配置
+	LoggerCommand    = "command"    // 云端命令分发
+	LoggerDatabase   = "database"   // 数据库初始化、迁移与备份
+	LoggerDiscovery  = "discovery"  // 局域网设备发现
+	LoggerDownload   = "download"   // 算法包下载
+	LoggerMqtt       = "mqtt"       // MQTT 连接与心跳
+	LoggerSchedule   = "schedule"   // 运行时间窗调度
+	LoggerStorage    = "storage"    // 算法存储管理
+	LoggerSupervisor = "supervisor" // 算法进程守护
+)
+
+// CtxKeyCmdId 命令上下文中的 cmdId，输出在日志的 CtxStr 字段中
+const CtxKeyCmdId gctx.StrKey = "cmdId"
@@ -3,12 +3,19 @@ package algorithm
 import (
 	"context"
 
-	"github.com/gogf/gf/v2/errors/gcode"
-	"github.com/gogf/gf/v2/errors/gerror"
-
 	"demo/api/algorithm/v1"
+	"demo/internal/model"
+	"demo/internal/service"
 )
 
 func (c *ControllerV1) Add(ctx context.Context, req *v1.AddReq) (res *v1.AddRes, err error) {
-	return nil, gerror.NewCode(gcode.CodeNotImplemented)
+	id, err := service.Algorithm().Install(ctx, model.AlgorithmInstallInput{AlgorithmAddInput: req.AlgorithmAddInput})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.AddRes{
+		Id:      id,
+		Success: true,
+		Message: "algorithm installed",
+	}, nil
 }
@@ -0,0 +1,21 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) BatchActivate(ctx context.Context, req *v1.BatchActivateReq) (res *v1.BatchActivateRes, err error) {
+	job, err := service.Batch().Activate(ctx, "", req.AlgorithmIds)
+	if err != nil {
+		return nil, err
+	}
+	if req.Wait {
+		if job, err = service.Batch().Wait(ctx, job.JobId); err != nil {
+			return nil, err
+		}
+	}
+	return &v1.BatchActivateRes{BatchJob: job}, nil
+}
@@ -0,0 +1,26 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/model"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) BatchAdd(ctx context.Context, req *v1.BatchAddReq) (res *v1.BatchAddRes, err error) {
+	inputs := make([]model.AlgorithmInstallInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = model.AlgorithmInstallInput{AlgorithmAddInput: item}
+	}
+	job, err := service.Batch().Add(ctx, "", inputs)
+	if err != nil {
+		return nil, err
+	}
+	if req.Wait {
+		if job, err = service.Batch().Wait(ctx, job.JobId); err != nil {
+			return nil, err
+		}
+	}
+	return &v1.BatchAddRes{BatchJob: job}, nil
+}
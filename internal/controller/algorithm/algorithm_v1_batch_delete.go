@@ -0,0 +1,21 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) BatchDelete(ctx context.Context, req *v1.BatchDeleteReq) (res *v1.BatchDeleteRes, err error) {
+	job, err := service.Batch().Delete(ctx, "", req.AlgorithmIds)
+	if err != nil {
+		return nil, err
+	}
+	if req.Wait {
+		if job, err = service.Batch().Wait(ctx, job.JobId); err != nil {
+			return nil, err
+		}
+	}
+	return &v1.BatchDeleteRes{BatchJob: job}, nil
+}
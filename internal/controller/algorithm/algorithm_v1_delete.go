@@ -3,12 +3,17 @@ package algorithm
 import (
 	"context"
 
-	"github.com/gogf/gf/v2/errors/gcode"
-	"github.com/gogf/gf/v2/errors/gerror"
-
 	"demo/api/algorithm/v1"
+	"demo/internal/service"
 )
 
 func (c *ControllerV1) Delete(ctx context.Context, req *v1.DeleteReq) (res *v1.DeleteRes, err error) {
-	return nil, gerror.NewCode(gcode.CodeNotImplemented)
+	algorithm, err := service.Algorithm().GetById(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = service.Algorithm().Delete(ctx, algorithm.AlgorithmId); err != nil {
+		return nil, err
+	}
+	return &v1.DeleteRes{Success: true, Message: "algorithm deleted"}, nil
 }
@@ -0,0 +1,16 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetBatch(ctx context.Context, req *v1.GetBatchReq) (res *v1.GetBatchRes, err error) {
+	job, err := service.Batch().Get(req.JobId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetBatchRes{BatchJob: job}, nil
+}
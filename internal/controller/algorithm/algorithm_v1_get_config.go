@@ -0,0 +1,34 @@
+package algorithm
+
+import (
+	"context"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetConfig(ctx context.Context, req *v1.GetConfigReq) (res *v1.GetConfigRes, err error) {
+	algorithm, err := service.Algorithm().GetById(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	config, err := service.AlgorithmConfig().Get(ctx, algorithm.AlgorithmId, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+	res = &v1.GetConfigRes{AlgorithmId: algorithm.AlgorithmId}
+	if config == nil {
+		if req.Revision > 0 {
+			return nil, gerror.NewCodef(gcode.CodeNotFound, "config revision %d not found", req.Revision)
+		}
+		return res, nil
+	}
+	res.Revision = config.Revision
+	res.CreatedAt = config.CreatedAt
+	res.Config, err = gjson.Decode(config.Content)
+	return res, err
+}
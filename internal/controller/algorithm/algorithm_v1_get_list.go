@@ -3,12 +3,23 @@ package algorithm
 import (
 	"context"
 
-	"github.com/gogf/gf/v2/errors/gcode"
-	"github.com/gogf/gf/v2/errors/gerror"
-
 	"demo/api/algorithm/v1"
+	"demo/internal/dao"
 )
 
 func (c *ControllerV1) GetList(ctx context.Context, req *v1.GetListReq) (res *v1.GetListRes, err error) {
-	return nil, gerror.NewCode(gcode.CodeNotImplemented)
+	page, pageSize := 1, 20
+	if req.Page != nil {
+		page = *req.Page
+	}
+	if req.PageSize != nil {
+		pageSize = *req.PageSize
+	}
+	res = &v1.GetListRes{Page: page}
+	m := dao.Algorithm.Ctx(ctx)
+	if req.Name != "" {
+		m = m.WhereLike(dao.Algorithm.Columns().AlgorithmName, "%"+req.Name+"%")
+	}
+	err = m.Page(page, pageSize).OrderAsc(dao.Algorithm.Columns().Id).ScanAndCount(&res.List, &res.Total, false)
+	return
 }
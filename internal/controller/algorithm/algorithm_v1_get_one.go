@@ -7,8 +7,22 @@ import (
 	"github.com/gogf/gf/v2/errors/gerror"
 
 	"demo/api/algorithm/v1"
+	"demo/internal/dao"
 )
 
 func (c *ControllerV1) GetOne(ctx context.Context, req *v1.GetOneReq) (res *v1.GetOneRes, err error) {
-	return nil, gerror.NewCode(gcode.CodeNotImplemented)
+	res = &v1.GetOneRes{}
+	m := dao.Algorithm.Ctx(ctx)
+	if req.AlgorithmId != "" {
+		m = m.Where(dao.Algorithm.Columns().AlgorithmId, req.AlgorithmId)
+	} else {
+		m = m.WherePri(req.Id)
+	}
+	if err = m.Scan(&res.Algorithm); err != nil {
+		return nil, err
+	}
+	if res.Algorithm == nil {
+		return nil, gerror.NewCode(gcode.CodeNotFound, "algorithm not found")
+	}
+	return res, nil
 }
@@ -0,0 +1,20 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) SetConfig(ctx context.Context, req *v1.SetConfigReq) (res *v1.SetConfigRes, err error) {
+	algorithm, err := service.Algorithm().GetById(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := service.AlgorithmConfig().Set(ctx, algorithm.AlgorithmId, req.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.SetConfigRes{Revision: revision}, nil
+}
@@ -0,0 +1,19 @@
+package algorithm
+
+import (
+	"context"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Start(ctx context.Context, req *v1.StartReq) (res *v1.StartRes, err error) {
+	algorithm, err := service.Algorithm().GetById(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = service.Algorithm().Start(ctx, algorithm.AlgorithmId); err != nil {
+		return nil, err
+	}
+	return &v1.StartRes{Success: true, Message: "algorithm started"}, nil
+}
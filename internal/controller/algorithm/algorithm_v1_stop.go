@@ -0,0 +1,20 @@
+package algorithm
+
+import (
+	"context"
+	"time"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Stop(ctx context.Context, req *v1.StopReq) (res *v1.StopRes, err error) {
+	algorithm, err := service.Algorithm().GetById(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = service.Algorithm().Stop(ctx, algorithm.AlgorithmId, time.Duration(req.Timeout)*time.Second); err != nil {
+		return nil, err
+	}
+	return &v1.StopRes{Success: true, Message: "algorithm stopped"}, nil
+}
@@ -0,0 +1,47 @@
+package algorithm
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/net/ghttp"
+
+	"demo/api/algorithm/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Upload(ctx context.Context, req *v1.UploadReq) (res *v1.UploadRes, err error) {
+	// 元数据以文件形式上传时优先使用文件内容
+	metadata := []byte(req.Metadata)
+	if file := ghttp.RequestFromCtx(ctx).GetUploadFile("metadata"); file != nil {
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		metadata, err = io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name, err := req.File.Save(service.Import().UploadPath(), true)
+	if err != nil {
+		return nil, gerror.Wrap(err, "save uploaded package failed")
+	}
+	packageFile := filepath.Join(service.Import().UploadPath(), name)
+	defer os.Remove(packageFile)
+
+	id, err := service.Import().Install(ctx, packageFile, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UploadRes{
+		Id:      id,
+		Success: true,
+		Message: "algorithm installed",
+	}, nil
+}
@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package database
+
+import (
+	"demo/api/database"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() database.IDatabaseV1 {
+	return &ControllerV1{}
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) CreateBackup(ctx context.Context, req *v1.CreateBackupReq) (res *v1.CreateBackupRes, err error) {
+	backup, err := service.Backup().Create(ctx, service.BackupManual)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.CreateBackupRes{DatabaseBackup: backup}, nil
+}
@@ -0,0 +1,12 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) DeleteBackup(ctx context.Context, req *v1.DeleteBackupReq) (res *v1.DeleteBackupRes, err error) {
+	return nil, service.Backup().Delete(ctx, req.Name)
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) DownloadBackup(ctx context.Context, req *v1.DownloadBackupReq) (res *v1.DownloadBackupRes, err error) {
+	file, err := service.Backup().File(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	g.RequestFromCtx(ctx).Response.ServeFileDownload(file)
+	return nil, nil
+}
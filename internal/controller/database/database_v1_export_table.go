@@ -0,0 +1,29 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) ExportTable(ctx context.Context, req *v1.ExportTableReq) (res *v1.ExportTableRes, err error) {
+	// 先导出到缓冲区，出错时仍按统一格式返回错误
+	var buffer bytes.Buffer
+	if err = service.Backup().Export(ctx, req.Table, req.Format, &buffer); err != nil {
+		return nil, err
+	}
+	r := g.RequestFromCtx(ctx)
+	contentType := "application/json"
+	if req.Format == service.TableFormatCsv {
+		contentType = "text/csv"
+	}
+	r.Response.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	r.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, req.Table, req.Format))
+	r.Response.Write(buffer.Bytes())
+	return nil, nil
+}
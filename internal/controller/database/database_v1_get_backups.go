@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetBackups(ctx context.Context, req *v1.GetBackupsReq) (res *v1.GetBackupsRes, err error) {
+	list, err := service.Backup().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetBackupsRes{List: list}, nil
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetTables(ctx context.Context, req *v1.GetTablesReq) (res *v1.GetTablesRes, err error) {
+	list, err := service.Backup().Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetTablesRes{List: list}, nil
+}
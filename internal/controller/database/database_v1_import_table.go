@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) ImportTable(ctx context.Context, req *v1.ImportTableReq) (res *v1.ImportTableRes, err error) {
+	f, err := req.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := service.Backup().Import(ctx, req.Table, req.Format, f, req.Mode == "replace")
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ImportTableRes{Rows: rows}, nil
+}
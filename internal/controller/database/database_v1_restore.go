@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Restore(ctx context.Context, req *v1.RestoreReq) (res *v1.RestoreRes, err error) {
+	file, err := service.Backup().File(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	// 恢复后重启，使算法进程和各服务重新加载恢复后的状态
+	out, err := service.Backup().Restore(ctx, file, true)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RestoreRes{DatabaseRestoreOutput: out}, nil
+}
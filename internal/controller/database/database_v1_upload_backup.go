@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+
+	"demo/api/database/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) UploadBackup(ctx context.Context, req *v1.UploadBackupReq) (res *v1.UploadBackupRes, err error) {
+	// 以随机名称暂存，检查通过后再使用上传的文件名
+	name, err := req.File.Save(service.Backup().Path(), true)
+	if err != nil {
+		return nil, gerror.Wrap(err, "save uploaded backup failed")
+	}
+	file := filepath.Join(service.Backup().Path(), name)
+	defer os.Remove(file)
+
+	backup, err := service.Backup().Add(ctx, file, req.File.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.UploadBackupRes{DatabaseBackup: backup}, nil
+}
@@ -0,0 +1,12 @@
+package download
+
+import (
+	"context"
+
+	"demo/api/download/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetThrottle(ctx context.Context, req *v1.GetThrottleReq) (res *v1.GetThrottleRes, err error) {
+	return &v1.GetThrottleRes{DownloadThrottleStatus: service.Throttle().Status(ctx)}, nil
+}
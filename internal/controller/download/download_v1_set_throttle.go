@@ -0,0 +1,21 @@
+package download
+
+import (
+	"context"
+
+	"demo/api/download/v1"
+	"demo/internal/model"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) SetThrottle(ctx context.Context, req *v1.SetThrottleReq) (res *v1.SetThrottleRes, err error) {
+	if req.Reset {
+		service.Throttle().Reset(ctx)
+	} else if err = service.Throttle().Set(ctx, model.DownloadThrottle{
+		RateLimit: req.RateLimit,
+		Windows:   req.Windows,
+	}); err != nil {
+		return nil, err
+	}
+	return &v1.SetThrottleRes{DownloadThrottleStatus: service.Throttle().Status(ctx)}, nil
+}
@@ -0,0 +1,24 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package health
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// writeReport 直接输出检查结果，探针依赖 HTTP 状态码：失败返回 503，其余返回 200
+func writeReport(ctx context.Context, report *model.HealthReport) {
+	r := g.RequestFromCtx(ctx)
+	if report.Status == consts.HealthStatusFail {
+		r.Response.WriteHeader(http.StatusServiceUnavailable)
+	}
+	r.Response.WriteJson(report)
+}
@@ -0,0 +1,13 @@
+package health
+
+import (
+	"context"
+
+	"demo/api/health/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Liveness(ctx context.Context, req *v1.LivenessReq) (res *v1.LivenessRes, err error) {
+	writeReport(ctx, service.Health().Liveness(ctx))
+	return nil, nil
+}
@@ -0,0 +1,13 @@
+package health
+
+import (
+	"context"
+
+	"demo/api/health/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Readiness(ctx context.Context, req *v1.ReadinessReq) (res *v1.ReadinessRes, err error) {
+	writeReport(ctx, service.Health().Readiness(ctx))
+	return nil, nil
+}
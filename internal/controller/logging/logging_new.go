@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package logging
+
+import (
+	"demo/api/logging"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() logging.ILoggingV1 {
+	return &ControllerV1{}
+}
@@ -0,0 +1,12 @@
+package logging
+
+import (
+	"context"
+
+	"demo/api/logging/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetLevels(ctx context.Context, req *v1.GetLevelsReq) (res *v1.GetLevelsRes, err error) {
+	return &v1.GetLevelsRes{List: service.Logging().Levels()}, nil
+}
@@ -0,0 +1,15 @@
+package logging
+
+import (
+	"context"
+
+	"demo/api/logging/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) SetLevel(ctx context.Context, req *v1.SetLevelReq) (res *v1.SetLevelRes, err error) {
+	if err = service.Logging().SetLevel(req.Subsystem, req.Level); err != nil {
+		return nil, err
+	}
+	return &v1.SetLevelRes{List: service.Logging().Levels()}, nil
+}
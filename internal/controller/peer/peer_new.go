@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package peer
+
+import (
+	"demo/api/peer"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() peer.IPeerV1 {
+	return &ControllerV1{}
+}
@@ -0,0 +1,12 @@
+package peer
+
+import (
+	"context"
+
+	"demo/api/peer/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetList(ctx context.Context, req *v1.GetListReq) (res *v1.GetListRes, err error) {
+	return &v1.GetListRes{List: service.Discovery().Peers()}, nil
+}
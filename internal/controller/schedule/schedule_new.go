@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package schedule
+
+import (
+	"demo/api/schedule"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() schedule.IScheduleV1 {
+	return &ControllerV1{}
+}
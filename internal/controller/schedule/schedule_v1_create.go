@@ -0,0 +1,16 @@
+package schedule
+
+import (
+	"context"
+
+	"demo/api/schedule/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Create(ctx context.Context, req *v1.CreateReq) (res *v1.CreateRes, err error) {
+	id, err := service.Schedule().Create(ctx, req.AlgorithmId, req.ScheduleInput)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.CreateRes{Id: id}, nil
+}
@@ -0,0 +1,13 @@
+package schedule
+
+import (
+	"context"
+
+	"demo/api/schedule/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Delete(ctx context.Context, req *v1.DeleteReq) (res *v1.DeleteRes, err error) {
+	err = service.Schedule().Delete(ctx, req.Id)
+	return
+}
@@ -0,0 +1,16 @@
+package schedule
+
+import (
+	"context"
+
+	"demo/api/schedule/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetList(ctx context.Context, req *v1.GetListReq) (res *v1.GetListRes, err error) {
+	list, err := service.Schedule().List(ctx, req.AlgorithmId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetListRes{List: list}, nil
+}
@@ -0,0 +1,16 @@
+package schedule
+
+import (
+	"context"
+
+	"demo/api/schedule/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetTransitions(ctx context.Context, req *v1.GetTransitionsReq) (res *v1.GetTransitionsRes, err error) {
+	list, err := service.Schedule().Transitions(ctx, req.AlgorithmId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetTransitionsRes{List: list}, nil
+}
@@ -0,0 +1,13 @@
+package schedule
+
+import (
+	"context"
+
+	"demo/api/schedule/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Update(ctx context.Context, req *v1.UpdateReq) (res *v1.UpdateRes, err error) {
+	err = service.Schedule().Update(ctx, req.Id, req.ScheduleInput)
+	return
+}
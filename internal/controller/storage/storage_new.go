@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package storage
+
+import (
+	"demo/api/storage"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() storage.IStorageV1 {
+	return &ControllerV1{}
+}
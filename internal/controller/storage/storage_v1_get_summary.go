@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+
+	"demo/api/storage/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetSummary(ctx context.Context, req *v1.GetSummaryReq) (res *v1.GetSummaryRes, err error) {
+	summary, err := service.Storage().Summary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetSummaryRes{StorageSummary: summary}, nil
+}
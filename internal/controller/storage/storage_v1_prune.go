@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+
+	"demo/api/storage/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Prune(ctx context.Context, req *v1.PruneReq) (res *v1.PruneRes, err error) {
+	keep := -1
+	if req.Keep != nil {
+		keep = *req.Keep
+	}
+	out, err := service.Storage().Prune(ctx, req.AlgorithmId, keep)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.PruneRes{StoragePruneOutput: out}, nil
+}
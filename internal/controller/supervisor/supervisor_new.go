@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package supervisor
+
+import (
+	"demo/api/supervisor"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() supervisor.ISupervisorV1 {
+	return &ControllerV1{}
+}
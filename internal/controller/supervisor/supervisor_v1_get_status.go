@@ -0,0 +1,19 @@
+package supervisor
+
+import (
+	"context"
+
+	"demo/api/supervisor/v1"
+	"demo/internal/model"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetStatus(ctx context.Context, req *v1.GetStatusReq) (res *v1.GetStatusRes, err error) {
+	res = &v1.GetStatusRes{List: make([]model.AlgorithmRuntimeStatus, 0)}
+	for _, status := range service.Supervisor().Status() {
+		if req.AlgorithmId == "" || status.AlgorithmId == req.AlgorithmId {
+			res.List = append(res.List, status)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,15 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package transport
+
+import (
+	"demo/api/transport"
+)
+
+type ControllerV1 struct{}
+
+func NewV1() transport.ITransportV1 {
+	return &ControllerV1{}
+}
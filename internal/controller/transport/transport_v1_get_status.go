@@ -0,0 +1,17 @@
+package transport
+
+import (
+	"context"
+
+	"demo/api/transport/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) GetStatus(ctx context.Context, req *v1.GetStatusReq) (res *v1.GetStatusRes, err error) {
+	t := service.Transport()
+	return &v1.GetStatusRes{
+		Type:      t.Name(),
+		Enabled:   t.Enabled(),
+		Connected: t.IsConnected(),
+	}, nil
+}
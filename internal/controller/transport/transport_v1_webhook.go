@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/net/ghttp"
+
+	"demo/api/transport/v1"
+	"demo/internal/service"
+)
+
+func (c *ControllerV1) Webhook(ctx context.Context, req *v1.WebhookReq) (res *v1.WebhookRes, err error) {
+	webhook, ok := service.Transport().(service.IWebhook)
+	if !ok {
+		return nil, gerror.NewCode(gcode.CodeNotSupported, "webhook transport is not enabled")
+	}
+	r := ghttp.RequestFromCtx(ctx)
+	if err = webhook.Receive(ctx, r.GetBody(), r.Header); err != nil {
+		return nil, err
+	}
+	return &v1.WebhookRes{Accepted: true}, nil
+}
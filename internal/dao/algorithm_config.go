@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"demo/internal/dao/internal"
+)
+
+// algorithmConfigDao is the data access object for the table algorithm_config.
+// You can define custom methods on it to extend its functionality as needed.
+type algorithmConfigDao struct {
+	*internal.AlgorithmConfigDao
+}
+
+var (
+	// AlgorithmConfig is a globally accessible object for table algorithm_config operations.
+	AlgorithmConfig = algorithmConfigDao{internal.NewAlgorithmConfigDao()}
+)
+
+// Add your custom methods and functionality below.
@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"demo/internal/dao/internal"
+)
+
+// algorithmScheduleDao is the data access object for the table algorithm_schedule.
+// You can define custom methods on it to extend its functionality as needed.
+type algorithmScheduleDao struct {
+	*internal.AlgorithmScheduleDao
+}
+
+var (
+	// AlgorithmSchedule is a globally accessible object for table algorithm_schedule operations.
+	AlgorithmSchedule = algorithmScheduleDao{internal.NewAlgorithmScheduleDao()}
+)
+
+// Add your custom methods and functionality below.
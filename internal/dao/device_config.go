@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"demo/internal/dao/internal"
+)
+
+// deviceConfigDao is the data access object for the table device_config.
+// You can define custom methods on it to extend its functionality as needed.
+type deviceConfigDao struct {
+	*internal.DeviceConfigDao
+}
+
+var (
+	// DeviceConfig is a globally accessible object for table device_config operations.
+	DeviceConfig = deviceConfigDao{internal.NewDeviceConfigDao()}
+)
+
+// Add your custom methods and functionality below.
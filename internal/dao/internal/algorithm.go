@@ -30,6 +30,9 @@ type AlgorithmColumns struct {
 	FileSize           string //
 	Md5                string //
 	LocalPath          string //
+	DigestAlgo         string //
+	Digest             string //
+	RunState           string //
 }
 
 // algorithmColumns holds the columns for the table algorithm.
@@ -43,6 +46,9 @@ var algorithmColumns = AlgorithmColumns{
 	FileSize:           "file_size",
 	Md5:                "md5",
 	LocalPath:          "local_path",
+	DigestAlgo:         "digest_algo",
+	Digest:             "digest",
+	RunState:           "run_state",
 }
 
 // NewAlgorithmDao creates and returns a new DAO object for table data access.
@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"demo/internal/dao/internal"
+)
+
+// processedCommandDao is the data access object for the table processed_command.
+// You can define custom methods on it to extend its functionality as needed.
+type processedCommandDao struct {
+	*internal.ProcessedCommandDao
+}
+
+var (
+	// ProcessedCommand is a globally accessible object for table processed_command operations.
+	ProcessedCommand = processedCommandDao{internal.NewProcessedCommandDao()}
+)
+
+// Add your custom methods and functionality below.
@@ -0,0 +1,209 @@
+// Package fakecloud 本地模拟云端 HTTP 命令通道，用于 cmd/fakecloud 和 HTTP 通道的测试。
+// 指定 webhook 时命令直接推送到设备的 /transport/webhook，否则进入队列等待设备长轮询。
+package fakecloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// device 单个设备的命令队列、回复、注册信息和最近一次心跳
+type device struct {
+	commands     []json.RawMessage
+	notify       chan struct{} // 有新命令时关闭，唤醒长轮询
+	replies      []json.RawMessage
+	heartbeat    json.RawMessage
+	registration json.RawMessage
+}
+
+// Cloud 模拟云端，按设备保存命令队列和设备上报的内容
+type Cloud struct {
+	token   string
+	webhook string
+	mu      sync.Mutex
+	devices map[string]*device
+}
+
+// New 创建模拟云端，token 不为空时校验设备请求的令牌，webhook 不为空时命令推送到该地址
+func New(token, webhook string) *Cloud {
+	return &Cloud{token: token, webhook: webhook, devices: make(map[string]*device)}
+}
+
+// Handler 返回模拟云端的 HTTP 接口
+func (c *Cloud) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices/{id}/commands", c.auth(c.poll))
+	mux.HandleFunc("POST /devices/{id}/commands", c.enqueue)
+	mux.HandleFunc("POST /devices/{id}/replies", c.auth(c.reply))
+	mux.HandleFunc("GET /devices/{id}/replies", c.listReplies)
+	mux.HandleFunc("POST /devices/{id}/heartbeat", c.auth(c.saveHeartbeat))
+	mux.HandleFunc("GET /devices/{id}/heartbeat", c.getHeartbeat)
+	mux.HandleFunc("POST /devices/{id}/register", c.auth(c.register))
+	mux.HandleFunc("GET /devices/{id}/register", c.getRegistration)
+	return mux
+}
+
+// auth 校验设备请求的令牌
+func (c *Cloud) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c.token != "" && r.Header.Get("Authorization") != "Bearer "+c.token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// device 获取或创建设备记录，调用方持有锁
+func (c *Cloud) device(id string) *device {
+	d, ok := c.devices[id]
+	if !ok {
+		d = &device{notify: make(chan struct{})}
+		c.devices[id] = d
+	}
+	return d
+}
+
+// poll 长轮询：有命令时立即返回全部命令，否则等待 wait 秒后返回 204
+func (c *Cloud) poll(w http.ResponseWriter, r *http.Request) {
+	wait, _ := strconv.Atoi(r.URL.Query().Get("wait"))
+	deadline := time.After(time.Duration(wait) * time.Second)
+	for {
+		c.mu.Lock()
+		d := c.device(r.PathValue("id"))
+		if len(d.commands) > 0 {
+			commands := d.commands
+			d.commands = nil
+			c.mu.Unlock()
+			writeJson(w, commands)
+			return
+		}
+		notify := d.notify
+		c.mu.Unlock()
+		select {
+		case <-notify:
+		case <-deadline:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// enqueue 下发命令：配置了 webhook 时推送给设备，否则放入队列
+func (c *Cloud) enqueue(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "invalid command", http.StatusBadRequest)
+		return
+	}
+	if c.webhook != "" {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, c.webhook, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+	c.mu.Lock()
+	d := c.device(r.PathValue("id"))
+	d.commands = append(d.commands, body)
+	close(d.notify)
+	d.notify = make(chan struct{})
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// reply 保存设备回复
+func (c *Cloud) reply(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "invalid reply", http.StatusBadRequest)
+		return
+	}
+	log.Printf("reply from %s: %s", r.PathValue("id"), body)
+	c.mu.Lock()
+	d := c.device(r.PathValue("id"))
+	d.replies = append(d.replies, body)
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listReplies 返回设备的全部回复
+func (c *Cloud) listReplies(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	replies := append([]json.RawMessage{}, c.device(r.PathValue("id")).replies...)
+	c.mu.Unlock()
+	writeJson(w, replies)
+}
+
+// saveHeartbeat 保存设备最近一次心跳
+func (c *Cloud) saveHeartbeat(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "invalid heartbeat", http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.device(r.PathValue("id")).heartbeat = body
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getHeartbeat 返回设备最近一次心跳
+func (c *Cloud) getHeartbeat(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	heartbeat := c.device(r.PathValue("id")).heartbeat
+	c.mu.Unlock()
+	if heartbeat == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJson(w, heartbeat)
+}
+
+// register 保存设备注册信息
+func (c *Cloud) register(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "invalid registration", http.StatusBadRequest)
+		return
+	}
+	log.Printf("registration from %s: %s", r.PathValue("id"), body)
+	c.mu.Lock()
+	c.device(r.PathValue("id")).registration = body
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getRegistration 返回设备注册信息
+func (c *Cloud) getRegistration(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	registration := c.device(r.PathValue("id")).registration
+	c.mu.Unlock()
+	if registration == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJson(w, registration)
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// AlgorithmAddInput 算法下发字段，POST /algorithm、批量添加和对应的云端命令共用
+type AlgorithmAddInput struct {
+	AlgorithmId        string `json:"algorithmId"        v:"required|regex:^[A-Za-z0-9._-]+$|not-in:.,.." dc:"Algorithm unique ID"`
+	AlgorithmName      string `json:"algorithmName"      v:"required" dc:"Algorithm name"`
+	AlgorithmVersion   string `json:"algorithmVersion"   v:"required" dc:"Algorithm version"`
+	AlgorithmVersionId string `json:"algorithmVersionId" v:"required|regex:^[A-Za-z0-9._-]+$|not-in:.,.." dc:"Algorithm version ID"`
+	AlgorithmDataUrl   string `json:"algorithmDataUrl"   v:"required|url" dc:"Algorithm download URL"`
+	FileSize           int64  `json:"fileSize"           v:"required|min:1" dc:"File size in bytes"`
+	Md5                string `json:"md5"                v:"required-without-all:sha256,digest|length:32,32" dc:"MD5 checksum"`
+	Sha256             string `json:"sha256"             v:"length:64,64" dc:"SHA-256 checksum"`
+	Digest             string `json:"digest"             v:"regex:^[a-z0-9]+:[0-9a-fA-F]+$" dc:"Digest in the form algo:hex, e.g. sha256:..."`
+}
+
+// AlgorithmInstallInput 算法安装输入参数
+type AlgorithmInstallInput struct {
+	AlgorithmAddInput
+	PackageFile string // 本地算法包路径，非空时从该文件导入而不下载 AlgorithmDataUrl
+}
+
+// AlgorithmMetadata 离线导入算法包的元数据，上传时随包提交，导入目录中为与算法包同名的 .json 文件，
+// 字段与 AlgorithmAddInput 相同，不需要下载地址
+type AlgorithmMetadata struct {
+	AlgorithmId        string `json:"algorithmId"        v:"required|regex:^[A-Za-z0-9._-]+$|not-in:.,.."`
+	AlgorithmName      string `json:"algorithmName"      v:"required"`
+	AlgorithmVersion   string `json:"algorithmVersion"   v:"required"`
+	AlgorithmVersionId string `json:"algorithmVersionId" v:"required|regex:^[A-Za-z0-9._-]+$|not-in:.,.."`
+	FileSize           int64  `json:"fileSize"           v:"required|min:1"`
+	Md5                string `json:"md5"                v:"required-without-all:sha256,digest|length:32,32"`
+	Sha256             string `json:"sha256"             v:"length:64,64"`
+	Digest             string `json:"digest"             v:"regex:^[a-z0-9]+:[0-9a-fA-F]+$"`
+}
+
+// AlgorithmManifest 算法包清单，对应算法包根目录下的 manifest.json
+type AlgorithmManifest struct {
+	Entrypoint   string            `json:"entrypoint"`   // 启动入口，相对于算法包根目录
+	Args         []string          `json:"args"`         // 启动参数
+	Env          map[string]string `json:"env"`          // 额外环境变量
+	ConfigSchema interface{}       `json:"configSchema"` // 配置参数 JSON Schema，内联对象或相对于算法包根目录的文件路径
+	ConfigFile   string            `json:"configFile"`   // 配置文件名，写入算法包根目录，默认 config.json
+}
+
+// AlgorithmVerifyResult 已安装算法的文件校验结果
+type AlgorithmVerifyResult struct {
+	AlgorithmId        string   `json:"algorithmId"        dc:"Algorithm unique ID"`
+	AlgorithmVersionId string   `json:"algorithmVersionId" dc:"Installed algorithm version ID"`
+	Digest             string   `json:"digest"             dc:"Recorded package digest in the form algo:hex"`
+	Entrypoint         string   `json:"entrypoint"         dc:"Entrypoint path"`
+	Ok                 bool     `json:"ok"                 dc:"Whether all checks passed"`
+	Problems           []string `json:"problems"           dc:"Failed checks"`
+}
+
+// AlgorithmRuntimeStatus 算法运行状态
+type AlgorithmRuntimeStatus struct {
+	AlgorithmId        string      `json:"algorithmId"        dc:"Algorithm unique ID"`
+	AlgorithmVersionId string      `json:"algorithmVersionId" dc:"Running algorithm version ID"`
+	State              string      `json:"state"              dc:"Process state: starting, running, crashed, stopped"`
+	Pid                int         `json:"pid"                dc:"Process ID"`
+	Restarts           int         `json:"restarts"           dc:"Restart count since supervised"`
+	StartedAt          *gtime.Time `json:"startedAt"          dc:"Last start time"`
+	LastExit           string      `json:"lastExit"           dc:"Last exit reason"`
+	LastExitAt         *gtime.Time `json:"lastExitAt"         dc:"Last exit time"`
+	Backoff            int64       `json:"backoff"            dc:"Delay before restarting a crashed process in milliseconds, 0 when not waiting"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// BatchJob 批量算法操作任务，各条目并发执行，执行中可按 jobId 查询进度
+type BatchJob struct {
+	JobId      string            `json:"jobId"      dc:"Job ID"`
+	Operation  string            `json:"operation"  dc:"Operation: add, delete, activate"`
+	Status     string            `json:"status"     dc:"Job status: running, done"`
+	Total      int               `json:"total"      dc:"Item count"`
+	Completed  int               `json:"completed"  dc:"Finished item count"`
+	Succeeded  int               `json:"succeeded"  dc:"Succeeded item count"`
+	Failed     int               `json:"failed"     dc:"Failed item count"`
+	Throughput int64             `json:"throughput" dc:"Total download throughput of running items in bytes per second"`
+	Items      []BatchItemResult `json:"items"      dc:"Per-item results in request order"`
+	CreatedAt  *gtime.Time       `json:"createdAt"  dc:"Job creation time"`
+	FinishedAt *gtime.Time       `json:"finishedAt" dc:"Job finish time"`
+}
+
+// BatchItemResult 批量操作单个条目的执行结果
+type BatchItemResult struct {
+	Index       int               `json:"index"       dc:"Item index in the request"`
+	AlgorithmId string            `json:"algorithmId" dc:"Algorithm unique ID"`
+	Status      string            `json:"status"      dc:"Item status: pending, running, success, failed"`
+	Code        int               `json:"code"        dc:"Error code, 0 on success"`
+	Message     string            `json:"message"     dc:"Result message"`
+	Data        interface{}       `json:"data,omitempty" dc:"Item result data"`
+	Download    *DownloadProgress `json:"download,omitempty" dc:"Download progress while the package is downloading"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+)
+
+// Config 运行配置，由 manifest/config/config.yaml、d 标签中的默认值和 I800_ 开头的环境变量合并而成。
+// 时长支持 30s、5m 等写法，大小支持 100MB 等写法。
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	Database DatabaseConfig `json:"database"`
+	Device   DeviceConfig   `json:"device"`
+	Mqtt     MqttConfig     `json:"mqtt"`
+	Storage  StorageConfig  `json:"storage"`
+	Runtime  RuntimeConfig  `json:"runtime"`
+	Upgrade  UpgradeConfig  `json:"upgrade"`
+}
+
+// ServerConfig HTTP 服务配置，其余 GoFrame server 配置项原样生效
+type ServerConfig struct {
+	Address           string `json:"address"           d:":8000" v:"required"`
+	ClientMaxBodySize string `json:"clientMaxBodySize" d:"1GB"   v:"size"` // 算法包通过 /algorithm/upload 上传
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Default DatabaseNodeConfig `json:"default"`
+}
+
+// DatabaseNodeConfig 数据库连接配置
+type DatabaseNodeConfig struct {
+	Link  string `json:"link"  d:"sqlite::@file(./data/sqlite.db)" v:"required"`
+	Debug bool   `json:"debug"`
+}
+
+// DeviceConfig 设备配置
+type DeviceConfig struct {
+	Id string `json:"id"` // 设备ID，为空时使用主机名
+}
+
+// MqttConfig MQTT 命令通道配置
+type MqttConfig struct {
+	Enabled           bool          `json:"enabled"`
+	Version           int           `json:"version"           d:"3"                         v:"in:3,5"`
+	Broker            string        `json:"broker"            d:"tcp://broker.emqx.io:1883" v:"required-if:enabled,true"`
+	ClientId          string        `json:"clientId"` // 为空时使用设备ID
+	Username          string        `json:"username"`
+	Password          string        `json:"password"`
+	HeartbeatInterval time.Duration `json:"heartbeatInterval" d:"30s"                       v:"duration-min:1s"`
+	Timeout           time.Duration `json:"timeout"           d:"10s"                       v:"duration-gt:0"`
+	MessageExpiry     time.Duration `json:"messageExpiry"     d:"5m"` // v5 消息过期时间
+	SessionExpiry     time.Duration `json:"sessionExpiry"     d:"1h"` // v5 会话过期时间
+}
+
+// StorageConfig 存储空间管理配置
+type StorageConfig struct {
+	ExpansionFactor float64 `json:"expansionFactor" d:"3"     v:"min:1"` // 安装需要的空间为算法包大小的倍数
+	Quota           string  `json:"quota"           d:"0"     v:"size"`  // 算法目录配额，0 不限制
+	Reserve         string  `json:"reserve"         d:"100MB" v:"size"`  // 文件系统始终保留的空闲空间
+	KeepVersions    int     `json:"keepVersions"    d:"2"     v:"min:0"` // 每个算法除当前版本外保留的历史版本数
+}
+
+// RuntimeConfig 算法进程守护配置
+type RuntimeConfig struct {
+	LogPath              string        `json:"logPath"              d:"data/logs/algorithms" v:"required"`
+	LogRotateSize        string        `json:"logRotateSize"        d:"10MB"                 v:"size"`
+	LogRotateBackupLimit int           `json:"logRotateBackupLimit" d:"5"                    v:"min:0"`
+	BackoffMin           time.Duration `json:"backoffMin"           d:"1s"                   v:"duration-min:1s"`
+	BackoffMax           time.Duration `json:"backoffMax"           d:"1m"                   v:"duration-min:backoffMin"`
+	StopTimeout          time.Duration `json:"stopTimeout"          d:"10s"                  v:"duration-gt:0"`
+	Limits               ProcessLimits `json:"limits"`
+}
+
+// ProcessLimits 算法进程资源限制，数值为 0 表示不限制
+type ProcessLimits struct {
+	Nice          int    `json:"nice"          d:"0" v:"between:-20,19"` // 进程优先级，-20 ~ 19
+	MaxMemory     uint64 `json:"maxMemory"`                              // 虚拟内存上限(RLIMIT_AS)，单位字节
+	MaxOpenFiles  uint64 `json:"maxOpenFiles"`                           // 打开文件数上限(RLIMIT_NOFILE)
+	MaxCpuSeconds uint64 `json:"maxCpuSeconds"`                          // CPU 时间上限(RLIMIT_CPU)，单位秒
+}
+
+// UpgradeConfig 程序自升级配置
+type UpgradeConfig struct {
+	HealthTimeout time.Duration `json:"healthTimeout" d:"2m" v:"duration-gt:0"` // 重启后需在该时间内就绪并连上云端，否则回滚
+}
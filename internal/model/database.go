@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// DatabaseBackup 数据库备份文件
+type DatabaseBackup struct {
+	Name      string      `json:"name"      dc:"Backup file name"`
+	Size      int64       `json:"size"      dc:"File size in bytes"`
+	Schema    string      `json:"schema"    dc:"Latest migration applied in the backup, empty if unreadable"`
+	CreatedAt *gtime.Time `json:"createdAt" dc:"Backup time"`
+}
+
+// DatabaseRestoreOutput 恢复结果
+type DatabaseRestoreOutput struct {
+	Restored   string   `json:"restored"   dc:"Restored backup"`
+	PreRestore string   `json:"preRestore" dc:"Backup of the replaced database taken before restoring"`
+	Migrations []string `json:"migrations" dc:"Migrations applied to the restored database"`
+	Restarting bool     `json:"restarting" dc:"Whether the service restarts to reload the restored state"`
+}
+
+// DatabaseTable 数据库表
+type DatabaseTable struct {
+	Name string `json:"name" dc:"Table name"`
+	Rows int    `json:"rows" dc:"Row count"`
+}
+
+// DatabaseColumn 表的列定义
+type DatabaseColumn struct {
+	Name    string      `json:"name"    dc:"Column name"`
+	Type    string      `json:"type"    dc:"Declared type"`
+	Null    bool        `json:"null"    dc:"Whether the column accepts NULL"`
+	Key     string      `json:"key"     dc:"pri for primary key columns"`
+	Default interface{} `json:"default" dc:"Default value"`
+}
+
+// DatabaseTableDetail 表结构和最前面的若干行
+type DatabaseTableDetail struct {
+	Name    string                   `json:"name"    dc:"Table name"`
+	Rows    int                      `json:"rows"    dc:"Row count"`
+	Columns []DatabaseColumn         `json:"columns" dc:"Columns in definition order"`
+	Sample  []map[string]interface{} `json:"sample"  dc:"First rows ordered by the first column"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// DeviceSettings 云端下发的设备配置，覆盖配置文件中的对应项，未设置的项使用配置文件
+type DeviceSettings struct {
+	HeartbeatInterval string            `json:"heartbeatInterval,omitempty" dc:"Heartbeat interval, e.g. 30s"`
+	LogLevels         map[string]string `json:"logLevels,omitempty"         dc:"Log level by subsystem, e.g. {\"download\":\"debug\"}"`
+	Download          *DownloadThrottle `json:"download,omitempty"          dc:"Download rate limit and windows"`
+}
+
+// DeviceSettingsRevision 设备配置的一个版本
+type DeviceSettingsRevision struct {
+	Revision  int            `json:"revision"  dc:"Revision number, increases with each change"`
+	Status    string         `json:"status"    dc:"pending, confirmed, reverted or superseded"`
+	Reason    string         `json:"reason"    dc:"Why the revision was reverted"`
+	Settings  DeviceSettings `json:"settings"  dc:"Settings of the revision"`
+	CreatedAt *gtime.Time    `json:"createdAt" dc:"Creation time"`
+	UpdatedAt *gtime.Time    `json:"updatedAt" dc:"Last status change"`
+}
+
+// DeviceSettingsStatus 当前应用的设备配置版本和生效的设置
+type DeviceSettingsStatus struct {
+	Revision          int                     `json:"revision"          dc:"Applied revision, 0 when only the config file is in effect"`
+	Status            string                  `json:"status"            dc:"Status of the applied revision"`
+	RollbackAt        *gtime.Time             `json:"rollbackAt"        dc:"End of the rollback window while the applied revision is pending"`
+	Settings          DeviceSettings          `json:"settings"          dc:"Settings of the applied revision"`
+	HeartbeatInterval string                  `json:"heartbeatInterval" dc:"Heartbeat interval in effect"`
+	LogLevels         []LogLevel              `json:"logLevels"         dc:"Log levels in effect"`
+	Download          *DownloadThrottleStatus `json:"download"          dc:"Download rate limit in effect"`
+}
+
+// DeviceIdentity 设备身份和连接信息，用于现场排查
+type DeviceIdentity struct {
+	DeviceId         string                 `json:"deviceId"         dc:"Device ID"`
+	Version          string                 `json:"version"          dc:"Application version"`
+	Hostname         string                 `json:"hostname"         dc:"Host name"`
+	Transport        string                 `json:"transport"        dc:"Command transport: mqtt or http"`
+	MqttBroker       string                 `json:"mqttBroker"       dc:"MQTT broker with the password masked"`
+	MqttClientId     string                 `json:"mqttClientId"     dc:"MQTT client ID"`
+	MqttVersion      int                    `json:"mqttVersion"      dc:"MQTT protocol version"`
+	CommandTopic     string                 `json:"commandTopic"     dc:"Topic the device receives commands on"`
+	ProtocolVersions []string               `json:"protocolVersions" dc:"Supported command protocol versions"`
+	ServerAddress    string                 `json:"serverAddress"    dc:"HTTP server address"`
+	Database         string                 `json:"database"         dc:"Database link with the password masked"`
+	Upgrade          *FirmwareUpgradeStatus `json:"upgrade"          dc:"Last self-upgrade, null if none"`
+}
@@ -20,4 +20,7 @@ type Algorithm struct {
 	FileSize           interface{} //
 	Md5                interface{} //
 	LocalPath          interface{} //
+	DigestAlgo         interface{} //
+	Digest             interface{} //
+	RunState           interface{} //
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// DownloadThrottle 算法包下载限速设置，时间窗外使用 RateLimit，处于时间窗内时使用时间窗的限速
+type DownloadThrottle struct {
+	RateLimit int64            `json:"rateLimit" v:"min:0" dc:"Default limit in bytes per second outside windows, 0 means unlimited"`
+	Windows   []ThrottleWindow `json:"windows"             dc:"Time-of-day windows overriding the default limit, the lowest limit wins when windows overlap"`
+}
+
+// ThrottleWindow 限速时间窗，格式与算法运行时间窗相同
+type ThrottleWindow struct {
+	Cron      string `json:"cron"      v:"required" dc:"Window start, 5-field cron expression, e.g. 0 8 * * 1-5"`
+	Duration  string `json:"duration"  v:"required" dc:"Window length, e.g. 10h"`
+	Timezone  string `json:"timezone"               dc:"IANA timezone, e.g. Asia/Shanghai, default Local"`
+	RateLimit int64  `json:"rateLimit" v:"min:0"    dc:"Limit in bytes per second inside the window, 0 means unlimited"`
+}
+
+// DownloadThrottleStatus 当前限速设置和生效的限速
+type DownloadThrottleStatus struct {
+	DownloadThrottle
+	Source        string             `json:"source"        dc:"Where the settings come from: config, or api after a live adjustment"`
+	CurrentRate   int64              `json:"currentRate"   dc:"Limit in effect now in bytes per second, 0 means unlimited"`
+	ActiveWindows []int              `json:"activeWindows" dc:"Indexes of windows active now"`
+	Throughput    int64              `json:"throughput"    dc:"Total throughput of active downloads in bytes per second"`
+	Downloads     []DownloadProgress `json:"downloads"     dc:"Active downloads"`
+}
+
+// DownloadProgress 进行中的下载进度
+type DownloadProgress struct {
+	Url        string      `json:"url"        dc:"Source URL"`
+	Size       int64       `json:"size"       dc:"Expected size in bytes, 0 if unknown"`
+	Written    int64       `json:"written"    dc:"Received bytes"`
+	Throughput int64       `json:"throughput" dc:"Recent throughput in bytes per second"`
+	Throttled  bool        `json:"throttled"  dc:"Whether a rate limit is in effect for the download now"`
+	StartedAt  *gtime.Time `json:"startedAt"  dc:"Download start time"`
+}
@@ -15,4 +15,7 @@ type Algorithm struct {
 	FileSize           int    `json:"fileSize"           orm:"file_size"            description:""` //
 	Md5                string `json:"md5"                orm:"md5"                  description:""` //
 	LocalPath          string `json:"localPath"          orm:"local_path"           description:""` //
+	DigestAlgo         string `json:"digestAlgo"         orm:"digest_algo"          description:""` //
+	Digest             string `json:"digest"             orm:"digest"               description:""` //
+	RunState           string `json:"runState"           orm:"run_state"            description:""` //
 }
@@ -0,0 +1,18 @@
+package model
+
+import "github.com/gogf/gf/v2/os/gtime"
+
+// HealthCheck 单项健康检查结果
+type HealthCheck struct {
+	Name     string `json:"name"     dc:"Check name"`
+	Status   string `json:"status"   dc:"ok, warn or fail"`
+	Message  string `json:"message"  dc:"Detail of the check result"`
+	Duration int64  `json:"duration" dc:"Check duration in milliseconds"`
+}
+
+// HealthReport 健康检查汇总，任一检查失败时整体为 fail
+type HealthReport struct {
+	Status string        `json:"status" dc:"Overall status: ok, warn or fail"`
+	Checks []HealthCheck `json:"checks" dc:"Individual check results"`
+	Time   *gtime.Time   `json:"time"   dc:"Check time"`
+}
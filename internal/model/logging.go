@@ -0,0 +1,7 @@
+package model
+
+// LogLevel 日志子系统级别
+type LogLevel struct {
+	Subsystem string `json:"subsystem" dc:"Log subsystem"`
+	Level     string `json:"level"     dc:"Log level: debug, info, notice, warning, error or critical"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// MqttMessage 收发的 MQTT 消息，ResponseTopic 等 v5 属性在 v3.1.1 连接下忽略
+type MqttMessage struct {
+	Topic           string
+	Payload         []byte
+	Qos             byte
+	Retained        bool
+	ResponseTopic   string            // v5 请求/响应模式的回复主题
+	CorrelationData []byte            // v5 请求/响应模式的关联数据
+	MessageExpiry   uint32            // v5 消息过期秒数，过期未投递的消息由 broker 丢弃，0 使用默认配置
+	UserProperties  map[string]string // v5 用户属性
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// Peer 通过 mDNS 发现的同一局域网内的设备
+type Peer struct {
+	DeviceId  string      `json:"deviceId"  dc:"Peer device ID"`
+	Version   string      `json:"version"   dc:"Peer application version"`
+	Host      string      `json:"host"      dc:"Address used to reach the peer"`
+	Port      int         `json:"port"      dc:"Peer HTTP port"`
+	Url       string      `json:"url"       dc:"Peer HTTP base URL"`
+	Addresses []string    `json:"addresses" dc:"IPv4 addresses announced by the peer"`
+	Mirror    bool        `json:"mirror"    dc:"Whether the peer serves algorithm packages"`
+	LastSeen  *gtime.Time `json:"lastSeen"  dc:"Last announcement time"`
+	ExpiresAt *gtime.Time `json:"expiresAt" dc:"Time the entry expires without a new announcement"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+
+	"demo/internal/model/entity"
+)
+
+// ScheduleInput 算法运行时间窗参数
+type ScheduleInput struct {
+	Cron     string `json:"cron"     v:"required" dc:"Window start, 5-field cron expression, e.g. 0 22 * * 1-5"`
+	Duration string `json:"duration" v:"required" dc:"Window length, e.g. 8h"`
+	Timezone string `json:"timezone"              dc:"IANA timezone, e.g. Asia/Shanghai, default Local"`
+	Enabled  *bool  `json:"enabled"               dc:"Whether the window is enabled, default true"`
+}
+
+// ScheduleItem 时间窗及其当前状态
+type ScheduleItem struct {
+	entity.AlgorithmSchedule
+	Active    bool        `json:"active"    dc:"Whether now is inside the window"`
+	NextStart *gtime.Time `json:"nextStart" dc:"Next window start"`
+	NextStop  *gtime.Time `json:"nextStop"  dc:"Next window stop"`
+}
+
+// ScheduleTransition 计划中的启停动作
+type ScheduleTransition struct {
+	AlgorithmId string      `json:"algorithmId" dc:"Algorithm unique ID"`
+	ScheduleId  int         `json:"scheduleId"  dc:"Schedule ID"`
+	Action      string      `json:"action"      dc:"start or stop"`
+	At          *gtime.Time `json:"at"          dc:"Planned time"`
+}
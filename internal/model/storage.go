@@ -0,0 +1,33 @@
+package model
+
+// StorageVersion 算法版本占用的存储空间
+type StorageVersion struct {
+	AlgorithmVersionId string `json:"algorithmVersionId" dc:"Algorithm version ID"`
+	Size               int64  `json:"size"               dc:"Bytes used"`
+	Active             bool   `json:"active"             dc:"Whether this is the active version"`
+}
+
+// StorageAlgorithm 单个算法占用的存储空间
+type StorageAlgorithm struct {
+	AlgorithmId string           `json:"algorithmId" dc:"Algorithm unique ID"`
+	Installed   bool             `json:"installed"   dc:"Whether the algorithm exists in the algorithm table"`
+	Size        int64            `json:"size"        dc:"Bytes used by all versions"`
+	Versions    []StorageVersion `json:"versions"    dc:"Versions on disk"`
+}
+
+// StorageSummary 算法存储目录概况
+type StorageSummary struct {
+	Path       string             `json:"path"       dc:"Algorithm store path"`
+	Total      int64              `json:"total"      dc:"Filesystem total bytes, 0 if unknown"`
+	Free       int64              `json:"free"       dc:"Filesystem available bytes, -1 if unknown"`
+	Used       int64              `json:"used"       dc:"Bytes used by the algorithm store"`
+	Quota      int64              `json:"quota"      dc:"Store quota in bytes, 0 for unlimited"`
+	Reserve    int64              `json:"reserve"    dc:"Free bytes always kept on the filesystem"`
+	Algorithms []StorageAlgorithm `json:"algorithms" dc:"Usage per algorithm"`
+}
+
+// StoragePruneOutput 清理结果
+type StoragePruneOutput struct {
+	Removed    []string `json:"removed"    dc:"Removed paths"`
+	FreedBytes int64    `json:"freedBytes" dc:"Bytes freed"`
+}
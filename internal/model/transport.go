@@ -0,0 +1,7 @@
+package model
+
+// CommandMessage 传输层收到的一条命令
+type CommandMessage struct {
+	Payload    []byte            // 命令 JSON
+	Properties map[string]string // 传输层附带的元数据，如 MQTT v5 用户属性、HTTP 请求头中的 traceparent
+}
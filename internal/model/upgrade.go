@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// FirmwareUpgradeInput 程序自升级参数
+type FirmwareUpgradeInput struct {
+	FirmwareVersion string // 新版本号
+	Url             string // 新版本程序的下载地址
+	FileSize        int64  // 文件大小，0 不校验
+	Md5             string
+	Sha256          string
+	Digest          string // 通用摘要，如 sha256:xxx
+	HealthTimeout   string // 重启后通过健康检查的时限，如 2m
+}
+
+// FirmwareUpgradeStatus 程序自升级状态，持久化在 data/upgrade.json，重启后据此检查或回滚
+type FirmwareUpgradeStatus struct {
+	CmdId         string      `json:"cmdId"         dc:"Command that started the upgrade"`
+	FromVersion   string      `json:"fromVersion"   dc:"Version before the upgrade"`
+	ToVersion     string      `json:"toVersion"     dc:"Version being installed"`
+	Url           string      `json:"url"           dc:"Download URL of the new binary"`
+	Digest        string      `json:"digest"        dc:"Verified digest of the new binary"`
+	Status        string      `json:"status"        dc:"downloading, restarting, verifying, succeeded, rolledBack or failed"`
+	Reason        string      `json:"reason"        dc:"Why the upgrade failed or was rolled back"`
+	HealthTimeout string      `json:"healthTimeout" dc:"Time allowed for the new binary to become healthy"`
+	StartedAt     *gtime.Time `json:"startedAt"     dc:"Upgrade start time"`
+	UpdatedAt     *gtime.Time `json:"updatedAt"     dc:"Last status change"`
+}
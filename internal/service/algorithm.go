@@ -0,0 +1,381 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gcompress"
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+	"go.opentelemetry.io/otel/attribute"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/do"
+	"demo/internal/model/entity"
+)
+
+const (
+	// 算法包默认存储目录
+	defaultAlgorithmStorePath = "data/algorithms"
+	// 下载的算法包文件名
+	algorithmPackageFile = "package.zip"
+	// 算法包解压目录名
+	algorithmAppDir = "app"
+	// 算法包清单文件名
+	algorithmManifestFile = "manifest.json"
+	// 清单未指定入口时的默认启动脚本
+	defaultAlgorithmEntrypoint = "run.sh"
+	// 清单未指定配置文件时的默认配置文件名
+	defaultAlgorithmConfigFile = "config.json"
+)
+
+// pathSegmentPattern 用作目录名的标识只允许的字符
+var pathSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// CheckPathSegment 检查用作目录名的标识为单个安全的路径段，拒绝路径分隔符、. 和 ..
+func CheckPathSegment(name string) error {
+	if !pathSegmentPattern.MatchString(name) || name == "." || name == ".." {
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "invalid id %q, only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// sAlgorithm 算法管理服务，负责算法包的下载、校验、解压和入库
+type sAlgorithm struct {
+	storePath string
+}
+
+var (
+	algorithmService *sAlgorithm
+	algorithmOnce    sync.Once
+)
+
+// Algorithm 获取算法服务单例
+func Algorithm() *sAlgorithm {
+	algorithmOnce.Do(func() {
+		ctx := context.Background()
+		algorithmService = &sAlgorithm{
+			storePath: g.Cfg().MustGet(ctx, "algorithm.storePath", defaultAlgorithmStorePath).String(),
+		}
+	})
+	return algorithmService
+}
+
+// StorePath 返回算法包存储根目录
+func (s *sAlgorithm) StorePath() string {
+	return s.storePath
+}
+
+// VersionPath 返回指定算法版本的存储目录
+func (s *sAlgorithm) VersionPath(algorithmId, algorithmVersionId string) string {
+	return filepath.Join(s.storePath, algorithmId, algorithmVersionId)
+}
+
+// Install 下载算法包(离线导入时复制本地文件)，使用下发的最强摘要校验后写入算法表。
+// 同一 algorithmId 重复下发时覆盖原记录。
+func (s *sAlgorithm) Install(ctx context.Context, in model.AlgorithmInstallInput) (id int64, err error) {
+	ctx, span := Tracing().StartSpan(ctx, "algorithm.install",
+		attribute.String("algorithm.id", in.AlgorithmId),
+		attribute.String("algorithm.version", in.AlgorithmVersion),
+	)
+	defer func() { Tracing().EndSpan(span, err) }()
+
+	// 两个标识直接用作目录名，不只依赖接口的校验规则
+	for _, name := range []string{in.AlgorithmId, in.AlgorithmVersionId} {
+		if err = CheckPathSegment(name); err != nil {
+			return 0, err
+		}
+	}
+	digest, err := StrongestDigest(in.Md5, in.Sha256, in.Digest)
+	if err != nil {
+		return 0, err
+	}
+
+	versionPath := s.VersionPath(in.AlgorithmId, in.AlgorithmVersionId)
+	packagePath := filepath.Join(versionPath, algorithmPackageFile)
+	release, err := Storage().Preflight(ctx, versionPath, in.FileSize)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	if in.PackageFile != "" {
+		in.AlgorithmDataUrl = consts.AlgorithmSourceLocal
+		err = Download().Copy(ctx, in.PackageFile, packagePath, in.FileSize, digest)
+	} else {
+		err = Download().Fetch(ctx, in.AlgorithmDataUrl, packagePath, in.FileSize, digest)
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, extractSpan := Tracing().StartSpan(ctx, "algorithm.extract", attribute.String("package.path", packagePath))
+	err = s.extract(packagePath, filepath.Join(versionPath, algorithmAppDir))
+	Tracing().EndSpan(extractSpan, err)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := dao.Algorithm.Columns()
+	_, err = dao.Algorithm.Ctx(ctx).Data(do.Algorithm{
+		AlgorithmId:        in.AlgorithmId,
+		AlgorithmName:      in.AlgorithmName,
+		AlgorithmVersion:   in.AlgorithmVersion,
+		AlgorithmVersionId: in.AlgorithmVersionId,
+		AlgorithmDataUrl:   in.AlgorithmDataUrl,
+		FileSize:           in.FileSize,
+		Md5:                in.Md5,
+		DigestAlgo:         digest.Algo,
+		Digest:             digest.Hex,
+		LocalPath:          versionPath,
+	}).OnConflict(columns.AlgorithmId).Save()
+	if err != nil {
+		return 0, err
+	}
+	value, err := dao.Algorithm.Ctx(ctx).Where(columns.AlgorithmId, in.AlgorithmId).Value(columns.Id)
+	if err != nil {
+		return 0, err
+	}
+	id = value.Int64()
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Algorithm %s (%s) installed, verified by %s", in.AlgorithmId, in.AlgorithmVersion, digest.Algo)
+
+	// 切换到新版本运行
+	if err = Supervisor().Reload(ctx, in.AlgorithmId); err != nil {
+		logger(consts.LoggerAlgorithm).Warningf(ctx, "Algorithm %s installed but failed to start: %v", in.AlgorithmId, err)
+	}
+	// 按保留策略清理旧版本
+	if _, err = Storage().Prune(ctx, in.AlgorithmId, -1); err != nil {
+		logger(consts.LoggerAlgorithm).Warningf(ctx, "Prune algorithm %s failed: %v", in.AlgorithmId, err)
+	}
+	return id, nil
+}
+
+// GetById 按记录ID获取算法
+func (s *sAlgorithm) GetById(ctx context.Context, id int64) (*entity.Algorithm, error) {
+	var algorithm *entity.Algorithm
+	if err := dao.Algorithm.Ctx(ctx).WherePri(id).Scan(&algorithm); err != nil {
+		return nil, err
+	}
+	if algorithm == nil {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "algorithm %d not found", id)
+	}
+	return algorithm, nil
+}
+
+// GetByAlgorithmId 按算法唯一ID获取算法
+func (s *sAlgorithm) GetByAlgorithmId(ctx context.Context, algorithmId string) (*entity.Algorithm, error) {
+	var algorithm *entity.Algorithm
+	err := dao.Algorithm.Ctx(ctx).Where(dao.Algorithm.Columns().AlgorithmId, algorithmId).Scan(&algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == nil {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "algorithm %s not found", algorithmId)
+	}
+	return algorithm, nil
+}
+
+// Start 将算法期望状态设为运行并启动
+func (s *sAlgorithm) Start(ctx context.Context, algorithmId string) error {
+	if err := s.setRunState(ctx, algorithmId, consts.RunStateRunning); err != nil {
+		return err
+	}
+	return Supervisor().Start(ctx, algorithmId)
+}
+
+// Stop 将算法期望状态设为停止并优雅停止进程，timeout 为等待 SIGTERM 生效的时间
+func (s *sAlgorithm) Stop(ctx context.Context, algorithmId string, timeout time.Duration) error {
+	if err := s.setRunState(ctx, algorithmId, consts.RunStateStopped); err != nil {
+		return err
+	}
+	Supervisor().Stop(ctx, algorithmId, timeout)
+	return nil
+}
+
+// Restart 优雅停止算法进程后重新启动，期望状态设为运行
+func (s *sAlgorithm) Restart(ctx context.Context, algorithmId string, timeout time.Duration) error {
+	if err := s.setRunState(ctx, algorithmId, consts.RunStateRunning); err != nil {
+		return err
+	}
+	Supervisor().Stop(ctx, algorithmId, timeout)
+	return Supervisor().Start(ctx, algorithmId)
+}
+
+// algorithmPath 返回算法的存储目录，algorithmId 须为单个安全的路径段且目录严格位于存储根目录下
+func (s *sAlgorithm) algorithmPath(algorithmId string) (string, error) {
+	if err := CheckPathSegment(algorithmId); err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(s.storePath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, algorithmId)
+	if rel, err := filepath.Rel(root, dir); err != nil || rel == "." || rel == ".." || strings.ContainsRune(rel, filepath.Separator) {
+		return "", gerror.NewCodef(gcode.CodeInvalidParameter, "algorithm path %s is outside %s", dir, root)
+	}
+	return dir, nil
+}
+
+// Delete 停止算法进程，删除算法记录、配置、运行时间窗和全部版本文件
+func (s *sAlgorithm) Delete(ctx context.Context, algorithmId string) error {
+	if _, err := s.GetByAlgorithmId(ctx, algorithmId); err != nil {
+		return err
+	}
+	Supervisor().Remove(ctx, algorithmId)
+	err := dao.Algorithm.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := tx.Model(dao.AlgorithmSchedule.Table()).Ctx(ctx).Where(dao.AlgorithmSchedule.Columns().AlgorithmId, algorithmId).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.Model(dao.AlgorithmConfig.Table()).Ctx(ctx).Where(dao.AlgorithmConfig.Columns().AlgorithmId, algorithmId).Delete(); err != nil {
+			return err
+		}
+		_, err := tx.Model(dao.Algorithm.Table()).Ctx(ctx).Where(dao.Algorithm.Columns().AlgorithmId, algorithmId).Delete()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	dir, err := s.algorithmPath(algorithmId)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return gerror.Wrapf(err, "remove files of algorithm %s failed", algorithmId)
+	}
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Algorithm %s deleted", algorithmId)
+	return nil
+}
+
+// Verify 按安装时记录的摘要重新校验保留的算法包，并检查清单和启动入口，用于排查存储损坏
+func (s *sAlgorithm) Verify(ctx context.Context, algorithmId string) (*model.AlgorithmVerifyResult, error) {
+	algorithm, err := s.GetByAlgorithmId(ctx, algorithmId)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.AlgorithmVerifyResult{
+		AlgorithmId:        algorithm.AlgorithmId,
+		AlgorithmVersionId: algorithm.AlgorithmVersionId,
+		Problems:           []string{},
+	}
+	// 早期安装的记录只有 md5
+	digest, err := NewDigest(algorithm.DigestAlgo, algorithm.Digest)
+	if algorithm.DigestAlgo == "" {
+		digest, err = NewDigest(DigestMd5, algorithm.Md5)
+	}
+	if err != nil {
+		result.Problems = append(result.Problems, "recorded digest invalid: "+err.Error())
+	} else {
+		result.Digest = digest.String()
+		if err = VerifyFile(filepath.Join(algorithm.LocalPath, algorithmPackageFile), digest); err != nil {
+			result.Problems = append(result.Problems, "package: "+err.Error())
+		}
+	}
+	manifest, err := s.LoadManifest(algorithm.LocalPath)
+	if err != nil {
+		result.Problems = append(result.Problems, "manifest: "+err.Error())
+	} else {
+		result.Entrypoint = filepath.Join(s.AppPath(algorithm.LocalPath), manifest.Entrypoint)
+		if info, err := os.Stat(result.Entrypoint); err != nil {
+			result.Problems = append(result.Problems, "entrypoint: "+err.Error())
+		} else if runtime.GOOS != "windows" && info.Mode()&0o111 == 0 {
+			result.Problems = append(result.Problems, "entrypoint: not executable")
+		}
+	}
+	result.Ok = len(result.Problems) == 0
+	return result, nil
+}
+
+// setRunState 持久化算法期望运行状态，重启设备后按该状态恢复
+func (s *sAlgorithm) setRunState(ctx context.Context, algorithmId, runState string) error {
+	if _, err := s.GetByAlgorithmId(ctx, algorithmId); err != nil {
+		return err
+	}
+	_, err := dao.Algorithm.Ctx(ctx).
+		Data(dao.Algorithm.Columns().RunState, runState).
+		Where(dao.Algorithm.Columns().AlgorithmId, algorithmId).
+		Update()
+	return err
+}
+
+// AppPath 返回算法版本目录下的解压目录
+func (s *sAlgorithm) AppPath(versionPath string) string {
+	return filepath.Join(versionPath, algorithmAppDir)
+}
+
+// LoadManifest 读取算法版本目录中的 manifest.json，文件不存在时使用默认入口
+func (s *sAlgorithm) LoadManifest(versionPath string) (*model.AlgorithmManifest, error) {
+	manifest := &model.AlgorithmManifest{}
+	manifestPath := filepath.Join(s.AppPath(versionPath), algorithmManifestFile)
+	if gfile.Exists(manifestPath) {
+		if err := gjson.DecodeTo(gfile.GetBytes(manifestPath), manifest); err != nil {
+			return nil, gerror.Wrapf(err, "parse %s failed", manifestPath)
+		}
+	}
+	if manifest.Entrypoint == "" {
+		manifest.Entrypoint = defaultAlgorithmEntrypoint
+	}
+	if manifest.ConfigFile == "" {
+		manifest.ConfigFile = defaultAlgorithmConfigFile
+	}
+	// 清单中的路径都相对于解压目录，不能指向目录之外
+	paths := map[string]string{"entrypoint": manifest.Entrypoint, "configFile": manifest.ConfigFile}
+	if schemaFile, ok := manifest.ConfigSchema.(string); ok {
+		paths["configSchema"] = schemaFile
+	}
+	for field, name := range paths {
+		if err := checkAppRelative(field, name); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// checkAppRelative 检查清单中的路径是否为解压目录内的相对路径
+func checkAppRelative(field, name string) error {
+	if filepath.IsAbs(name) || path.IsAbs(filepath.ToSlash(name)) {
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "manifest %s %q must be a relative path", field, name)
+	}
+	root := string(filepath.Separator) + algorithmAppDir
+	rel, err := filepath.Rel(root, filepath.Join(root, name))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "manifest %s %q is outside the package", field, name)
+	}
+	return nil
+}
+
+// extract 解压算法包到指定目录，拒绝包含绝对路径或 .. 的条目
+func (s *sAlgorithm) extract(packagePath, dst string) error {
+	reader, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return gerror.Wrapf(err, "open package %s failed", packagePath)
+	}
+	for _, file := range reader.File {
+		name := filepath.ToSlash(file.Name)
+		if path.IsAbs(name) || strings.HasPrefix(path.Clean(name), "..") {
+			_ = reader.Close()
+			return gerror.NewCodef(gcode.CodeValidationFailed, "unsafe path in package: %s", file.Name)
+		}
+	}
+	_ = reader.Close()
+
+	if err = os.RemoveAll(dst); err != nil {
+		return gerror.Wrapf(err, "clean %s failed", dst)
+	}
+	if err = gcompress.UnZipFile(packagePath, dst); err != nil {
+		return gerror.Wrapf(err, "extract package %s failed", packagePath)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/do"
+	"demo/internal/model/entity"
+)
+
+// sAlgorithmConfig 算法配置参数服务，按版本保存 JSON 配置，
+// 按算法包清单中的 JSON Schema 校验，并写入运行目录通知算法热加载
+type sAlgorithmConfig struct{}
+
+var (
+	algorithmConfigService *sAlgorithmConfig
+	algorithmConfigOnce    sync.Once
+)
+
+// AlgorithmConfig 获取算法配置服务单例
+func AlgorithmConfig() *sAlgorithmConfig {
+	algorithmConfigOnce.Do(func() {
+		algorithmConfigService = &sAlgorithmConfig{}
+	})
+	return algorithmConfigService
+}
+
+// Get 获取算法配置，revision 为 0 时返回最新版本，没有配置时返回 nil
+func (s *sAlgorithmConfig) Get(ctx context.Context, algorithmId string, revision int) (*entity.AlgorithmConfig, error) {
+	var (
+		config  *entity.AlgorithmConfig
+		columns = dao.AlgorithmConfig.Columns()
+		m       = dao.AlgorithmConfig.Ctx(ctx).Where(columns.AlgorithmId, algorithmId)
+	)
+	if revision > 0 {
+		m = m.Where(columns.Revision, revision)
+	} else {
+		m = m.OrderDesc(columns.Revision)
+	}
+	if err := m.Limit(1).Scan(&config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Set 校验并保存新版本配置，写入算法运行目录后通知进程重新加载，返回新版本号
+func (s *sAlgorithmConfig) Set(ctx context.Context, algorithmId string, content interface{}) (revision int, err error) {
+	algorithm, err := Algorithm().GetByAlgorithmId(ctx, algorithmId)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return 0, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid config")
+	}
+	if err = s.validate(algorithm, data); err != nil {
+		return 0, err
+	}
+
+	columns := dao.AlgorithmConfig.Columns()
+	err = dao.AlgorithmConfig.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		latest, err := tx.Model(dao.AlgorithmConfig.Table()).Ctx(ctx).
+			Where(columns.AlgorithmId, algorithmId).
+			Max(columns.Revision)
+		if err != nil {
+			return err
+		}
+		revision = int(latest) + 1
+		_, err = tx.Model(dao.AlgorithmConfig.Table()).Ctx(ctx).Data(do.AlgorithmConfig{
+			AlgorithmId: algorithmId,
+			Revision:    revision,
+			Content:     string(data),
+		}).Insert()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Algorithm %s config revision %d saved", algorithmId, revision)
+
+	if err = s.Materialize(ctx, algorithm); err != nil {
+		return revision, err
+	}
+	Supervisor().NotifyReload(ctx, algorithmId)
+	return revision, nil
+}
+
+// Materialize 将最新配置写入算法当前版本的运行目录，没有配置时不写入
+func (s *sAlgorithmConfig) Materialize(ctx context.Context, algorithm *entity.Algorithm) error {
+	if algorithm.LocalPath == "" {
+		return nil
+	}
+	config, err := s.Get(ctx, algorithm.AlgorithmId, 0)
+	if err != nil || config == nil {
+		return err
+	}
+	path, err := s.ConfigPath(algorithm)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免算法读到写了一半的配置
+	tmp := path + ".tmp"
+	if err = gfile.PutContents(tmp, config.Content); err != nil {
+		return gerror.Wrapf(err, "write %s failed", tmp)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return gerror.Wrapf(err, "rename %s failed", tmp)
+	}
+	return nil
+}
+
+// ConfigPath 返回算法当前版本配置文件的绝对路径
+func (s *sAlgorithmConfig) ConfigPath(algorithm *entity.Algorithm) (string, error) {
+	manifest, err := Algorithm().LoadManifest(algorithm.LocalPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(filepath.Join(Algorithm().AppPath(algorithm.LocalPath), manifest.ConfigFile))
+}
+
+// validate 按算法包清单中的 JSON Schema 校验配置，清单未提供 Schema 时只要求是合法 JSON
+func (s *sAlgorithmConfig) validate(algorithm *entity.Algorithm, data []byte) error {
+	if algorithm.LocalPath == "" {
+		return nil
+	}
+	manifest, err := Algorithm().LoadManifest(algorithm.LocalPath)
+	if err != nil {
+		return err
+	}
+	schema, err := s.compileSchema(algorithm.LocalPath, manifest)
+	if err != nil || schema == nil {
+		return err
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid config")
+	}
+	if err = schema.Validate(value); err != nil {
+		return gerror.NewCodef(gcode.CodeValidationFailed, "config does not match schema: %v", err)
+	}
+	return nil
+}
+
+// compileSchema 编译清单中的配置 Schema，未提供时返回 nil
+func (s *sAlgorithmConfig) compileSchema(versionPath string, manifest *model.AlgorithmManifest) (*jsonschema.Schema, error) {
+	var content []byte
+	switch v := manifest.ConfigSchema.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		content = gfile.GetBytes(filepath.Join(Algorithm().AppPath(versionPath), v))
+		if content == nil {
+			return nil, gerror.Newf("config schema file %s not found", v)
+		}
+	default:
+		var err error
+		if content, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("config.schema.json", bytes.NewReader(content)); err != nil {
+		return nil, gerror.Wrap(err, "invalid config schema")
+	}
+	schema, err := compiler.Compile("config.schema.json")
+	if err != nil {
+		return nil, gerror.Wrap(err, "invalid config schema")
+	}
+	return schema, nil
+}
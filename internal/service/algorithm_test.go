@@ -0,0 +1,53 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+func TestLoadManifestPaths(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	tests := []struct {
+		name     string
+		manifest g.Map
+		valid    bool
+	}{
+		{name: "defaults", manifest: g.Map{}, valid: true},
+		{name: "nested files", manifest: g.Map{"entrypoint": "bin/run.sh", "configFile": "etc/config.json", "configSchema": "etc/schema.json"}, valid: true},
+		{name: "inline schema", manifest: g.Map{"configSchema": g.Map{"type": "object"}}, valid: true},
+		{name: "dot segments inside", manifest: g.Map{"configFile": "etc/../config.json"}, valid: true},
+		{name: "config file escapes", manifest: g.Map{"configFile": "../../config.json"}},
+		{name: "config file escapes after clean", manifest: g.Map{"configFile": "etc/../../config.json"}},
+		{name: "absolute config file", manifest: g.Map{"configFile": "/etc/passwd"}},
+		{name: "app dir itself", manifest: g.Map{"configFile": "."}},
+		{name: "schema escapes", manifest: g.Map{"configSchema": "../schema.json"}},
+		{name: "absolute schema", manifest: g.Map{"configSchema": "/etc/schema.json"}},
+		{name: "entrypoint escapes", manifest: g.Map{"entrypoint": "../../../bin/sh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versionPath := t.TempDir()
+			appPath := Algorithm().AppPath(versionPath)
+			if err := os.MkdirAll(appPath, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			content := gjson.MustEncode(tt.manifest)
+			if err := os.WriteFile(filepath.Join(appPath, algorithmManifestFile), content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Algorithm().LoadManifest(versionPath)
+			if tt.valid && err != nil {
+				t.Fatalf("load %s: %v", content, err)
+			}
+			if !tt.valid && gerror.Code(err) != gcode.CodeInvalidParameter {
+				t.Fatalf("load %s: %v, want an invalid parameter error", content, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,606 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 备份文件名前缀，定时备份只轮转 auto 开头的文件，手动和恢复前的备份需手动删除
+const (
+	BackupManual     = "manual"
+	BackupAuto       = "auto"
+	BackupPreRestore = "pre-restore"
+)
+
+// 表导出导入格式
+const (
+	TableFormatJson = "json"
+	TableFormatCsv  = "csv"
+)
+
+// backupRestartDelay 恢复后等待回复发出再重启
+const backupRestartDelay = 2 * time.Second
+
+var (
+	// sqliteFilePattern 从 sqlite::@file(path) 中取出数据库文件路径
+	sqliteFilePattern = regexp.MustCompile(`^sqlite:.*@file\((.+)\)`)
+	// backupNamePattern 备份目录中的合法文件名，防止通过名称访问目录外的文件
+	backupNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\.db$`)
+)
+
+// sBackup 数据库备份恢复服务。SD 卡损坏后用备份恢复设备状态：
+// 备份使用 VACUUM INTO 在服务运行中得到一致的副本，恢复前检查完整性和迁移版本，
+// 单表可导出导入为 JSON 或 CSV
+type sBackup struct {
+	mu       sync.Mutex // 备份、恢复和导入互斥
+	path     string
+	interval time.Duration
+	keep     int
+}
+
+var (
+	backupService *sBackup
+	backupOnce    sync.Once
+)
+
+// Backup 获取数据库备份服务单例
+func Backup() *sBackup {
+	backupOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		cfg := g.Cfg()
+		backupService = &sBackup{
+			path:     cfg.MustGet(ctx, "backup.path", "data/backups").String(),
+			interval: cfg.MustGet(ctx, "backup.interval").Duration(),
+			keep:     cfg.MustGet(ctx, "backup.keep", 7).Int(),
+		}
+	})
+	return backupService
+}
+
+// Path 备份目录
+func (s *sBackup) Path() string {
+	return s.path
+}
+
+// Start 配置了 backup.interval 时定时备份并轮转，只保留最近 backup.keep 个定时备份
+func (s *sBackup) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	logger(consts.LoggerDatabase).Infof(ctx, "Backing up database to %s every %s, keeping %d", s.path, s.interval, s.keep)
+	gtimer.AddSingleton(ctx, s.interval, func(ctx context.Context) {
+		if _, err := s.Create(ctx, BackupAuto); err != nil {
+			logger(consts.LoggerDatabase).Errorf(ctx, "Scheduled backup failed: %v", err)
+			return
+		}
+		s.rotate(ctx)
+	})
+}
+
+// Create 备份数据库到备份目录，文件名为 <kind>-<时间>.db
+func (s *sBackup) Create(ctx context.Context, kind string) (*model.DatabaseBackup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(ctx, kind)
+}
+
+// create 备份数据库，调用方持有锁
+func (s *sBackup) create(ctx context.Context, kind string) (*model.DatabaseBackup, error) {
+	if _, err := s.databaseFile(); err != nil {
+		return nil, err
+	}
+	if err := gfile.Mkdir(s.path); err != nil {
+		return nil, gerror.Wrapf(err, "create backup directory %s failed", s.path)
+	}
+	base := kind + "-" + gtime.Now().Format("Ymd-His")
+	name := base + ".db"
+	for i := 1; gfile.Exists(filepath.Join(s.path, name)); i++ {
+		name = fmt.Sprintf("%s-%d.db", base, i)
+	}
+	file := filepath.Join(s.path, name)
+	// VACUUM INTO 在一个读事务中写出整库副本，不阻塞其他连接的读写
+	if _, err := g.DB().Exec(ctx, "VACUUM INTO ?", file); err != nil {
+		_ = os.Remove(file)
+		return nil, gerror.Wrapf(err, "backup database to %s failed", file)
+	}
+	backup := s.describe(ctx, file)
+	logger(consts.LoggerDatabase).Infof(ctx, "Database backed up to %s (%d bytes)", file, backup.Size)
+	return backup, nil
+}
+
+// List 列出备份目录中的备份，新的在前
+func (s *sBackup) List(ctx context.Context) ([]model.DatabaseBackup, error) {
+	files, err := gfile.ScanDirFile(s.path, "*.db")
+	if err != nil && gfile.Exists(s.path) {
+		return nil, gerror.Wrapf(err, "read backup directory %s failed", s.path)
+	}
+	list := make([]model.DatabaseBackup, 0, len(files))
+	for _, file := range files {
+		list = append(list, *s.describe(ctx, file))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+// File 返回备份目录中指定名称的备份文件路径
+func (s *sBackup) File(name string) (string, error) {
+	if !backupNamePattern.MatchString(name) {
+		return "", gerror.NewCodef(gcode.CodeInvalidParameter, "invalid backup name: %s", name)
+	}
+	file := filepath.Join(s.path, name)
+	if !gfile.IsFile(file) {
+		return "", gerror.NewCodef(gcode.CodeNotFound, "backup not found: %s", name)
+	}
+	return file, nil
+}
+
+// Add 检查上传的备份文件并以 name 移入备份目录
+func (s *sBackup) Add(ctx context.Context, file, name string) (*model.DatabaseBackup, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid backup name %s, expected a .db file", name)
+	}
+	target := filepath.Join(s.path, name)
+	if gfile.Exists(target) {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "backup already exists: %s", name)
+	}
+	if _, err := s.Check(ctx, file); err != nil {
+		return nil, err
+	}
+	if err := gfile.Move(file, target); err != nil {
+		return nil, gerror.Wrapf(err, "save backup %s failed", name)
+	}
+	logger(consts.LoggerDatabase).Infof(ctx, "Added backup %s", target)
+	return s.describe(ctx, target), nil
+}
+
+// Delete 删除备份目录中的备份
+func (s *sBackup) Delete(ctx context.Context, name string) error {
+	file, err := s.File(name)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(file); err != nil {
+		return gerror.Wrapf(err, "remove backup %s failed", name)
+	}
+	logger(consts.LoggerDatabase).Infof(ctx, "Removed backup %s", file)
+	return nil
+}
+
+// Check 检查备份文件可以恢复：完整性检查通过，包含算法表，且没有本程序不认识的迁移，
+// 返回备份中已执行的迁移
+func (s *sBackup) Check(ctx context.Context, file string) (map[string]bool, error) {
+	if !gfile.IsFile(file) {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "backup not found: %s", file)
+	}
+	db, err := s.open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close(ctx)
+
+	result, err := db.GetValue(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, gerror.WrapCodef(gcode.CodeInvalidParameter, err, "%s is not a readable database", file)
+	}
+	if result.String() != "ok" {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "integrity check of %s failed: %s", file, result.String())
+	}
+	tables, err := s.tables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if !tables["algorithm"] {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "%s is not a device database: algorithm table missing", file)
+	}
+	applied := make(map[string]bool)
+	if tables["schema_migrations"] {
+		versions, err := db.GetArray(ctx, "SELECT `version` FROM `schema_migrations`")
+		if err != nil {
+			return nil, gerror.Wrapf(err, "read schema_migrations of %s failed", file)
+		}
+		for _, version := range versions {
+			applied[version.String()] = true
+		}
+	}
+	// 新版本程序的备份可能包含本程序没有的表结构变化，恢复后无法迁移回来
+	known := make(map[string]bool)
+	for _, version := range Database().Migrations() {
+		known[version] = true
+	}
+	var unknown []string
+	for version := range applied {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter,
+			"backup was made by a newer version, unknown migrations: %s", strings.Join(unknown, ", "))
+	}
+	return applied, nil
+}
+
+// Restore 用备份文件替换数据库：检查备份后先备份当前数据库，替换文件并执行备份之后新增的迁移。
+// restart 为 true 时稍后停止算法并重新执行程序，使各服务重新加载恢复后的状态；
+// 为 false 时仅用于未运行服务的进程(命令行)，本进程守护着算法时拒绝，否则守护进程、设备配置和调度仍持有旧库的状态
+func (s *sBackup) Restore(ctx context.Context, file string, restart bool) (*model.DatabaseRestoreOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if supervised := Supervisor().Status(); !restart && len(supervised) > 0 {
+		return nil, gerror.NewCodef(gcode.CodeInvalidOperation,
+			"%d algorithms are supervised by this process, restore with restart to reload the restored state", len(supervised))
+	}
+
+	applied, err := s.Check(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.databaseFile()
+	if err != nil {
+		return nil, err
+	}
+	out := &model.DatabaseRestoreOutput{Restored: file, Restarting: restart}
+	for _, version := range Database().Migrations() {
+		if !applied[version] {
+			out.Migrations = append(out.Migrations, version)
+		}
+	}
+	// 先保留一份当前数据库，恢复错了还能回来；当前数据库已损坏无法备份时仍继续恢复
+	if gfile.Exists(target) {
+		if pre, err := s.create(ctx, BackupPreRestore); err != nil {
+			logger(consts.LoggerDatabase).Warningf(ctx, "Backup current database before restoring failed: %v", err)
+		} else {
+			out.PreRestore = pre.Name
+		}
+	}
+
+	// 关闭连接池后替换文件，之后的查询重新打开新文件
+	if err = g.DB().Close(ctx); err != nil {
+		return nil, gerror.Wrap(err, "close database failed")
+	}
+	if err = gfile.Mkdir(filepath.Dir(target)); err != nil {
+		return nil, gerror.Wrapf(err, "create database directory failed")
+	}
+	if err = gfile.CopyFile(file, target+".restore"); err != nil {
+		return nil, gerror.Wrapf(err, "copy %s failed", file)
+	}
+	// 旧库的 WAL 不能应用到恢复的库上
+	_ = os.Remove(target + "-wal")
+	_ = os.Remove(target + "-shm")
+	if err = os.Rename(target+".restore", target); err != nil {
+		_ = os.Remove(target + ".restore")
+		return nil, gerror.Wrapf(err, "replace %s failed", target)
+	}
+	_ = g.DB().Close(ctx)
+	logger(consts.LoggerDatabase).Infof(ctx, "Database restored from %s, previous database saved as %s", file, out.PreRestore)
+
+	if err = Database().Migrate(ctx); err != nil {
+		return nil, err
+	}
+	if restart {
+		logger(consts.LoggerDatabase).Infof(ctx, "Restarting in %s to reload restored state", backupRestartDelay)
+		go func() {
+			time.Sleep(backupRestartDelay)
+			exe, err := Upgrade().executable()
+			if err != nil {
+				logger(consts.LoggerDatabase).Errorf(ctx, "Restart after restore failed: %v", err)
+				return
+			}
+			Upgrade().restartService(context.WithoutCancel(ctx), exe)
+		}()
+	}
+	return out, nil
+}
+
+// Tables 列出数据库中的表和行数
+func (s *sBackup) Tables(ctx context.Context) ([]model.DatabaseTable, error) {
+	tables, err := s.tables(ctx, g.DB())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.DatabaseTable, 0, len(tables))
+	for name := range tables {
+		count, err := g.DB().Model(name).Count()
+		if err != nil {
+			return nil, gerror.Wrapf(err, "count %s failed", name)
+		}
+		list = append(list, model.DatabaseTable{Name: name, Rows: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// Describe 返回表的列定义、行数和按第一列排序的前 limit 行
+func (s *sBackup) Describe(ctx context.Context, table string, limit int) (*model.DatabaseTableDetail, error) {
+	columns, fields, err := s.columns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	detail := &model.DatabaseTableDetail{Name: table, Columns: make([]model.DatabaseColumn, 0, len(columns))}
+	for _, name := range columns {
+		field := fields[name]
+		detail.Columns = append(detail.Columns, model.DatabaseColumn{
+			Name:    name,
+			Type:    field.Type,
+			Null:    field.Null,
+			Key:     field.Key,
+			Default: field.Default,
+		})
+	}
+	if detail.Rows, err = g.DB().Model(table).Ctx(ctx).Count(); err != nil {
+		return nil, gerror.Wrapf(err, "count %s failed", table)
+	}
+	rows, err := g.DB().Model(table).Ctx(ctx).OrderAsc(columns[0]).Limit(limit).All()
+	if err != nil {
+		return nil, gerror.Wrapf(err, "query %s failed", table)
+	}
+	detail.Sample = make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		detail.Sample = append(detail.Sample, row.Map())
+	}
+	return detail, nil
+}
+
+// Export 导出表的全部行。JSON 为对象数组；CSV 首行为列名，NULL 输出为空
+func (s *sBackup) Export(ctx context.Context, table, format string, w io.Writer) error {
+	columns, _, err := s.columns(ctx, table)
+	if err != nil {
+		return err
+	}
+	rows, err := g.DB().Model(table).Ctx(ctx).OrderAsc(columns[0]).All()
+	if err != nil {
+		return gerror.Wrapf(err, "query %s failed", table)
+	}
+	switch format {
+	case TableFormatJson:
+		list := rows.List()
+		if list == nil {
+			list = gdb.List{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(list)
+	case TableFormatCsv:
+		writer := csv.NewWriter(w)
+		if err = writer.Write(columns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				if value, ok := row[column]; ok && !value.IsNil() {
+					record[i] = value.String()
+				}
+			}
+			if err = writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported format %s, use json or csv", format)
+	}
+}
+
+// Import 导入 Export 格式的行，返回导入的行数。replace 为 true 时先清空表，全部在一个事务中完成。
+// CSV 中的空值对可为 NULL 的列导入为 NULL，缺少的列使用表的默认值
+func (s *sBackup) Import(ctx context.Context, table, format string, r io.Reader, replace bool) (int, error) {
+	if table == "schema_migrations" {
+		return 0, gerror.NewCode(gcode.CodeInvalidParameter, "schema_migrations is managed by migrations and cannot be imported")
+	}
+	_, fields, err := s.columns(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	var rows []g.Map
+	switch format {
+	case TableFormatJson:
+		rows, err = s.decodeJson(r)
+	case TableFormatCsv:
+		rows, err = s.decodeCsv(r, fields)
+	default:
+		return 0, gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported format %s, use json or csv", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		for column := range row {
+			if _, ok := fields[column]; !ok {
+				return 0, gerror.NewCodef(gcode.CodeInvalidParameter, "row %d: table %s has no column %s", i+1, table, column)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if replace {
+			if _, err := tx.Model(table).Where("1=1").Delete(); err != nil {
+				return gerror.Wrapf(err, "clear %s failed", table)
+			}
+		}
+		for i, row := range rows {
+			if _, err := tx.Model(table).Data(row).Insert(); err != nil {
+				return gerror.WrapCodef(gcode.CodeInvalidParameter, err, "insert row %d into %s failed", i+1, table)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	logger(consts.LoggerDatabase).Infof(ctx, "Imported %d rows into %s (replace: %t)", len(rows), table, replace)
+	return len(rows), nil
+}
+
+// decodeJson 解析对象数组，数字保持原样，对象和数组值保存为 JSON 文本
+func (s *sBackup) decodeJson(r io.Reader) ([]g.Map, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var list []map[string]interface{}
+	if err := decoder.Decode(&list); err != nil {
+		return nil, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid JSON, expected an array of objects")
+	}
+	rows := make([]g.Map, 0, len(list))
+	for _, item := range list {
+		row := make(g.Map, len(item))
+		for column, value := range item {
+			switch v := value.(type) {
+			case json.Number:
+				row[column] = v.String()
+			case map[string]interface{}, []interface{}:
+				content, _ := json.Marshal(v)
+				row[column] = string(content)
+			default:
+				row[column] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeCsv 解析首行为列名的 CSV
+func (s *sBackup) decodeCsv(r io.Reader, fields map[string]*gdb.TableField) ([]g.Map, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid CSV")
+	}
+	if len(records) == 0 {
+		return nil, gerror.NewCode(gcode.CodeInvalidParameter, "CSV header missing")
+	}
+	header := records[0]
+	rows := make([]g.Map, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(g.Map, len(header))
+		for i, column := range header {
+			var value interface{} = record[i]
+			if field, ok := fields[column]; ok && record[i] == "" && field.Null {
+				value = nil
+			}
+			row[column] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// columns 返回表的列名(按定义顺序)和列信息，表不存在时返回错误
+func (s *sBackup) columns(ctx context.Context, table string) ([]string, map[string]*gdb.TableField, error) {
+	tables, err := s.tables(ctx, g.DB())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !tables[table] {
+		return nil, nil, gerror.NewCodef(gcode.CodeNotFound, "table not found: %s", table)
+	}
+	fields, err := g.DB().TableFields(ctx, table)
+	if err != nil {
+		return nil, nil, gerror.Wrapf(err, "read columns of %s failed", table)
+	}
+	columns := make([]string, len(fields))
+	for name, field := range fields {
+		columns[field.Index] = name
+	}
+	return columns, fields, nil
+}
+
+// tables 返回数据库中的用户表
+func (s *sBackup) tables(ctx context.Context, db gdb.DB) (map[string]bool, error) {
+	names, err := db.GetArray(ctx, "SELECT `name` FROM `sqlite_master` WHERE `type` = 'table' AND `name` NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, gerror.Wrap(err, "list tables failed")
+	}
+	tables := make(map[string]bool, len(names))
+	for _, name := range names {
+		tables[name.String()] = true
+	}
+	return tables, nil
+}
+
+// describe 读取备份文件信息，备份中最近执行的迁移作为表结构版本
+func (s *sBackup) describe(ctx context.Context, file string) *model.DatabaseBackup {
+	backup := &model.DatabaseBackup{
+		Name:      gfile.Basename(file),
+		Size:      gfile.Size(file),
+		CreatedAt: gtime.New(gfile.MTime(file)),
+	}
+	db, err := s.open(file)
+	if err != nil {
+		return backup
+	}
+	defer db.Close(ctx)
+	if version, err := db.GetValue(ctx, "SELECT MAX(`version`) FROM `schema_migrations`"); err == nil {
+		backup.Schema = version.String()
+	}
+	return backup
+}
+
+// open 打开备份文件，用完需 Close
+func (s *sBackup) open(file string) (gdb.DB, error) {
+	db, err := gdb.New(gdb.ConfigNode{Link: "sqlite::@file(" + file + ")"})
+	if err != nil {
+		return nil, gerror.Wrapf(err, "open %s failed", file)
+	}
+	return db, nil
+}
+
+// rotate 只保留最近 keep 个定时备份
+func (s *sBackup) rotate(ctx context.Context) {
+	if s.keep <= 0 {
+		return
+	}
+	files, err := gfile.ScanDirFile(s.path, BackupAuto+"-*.db")
+	if err != nil {
+		return
+	}
+	// 文件名中的时间使名称顺序即时间顺序
+	sort.Strings(files)
+	for len(files) > s.keep {
+		if err = os.Remove(files[0]); err != nil {
+			logger(consts.LoggerDatabase).Warningf(ctx, "Remove old backup %s failed: %v", files[0], err)
+		} else {
+			logger(consts.LoggerDatabase).Infof(ctx, "Removed old backup %s", files[0])
+		}
+		files = files[1:]
+	}
+}
+
+// databaseFile 从 database.default.link 中取出 SQLite 数据库文件路径
+func (s *sBackup) databaseFile() (string, error) {
+	match := sqliteFilePattern.FindStringSubmatch(Config().Get().Database.Default.Link)
+	if match == nil {
+		return "", gerror.NewCode(gcode.CodeNotSupported, "backup only supports sqlite::@file(...) databases")
+	}
+	return match[1], nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/guid"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 批量操作类型
+const (
+	BatchOperationAdd      = "add"      // 下载并安装算法
+	BatchOperationDelete   = "delete"   // 删除算法
+	BatchOperationActivate = "activate" // 将算法期望状态设为运行并启动
+)
+
+// batchMaxItems 单个批量任务的最大条目数
+const batchMaxItems = 100
+
+// sBatch 批量算法操作服务，所有任务共享 algorithm.batchConcurrency 个执行槽位，
+// 避免同时下载和解压过多算法包。任务保存在内存中，结束后保留 algorithm.batchRetention 供查询
+type sBatch struct {
+	mu        sync.Mutex
+	jobs      map[string]*batchJob
+	slots     chan struct{}
+	retention time.Duration
+}
+
+// batchJob 执行中的批量任务
+type batchJob struct {
+	mu   sync.Mutex
+	job  model.BatchJob
+	done chan struct{}
+}
+
+// batchFunc 执行单个条目，返回条目的结果数据
+type batchFunc func(ctx context.Context, index int) (interface{}, error)
+
+var (
+	batchService *sBatch
+	batchOnce    sync.Once
+)
+
+// Batch 获取批量操作服务单例
+func Batch() *sBatch {
+	batchOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		concurrency := g.Cfg().MustGet(ctx, "algorithm.batchConcurrency", 4).Int()
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		batchService = &sBatch{
+			jobs:      make(map[string]*batchJob),
+			slots:     make(chan struct{}, concurrency),
+			retention: g.Cfg().MustGet(ctx, "algorithm.batchRetention", "24h").Duration(),
+		}
+	})
+	return batchService
+}
+
+// Add 批量安装算法，jobId 为空时自动生成
+func (s *sBatch) Add(ctx context.Context, jobId string, items []model.AlgorithmInstallInput) (*model.BatchJob, error) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.AlgorithmId
+	}
+	return s.submit(ctx, jobId, BatchOperationAdd, ids, func(ctx context.Context, index int) (interface{}, error) {
+		id, err := Algorithm().Install(ctx, items[index])
+		if err != nil {
+			return nil, err
+		}
+		return g.Map{"id": id}, nil
+	})
+}
+
+// Delete 批量删除算法
+func (s *sBatch) Delete(ctx context.Context, jobId string, algorithmIds []string) (*model.BatchJob, error) {
+	return s.submit(ctx, jobId, BatchOperationDelete, algorithmIds, func(ctx context.Context, index int) (interface{}, error) {
+		return nil, Algorithm().Delete(ctx, algorithmIds[index])
+	})
+}
+
+// Activate 批量启动算法
+func (s *sBatch) Activate(ctx context.Context, jobId string, algorithmIds []string) (*model.BatchJob, error) {
+	return s.submit(ctx, jobId, BatchOperationActivate, algorithmIds, func(ctx context.Context, index int) (interface{}, error) {
+		return nil, Algorithm().Start(ctx, algorithmIds[index])
+	})
+}
+
+// Get 查询任务进度
+func (s *sBatch) Get(jobId string) (*model.BatchJob, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobId]
+	s.mu.Unlock()
+	if !ok {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "batch job %s not found", jobId)
+	}
+	return job.snapshot(), nil
+}
+
+// Wait 等待任务结束后返回全部条目结果，ctx 取消时返回当前进度
+func (s *sBatch) Wait(ctx context.Context, jobId string) (*model.BatchJob, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[jobId]
+	s.mu.Unlock()
+	if !ok {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "batch job %s not found", jobId)
+	}
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+	}
+	return job.snapshot(), nil
+}
+
+// submit 校验条目后创建任务并在后台执行，立即返回任务初始状态
+func (s *sBatch) submit(ctx context.Context, jobId, operation string, algorithmIds []string, fn batchFunc) (*model.BatchJob, error) {
+	if len(algorithmIds) == 0 {
+		return nil, gerror.NewCode(gcode.CodeMissingParameter, "batch items are required")
+	}
+	if len(algorithmIds) > batchMaxItems {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "too many batch items: %d, at most %d", len(algorithmIds), batchMaxItems)
+	}
+	// 同一算法的条目并发执行会互相覆盖，要求 algorithmId 唯一
+	seen := make(map[string]bool, len(algorithmIds))
+	for _, id := range algorithmIds {
+		if id == "" {
+			return nil, gerror.NewCode(gcode.CodeMissingParameter, "algorithmId is required for every batch item")
+		}
+		if seen[id] {
+			return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "duplicate algorithmId in batch: %s", id)
+		}
+		seen[id] = true
+	}
+	if jobId == "" {
+		jobId = guid.S()
+	}
+
+	job := &batchJob{
+		job: model.BatchJob{
+			JobId:     jobId,
+			Operation: operation,
+			Status:    consts.BatchJobRunning,
+			Total:     len(algorithmIds),
+			Items:     make([]model.BatchItemResult, len(algorithmIds)),
+			CreatedAt: gtime.Now(),
+		},
+		done: make(chan struct{}),
+	}
+	for i, id := range algorithmIds {
+		job.job.Items[i] = model.BatchItemResult{Index: i, AlgorithmId: id, Status: consts.BatchItemPending}
+	}
+
+	s.mu.Lock()
+	s.cleanup()
+	if _, ok := s.jobs[jobId]; ok {
+		s.mu.Unlock()
+		return nil, gerror.NewCodef(gcode.CodeInvalidOperation, "batch job %s already exists", jobId)
+	}
+	s.jobs[jobId] = job
+	s.mu.Unlock()
+
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Batch job %s started: %s %d algorithms", jobId, operation, len(algorithmIds))
+	go s.run(context.WithoutCancel(ctx), job, fn)
+	return job.snapshot(), nil
+}
+
+// run 按执行槽位并发执行各条目，全部结束后关闭 done
+func (s *sBatch) run(ctx context.Context, job *batchJob, fn batchFunc) {
+	var wg sync.WaitGroup
+	for i := range job.job.Items {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			s.slots <- struct{}{}
+			defer func() { <-s.slots }()
+
+			job.update(index, func(item *model.BatchItemResult) {
+				item.Status = consts.BatchItemRunning
+			})
+			data, err := fn(WithDownloadTag(ctx, job.downloadTag(index)), index)
+			job.update(index, func(item *model.BatchItemResult) {
+				if err != nil {
+					code := gerror.Code(err)
+					if code == gcode.CodeNil {
+						code = gcode.CodeInternalError
+					}
+					item.Status = consts.BatchItemFailed
+					item.Code = code.Code()
+					item.Message = err.Error()
+					job.job.Failed++
+				} else {
+					item.Status = consts.BatchItemSuccess
+					item.Message = "success"
+					item.Data = data
+					job.job.Succeeded++
+				}
+				job.job.Completed++
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	job.mu.Lock()
+	job.job.Status = consts.BatchJobDone
+	job.job.FinishedAt = gtime.Now()
+	succeeded, failed := job.job.Succeeded, job.job.Failed
+	job.mu.Unlock()
+	close(job.done)
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Batch job %s finished: %d succeeded, %d failed", job.job.JobId, succeeded, failed)
+}
+
+// cleanup 移除结束超过保留时间的任务，调用方持有锁
+func (s *sBatch) cleanup() {
+	before := gtime.Now().Add(-s.retention)
+	for id, job := range s.jobs {
+		job.mu.Lock()
+		expired := job.job.FinishedAt != nil && job.job.FinishedAt.Before(before)
+		job.mu.Unlock()
+		if expired {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// update 修改条目状态
+func (j *batchJob) update(index int, fn func(item *model.BatchItemResult)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.job.Items[index])
+}
+
+// snapshot 返回任务当前状态的副本，执行中的条目附带下载进度
+func (j *batchJob) snapshot() *model.BatchJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job := j.job
+	job.Items = append([]model.BatchItemResult(nil), j.job.Items...)
+	for i := range job.Items {
+		if job.Items[i].Status != consts.BatchItemRunning {
+			continue
+		}
+		if progress := Download().Progress(j.downloadTag(i)); progress != nil {
+			job.Items[i].Download = progress
+			job.Throughput += progress.Throughput
+		}
+	}
+	return &job
+}
+
+// downloadTag 条目发起的下载的标识
+func (j *batchJob) downloadTag(index int) string {
+	return fmt.Sprintf("batch/%s/%d", j.job.JobId, index)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+func TestBatchCommandRepliesImmediately(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	useTestDatabase(t)
+	// 占满执行槽位，条目在回复时一定尚未开始
+	slots := cap(Batch().slots)
+	for i := 0; i < slots; i++ {
+		Batch().slots <- struct{}{}
+	}
+	released := false
+	release := func() {
+		if !released {
+			released = true
+			for i := 0; i < slots; i++ {
+				<-Batch().slots
+			}
+		}
+	}
+	defer release()
+
+	data, err := handleBatchDelete(context.Background(), gjson.New(g.Map{
+		"cmdId":        "batch-delete-1",
+		"algorithmIds": []string{"missing-1", "missing-2"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, ok := data.(*model.BatchJob)
+	if !ok {
+		t.Fatalf("reply data is %T, want *model.BatchJob", data)
+	}
+	if job.JobId != "batch-delete-1" || job.Status != consts.BatchJobRunning || job.Total != 2 {
+		t.Fatalf("job = %+v", job)
+	}
+	for _, item := range job.Items {
+		if item.Status != consts.BatchItemPending {
+			t.Fatalf("item %d status = %s, want %s", item.Index, item.Status, consts.BatchItemPending)
+		}
+	}
+
+	// 进度通过 getBatchJob 查询
+	release()
+	waitFor(t, 10*time.Second, "batch job to finish", func() bool {
+		data, err := handleGetBatchJob(context.Background(), gjson.New(g.Map{"jobId": "batch-delete-1"}))
+		return err == nil && data.(*model.BatchJob).Status == consts.BatchJobDone
+	})
+	data, _ = handleGetBatchJob(context.Background(), gjson.New(g.Map{"jobId": "batch-delete-1"}))
+	if job = data.(*model.BatchJob); job.Completed != 2 {
+		t.Fatalf("finished job = %+v", job)
+	}
+}
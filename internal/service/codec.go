@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 命令协议版本
+const (
+	ProtocolVersion1 = "1.0" // 业务字段与公共字段平铺
+	ProtocolVersion2 = "2.0" // 业务字段放在 params 中，timestamp 为 Unix 毫秒数
+)
+
+// CodeUnsupportedVersion 命令协议主版本不受支持，回复 data 中带有设备支持的版本
+var CodeUnsupportedVersion = gcode.New(1001, "Unsupported Protocol Version", nil)
+
+// CommandDecoder 将某一协议版本的命令 JSON 转换为内部模型：公共字段与业务字段平铺的 JSON，
+// 处理函数只面向内部模型，协议升级时只需注册新版本的解码器
+type CommandDecoder func(payload *gjson.Json) (*gjson.Json, error)
+
+// codecVersion 已注册的协议版本
+type codecVersion struct {
+	version string
+	decoder CommandDecoder
+}
+
+// sCodec 命令协议编解码服务，按 version 的主版本号选择解码器，同一主版本的次版本向后兼容。
+// 各版本的命令格式见 docs/command-protocol.md
+type sCodec struct {
+	mu       sync.RWMutex
+	decoders map[int]codecVersion
+}
+
+var (
+	codecService *sCodec
+	codecOnce    sync.Once
+)
+
+// Codec 获取命令协议编解码服务单例
+func Codec() *sCodec {
+	codecOnce.Do(func() {
+		codecService = &sCodec{
+			decoders: make(map[int]codecVersion),
+		}
+		codecService.Register(ProtocolVersion1, decodeCommandV1)
+		codecService.Register(ProtocolVersion2, decodeCommandV2)
+	})
+	return codecService
+}
+
+// Register 注册协议版本的解码器，同一主版本覆盖
+func (s *sCodec) Register(version string, decoder CommandDecoder) {
+	major, err := protocolMajor(version)
+	if err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decoders[major] = codecVersion{version: version, decoder: decoder}
+}
+
+// Versions 返回支持的协议版本，按主版本升序
+func (s *sCodec) Versions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	majors := make([]int, 0, len(s.decoders))
+	for major := range s.decoders {
+		majors = append(majors, major)
+	}
+	sort.Ints(majors)
+	versions := make([]string, 0, len(majors))
+	for _, major := range majors {
+		versions = append(versions, s.decoders[major].version)
+	}
+	return versions
+}
+
+// Decode 按命令的 version 解码为内部模型，返回校验后的公共字段和平铺的命令 JSON
+func (s *sCodec) Decode(ctx context.Context, payload *gjson.Json) (envelope CommandEnvelope, j *gjson.Json, err error) {
+	version := payload.Get("version").String()
+	if version == "" {
+		return envelope, nil, gerror.NewCode(gcode.CodeMissingParameter, "version is required")
+	}
+	major, err := protocolMajor(version)
+	if err != nil {
+		return envelope, nil, err
+	}
+	s.mu.RLock()
+	codec, ok := s.decoders[major]
+	s.mu.RUnlock()
+	if !ok {
+		return envelope, nil, gerror.NewCodef(CodeUnsupportedVersion,
+			"unsupported protocol version %s, supported versions: %s", version, strings.Join(s.Versions(), ", "))
+	}
+	if j, err = codec.decoder(payload); err != nil {
+		return envelope, nil, gerror.WrapCodef(gcode.CodeInvalidParameter, err, "invalid version %s command", version)
+	}
+	if err = j.Scan(&envelope); err != nil {
+		return envelope, nil, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid command envelope")
+	}
+	if err = g.Validator().Data(envelope).Run(ctx); err != nil {
+		return envelope, nil, err
+	}
+	return envelope, j, nil
+}
+
+// protocolMajor 解析协议主版本号，支持 "1"、"1.0"、"v2" 等写法
+func protocolMajor(version string) (int, error) {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil || n <= 0 {
+		return 0, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid protocol version: %s", version)
+	}
+	return n, nil
+}
+
+// decodeCommandV1 v1 命令即内部模型
+func decodeCommandV1(payload *gjson.Json) (*gjson.Json, error) {
+	return payload, nil
+}
+
+// decodeCommandV2 v2 命令的业务字段在 params 对象中，timestamp 为 Unix 毫秒数：
+//
+//	{"version":"2.0","cmdId":"...","method":"addAlgorithm","timestamp":1760000000000,"params":{"algorithmId":"..."}}
+//
+// 转换为内部模型时将 params 平铺到顶层，params 中与公共字段同名的键被忽略，
+// 见 docs/command-protocol.md 的 v2 一节
+func decodeCommandV2(payload *gjson.Json) (*gjson.Json, error) {
+	params := payload.Get("params")
+	if !params.IsNil() && !params.IsMap() {
+		return nil, gerror.New("params must be an object")
+	}
+	flat := make(map[string]interface{})
+	for key, value := range params.Map() {
+		if !commandEnvelopeFields[key] {
+			flat[key] = value
+		}
+	}
+	for key, value := range payload.Map() {
+		if key != "params" {
+			flat[key] = value
+		}
+	}
+	return gjson.New(flat), nil
+}
+
+// commandEnvelopeFields 命令公共字段，与 CommandEnvelope 对应
+var commandEnvelopeFields = map[string]bool{"cmdId": true, "version": true, "method": true, "timestamp": true}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+func TestCodecDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		wantCode gcode.Code
+		want     CommandEnvelope
+		wantKeys g.Map // 解码后的业务字段
+	}{
+		{
+			name:     "v1",
+			payload:  `{"version":"1.0","cmdId":"c-1","method":"startAlgorithm","timestamp":"1760000000","algorithmId":"a-1"}`,
+			want:     CommandEnvelope{CmdId: "c-1", Version: "1.0", Method: "startAlgorithm", Timestamp: "1760000000"},
+			wantKeys: g.Map{"algorithmId": "a-1"},
+		},
+		{
+			name:     "v1 minor version",
+			payload:  `{"version":"1.3","cmdId":"c-1","method":"startAlgorithm","timestamp":"1760000000","algorithmId":"a-1"}`,
+			want:     CommandEnvelope{CmdId: "c-1", Version: "1.3", Method: "startAlgorithm", Timestamp: "1760000000"},
+			wantKeys: g.Map{"algorithmId": "a-1"},
+		},
+		{
+			name:     "v2",
+			payload:  `{"version":"2.0","cmdId":"c-2","method":"startAlgorithm","timestamp":1760000000000,"params":{"algorithmId":"a-2"}}`,
+			want:     CommandEnvelope{CmdId: "c-2", Version: "2.0", Method: "startAlgorithm", Timestamp: "1760000000000"},
+			wantKeys: g.Map{"algorithmId": "a-2", "params": nil},
+		},
+		{
+			name:     "v2 common fields win",
+			payload:  `{"version":"v2","cmdId":"c-2","method":"startAlgorithm","timestamp":1760000000000,"params":{"cmdId":"other","algorithmId":"a-2"}}`,
+			want:     CommandEnvelope{CmdId: "c-2", Version: "v2", Method: "startAlgorithm", Timestamp: "1760000000000"},
+			wantKeys: g.Map{"algorithmId": "a-2"},
+		},
+		{
+			name:    "v2 without params",
+			payload: `{"version":"2.0","cmdId":"c-2","method":"getThrottle","timestamp":1760000000000}`,
+			want:    CommandEnvelope{CmdId: "c-2", Version: "2.0", Method: "getThrottle", Timestamp: "1760000000000"},
+		},
+		{
+			name:     "v2 params not an object",
+			payload:  `{"version":"2.0","cmdId":"c-2","method":"startAlgorithm","timestamp":1760000000000,"params":["a-2"]}`,
+			wantCode: gcode.CodeInvalidParameter,
+		},
+		{
+			name:     "v3",
+			payload:  `{"version":"3.0","cmdId":"c-3","method":"startAlgorithm","timestamp":1760000000000,"body":{"algorithmId":"a-3"}}`,
+			wantCode: CodeUnsupportedVersion,
+		},
+		{
+			name:     "missing version",
+			payload:  `{"cmdId":"c-1","method":"startAlgorithm","timestamp":"1760000000"}`,
+			wantCode: gcode.CodeMissingParameter,
+		},
+		{
+			name:     "invalid version",
+			payload:  `{"version":"latest","cmdId":"c-1","method":"startAlgorithm","timestamp":"1760000000"}`,
+			wantCode: gcode.CodeInvalidParameter,
+		},
+		{
+			name:     "missing cmdId",
+			payload:  `{"version":"2.0","method":"startAlgorithm","timestamp":1760000000000,"params":{"cmdId":"c-2"}}`,
+			wantCode: gcode.CodeValidationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, j, err := Codec().Decode(context.Background(), gjson.New(tt.payload))
+			if tt.wantCode != nil {
+				if err == nil || gerror.Code(err) != tt.wantCode {
+					t.Fatalf("Decode error = %v (code %v), want code %v", err, gerror.Code(err), tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if envelope != tt.want {
+				t.Fatalf("envelope = %+v, want %+v", envelope, tt.want)
+			}
+			for key, want := range tt.wantKeys {
+				if got := j.Get(key).Val(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCodecUnsupportedVersionReply(t *testing.T) {
+	s := &sCommand{handlers: make(map[string]CommandHandler)}
+	reply := s.Dispatch(context.Background(), []byte(`{"version":"3.0","cmdId":"c-3","method":"startAlgorithm","timestamp":1760000000000}`))
+	if reply == nil {
+		t.Fatal("no reply")
+	}
+	if reply.Code != 1001 || reply.CmdId != "c-3" || reply.Method != "startAlgorithm" || reply.Version != "3.0" {
+		t.Fatalf("reply = %+v", reply)
+	}
+	want := g.Map{"supportedVersions": []string{ProtocolVersion1, ProtocolVersion2}}
+	if !reflect.DeepEqual(reply.Data, want) {
+		t.Fatalf("reply data = %v, want %v", reply.Data, want)
+	}
+}
+
+func TestCodecVersions(t *testing.T) {
+	codec := &sCodec{decoders: make(map[int]codecVersion)}
+	codec.Register(ProtocolVersion2, decodeCommandV2)
+	codec.Register("10.0", decodeCommandV1)
+	codec.Register(ProtocolVersion1, decodeCommandV1)
+	if got, want := codec.Versions(), []string{"1.0", "2.0", "10.0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Versions() = %v, want %v", got, want)
+	}
+	// 同一主版本后注册的覆盖先注册的
+	codec.Register("2.1", decodeCommandV2)
+	if got, want := codec.Versions(), []string{"1.0", "2.1", "10.0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Versions() = %v, want %v", got, want)
+	}
+
+	// 注册消息通告支持的版本
+	loadTestConfig(t, g.Map{})
+	registration := Device().Registration()
+	if want := []string{ProtocolVersion1, ProtocolVersion2}; !reflect.DeepEqual(registration.ProtocolVersions, want) {
+		t.Fatalf("registration protocolVersions = %v, want %v", registration.ProtocolVersions, want)
+	}
+	if encoded := gjson.MustEncodeString(registration); !gjson.New(encoded).Contains("protocolVersions") {
+		t.Fatalf("registration %s does not advertise protocolVersions", encoded)
+	}
+}
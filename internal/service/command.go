@@ -0,0 +1,503 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 命令 method 取值
+const (
+	MethodAddAlgorithm     = "addAlgorithm"
+	MethodStartAlgorithm   = "startAlgorithm"
+	MethodStopAlgorithm    = "stopAlgorithm"
+	MethodRestartAlgorithm = "restartAlgorithm"
+	MethodSetConfig        = "setConfig"
+	MethodGetConfig        = "getConfig"
+	MethodSetSchedule      = "setSchedule"
+	MethodGetSchedule      = "getSchedule"
+	MethodBatchAdd         = "batchAddAlgorithm"
+	MethodBatchDelete      = "batchDeleteAlgorithm"
+	MethodBatchActivate    = "batchActivateAlgorithm"
+	MethodGetBatchJob      = "getBatchJob"
+	MethodSetThrottle      = "setDownloadThrottle"
+	MethodGetThrottle      = "getDownloadThrottle"
+	MethodSetDeviceConfig  = "setDeviceConfig"
+	MethodGetDeviceConfig  = "getDeviceConfig"
+	MethodUpgradeFirmware  = "upgradeFirmware"
+)
+
+// CommandEnvelope 命令公共字段，Codec() 解码后业务字段与公共字段平铺在同一 JSON 对象中
+type CommandEnvelope struct {
+	CmdId     string `json:"cmdId"     v:"required"`
+	Version   string `json:"version"   v:"required"`
+	Method    string `json:"method"    v:"required"`
+	Timestamp string `json:"timestamp" v:"required"`
+}
+
+// CommandReply 命令执行结果，发布到 reply 主题
+type CommandReply struct {
+	CmdId     string      `json:"cmdId"`
+	Method    string      `json:"method"`
+	Version   string      `json:"version,omitempty"` // 命令的协议版本，各版本的回复格式相同
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+	// TraceParent 命令处理 span 的 W3C traceparent，云端据此关联设备侧链路
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// CommandHandler 命令处理函数，payload 为解码为内部模型的完整命令 JSON
+type CommandHandler func(ctx context.Context, payload *gjson.Json) (data interface{}, err error)
+
+// AlgorithmControlPayload 启动/停止/重启算法命令参数
+type AlgorithmControlPayload struct {
+	AlgorithmId string `json:"algorithmId" v:"required"`
+	Timeout     int    `json:"timeout"     v:"min:0"` // SIGTERM 后等待秒数，0 使用默认配置
+}
+
+// AlgorithmConfigPayload 算法配置命令参数
+type AlgorithmConfigPayload struct {
+	AlgorithmId string      `json:"algorithmId" v:"required|regex:^[A-Za-z0-9._-]+$|not-in:.,.."`
+	Revision    int         `json:"revision"    v:"min:0"` // getConfig 指定版本，0 为最新
+	Config      interface{} `json:"config"`                // setConfig 的配置内容
+}
+
+// AlgorithmSchedulePayload 算法时间窗命令参数，setSchedule 用 schedules 替换全部时间窗
+type AlgorithmSchedulePayload struct {
+	AlgorithmId string                `json:"algorithmId" v:"required"`
+	Schedules   []model.ScheduleInput `json:"schedules"`
+}
+
+// AlgorithmBatchAddPayload 批量添加算法命令参数
+type AlgorithmBatchAddPayload struct {
+	CmdId string                    `json:"cmdId"`
+	Items []model.AlgorithmAddInput `json:"items" v:"required"`
+}
+
+// AlgorithmBatchPayload 批量删除/启动算法命令参数
+type AlgorithmBatchPayload struct {
+	CmdId        string   `json:"cmdId"`
+	AlgorithmIds []string `json:"algorithmIds" v:"required"`
+}
+
+// BatchJobPayload 查询批量任务进度命令参数
+type BatchJobPayload struct {
+	JobId string `json:"jobId" v:"required"`
+}
+
+// DownloadThrottlePayload 下载限速命令参数，与 PUT /download/throttle 相同
+type DownloadThrottlePayload struct {
+	model.DownloadThrottle
+	Reset bool `json:"reset"` // 恢复配置文件的设置
+}
+
+// DeviceConfigPayload 设备配置命令参数
+type DeviceConfigPayload struct {
+	Config         *model.DeviceSettings `json:"config"`             // setDeviceConfig 的配置内容，替换当前版本的全部设置
+	RollbackWindow string                `json:"rollbackWindow"`     // setDeviceConfig 的回滚时间窗，如 10m，默认 deviceConfig.rollbackWindow
+	Revision       int                   `json:"revision" v:"min:0"` // getDeviceConfig 指定版本，0 为当前状态
+}
+
+// FirmwareUpgradePayload 程序自升级命令参数，摘要至少提供一个
+type FirmwareUpgradePayload struct {
+	CmdId           string `json:"cmdId"`
+	FirmwareVersion string `json:"firmwareVersion" v:"required"`
+	Url             string `json:"url"             v:"required|url"`
+	FileSize        int64  `json:"fileSize"        v:"min:0"`
+	Md5             string `json:"md5"`
+	Sha256          string `json:"sha256"`
+	Digest          string `json:"digest"`
+	HealthTimeout   string `json:"healthTimeout"` // 重启后通过健康检查的时限，默认 upgrade.healthTimeout
+}
+
+// sCommand 云端命令分发服务，订阅命令主题并按 method 分发到处理函数
+type sCommand struct {
+	mu        sync.RWMutex
+	handlers  map[string]CommandHandler
+	maxSkew   time.Duration // 命令 timestamp 与设备时间允许的最大偏差，0 不校验
+	retention time.Duration // 已处理命令记录的保留时间
+}
+
+var (
+	commandService *sCommand
+	commandOnce    sync.Once
+)
+
+// Command 获取命令分发服务单例
+func Command() *sCommand {
+	commandOnce.Do(func() {
+		ctx := context.Background()
+		commandService = &sCommand{
+			handlers:  make(map[string]CommandHandler),
+			maxSkew:   g.Cfg().MustGet(ctx, "command.maxSkew", "5m").Duration(),
+			retention: g.Cfg().MustGet(ctx, "command.retention", "24h").Duration(),
+		}
+		commandService.Register(MethodAddAlgorithm, handleAddAlgorithm)
+		commandService.Register(MethodStartAlgorithm, handleStartAlgorithm)
+		commandService.Register(MethodStopAlgorithm, handleStopAlgorithm)
+		commandService.Register(MethodRestartAlgorithm, handleRestartAlgorithm)
+		commandService.Register(MethodSetConfig, handleSetConfig)
+		commandService.Register(MethodGetConfig, handleGetConfig)
+		commandService.Register(MethodSetSchedule, handleSetSchedule)
+		commandService.Register(MethodGetSchedule, handleGetSchedule)
+		commandService.Register(MethodBatchAdd, handleBatchAdd)
+		commandService.Register(MethodBatchDelete, handleBatchDelete)
+		commandService.Register(MethodBatchActivate, handleBatchActivate)
+		commandService.Register(MethodGetBatchJob, handleGetBatchJob)
+		commandService.Register(MethodSetThrottle, handleSetThrottle)
+		commandService.Register(MethodGetThrottle, handleGetThrottle)
+		commandService.Register(MethodSetDeviceConfig, handleSetDeviceConfig)
+		commandService.Register(MethodGetDeviceConfig, handleGetDeviceConfig)
+		commandService.Register(MethodUpgradeFirmware, handleUpgradeFirmware)
+	})
+	return commandService
+}
+
+// Register 注册命令处理函数，同名 method 覆盖
+func (s *sCommand) Register(method string, handler CommandHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Start 通过 Transport() 配置的通道接收命令
+func (s *sCommand) Start(ctx context.Context) error {
+	s.startDedup(ctx)
+	return Transport().Start(ctx, s.Handle)
+}
+
+// Handle 执行一条命令并通过 reply 回复结果，MQTT 和 HTTP 通道共用
+func (s *sCommand) Handle(msg *model.CommandMessage, reply ReplyFunc) {
+	// 以 cmdId 生成 traceId，命令执行过程中的下载、安装日志都带有同一 traceId；
+	// 命令带有 traceparent(v5 用户属性、HTTP 请求头或 JSON 字段)时沿用云端链路
+	j := gjson.New(msg.Payload)
+	for key, value := range msg.Properties {
+		if !j.Contains(key) && (key == traceParentField || key == traceStateField) {
+			_ = j.Set(key, value)
+		}
+	}
+	ctx := Tracing().Extract(Logging().CommandContext(j.Get("cmdId").String()), j)
+	ctx, span := Tracing().StartSpan(ctx, "command "+j.Get("method").String(),
+		attribute.String("cmd.id", j.Get("cmdId").String()),
+		attribute.String("cmd.method", j.Get("method").String()),
+		attribute.String("cmd.transport", Transport().Name()),
+	)
+	var err error
+	defer func() { Tracing().EndSpan(span, err) }()
+
+	result := s.Dispatch(ctx, msg.Payload)
+	if result == nil {
+		return
+	}
+	result.TraceParent = Tracing().TraceParent(ctx)
+	span.SetAttributes(attribute.Int("cmd.code", result.Code))
+	if result.Code != gcode.CodeOK.Code() {
+		span.SetStatus(codes.Error, result.Message)
+	}
+	if err = reply(ctx, result); err != nil {
+		logger(consts.LoggerCommand).Errorf(ctx, "Reply command %s failed: %v", result.CmdId, err)
+	}
+}
+
+// Dispatch 按协议版本解码命令并调用对应处理函数，返回执行结果。
+// 同一 cmdId 只执行一次：已完成的返回缓存结果，仍在执行的返回 nil，由首次执行负责回复。
+func (s *sCommand) Dispatch(ctx context.Context, payload []byte) *CommandReply {
+	reply := &CommandReply{Timestamp: gtime.TimestampMilli()}
+	raw, err := gjson.DecodeToJson(payload)
+	if err != nil {
+		return reply.fail(gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid command payload"))
+	}
+	reply.CmdId = raw.Get("cmdId").String()
+	reply.Method = raw.Get("method").String()
+	reply.Version = raw.Get("version").String()
+	envelope, j, err := Codec().Decode(ctx, raw)
+	if err != nil {
+		if gerror.Code(err) == CodeUnsupportedVersion {
+			logger(consts.LoggerCommand).Warningf(ctx, "Rejected command %s: %v", reply.CmdId, err)
+			reply.Data = g.Map{"supportedVersions": Codec().Versions()}
+		}
+		return reply.fail(err)
+	}
+	if err = s.checkTimestamp(envelope.Timestamp); err != nil {
+		logger(consts.LoggerCommand).Warningf(ctx, "Rejected command %s: %v", envelope.CmdId, err)
+		return reply.fail(err)
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[envelope.Method]
+	s.mu.RUnlock()
+	if !ok {
+		return reply.fail(gerror.NewCodef(gcode.CodeNotSupported, "unsupported method: %s", envelope.Method))
+	}
+
+	claimed, cached, err := s.claim(ctx, envelope)
+	if err != nil {
+		return reply.fail(gerror.Wrapf(err, "register command %s failed", envelope.CmdId))
+	}
+	if !claimed {
+		if cached == nil {
+			logger(consts.LoggerCommand).Infof(ctx, "Command %s is already in progress, ignoring duplicate", envelope.CmdId)
+		} else {
+			logger(consts.LoggerCommand).Infof(ctx, "Command %s already processed, returning cached result", envelope.CmdId)
+		}
+		return cached
+	}
+
+	logger(consts.LoggerCommand).Infof(ctx, "Handling command %s: %s", envelope.CmdId, envelope.Method)
+	started := time.Now()
+	data, err := handler(ctx, j)
+	if err != nil {
+		logger(consts.LoggerCommand).Warningf(ctx, "Command %s failed after %s: %v", envelope.CmdId, time.Since(started), err)
+		reply.fail(err)
+	} else {
+		reply.Code = gcode.CodeOK.Code()
+		reply.Message = "success"
+		reply.Data = data
+	}
+	if err = s.complete(ctx, reply); err != nil {
+		logger(consts.LoggerCommand).Warningf(ctx, "Cache result of command %s failed: %v", envelope.CmdId, err)
+	}
+	return reply
+}
+
+// fail 填充失败信息
+func (r *CommandReply) fail(err error) *CommandReply {
+	code := gerror.Code(err)
+	if code == gcode.CodeNil {
+		code = gcode.CodeInternalError
+	}
+	r.Code = code.Code()
+	r.Message = err.Error()
+	return r
+}
+
+// scanPayload 将命令 JSON 转换为参数结构体并校验
+func scanPayload(ctx context.Context, payload *gjson.Json, pointer interface{}) error {
+	if err := payload.Scan(pointer); err != nil {
+		return gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid command payload")
+	}
+	return g.Validator().Data(pointer).Run(ctx)
+}
+
+// handleAddAlgorithm 下发算法，参数与 POST /algorithm 相同
+func handleAddAlgorithm(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in model.AlgorithmAddInput
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	id, err := Algorithm().Install(ctx, model.AlgorithmInstallInput{AlgorithmAddInput: in})
+	if err != nil {
+		return nil, err
+	}
+	return g.Map{"id": id}, nil
+}
+
+// handleStartAlgorithm 启动算法
+func handleStartAlgorithm(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmControlPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return nil, Algorithm().Start(ctx, in.AlgorithmId)
+}
+
+// handleStopAlgorithm 优雅停止算法
+func handleStopAlgorithm(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmControlPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return nil, Algorithm().Stop(ctx, in.AlgorithmId, time.Duration(in.Timeout)*time.Second)
+}
+
+// handleRestartAlgorithm 重启算法
+func handleRestartAlgorithm(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmControlPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return nil, Algorithm().Restart(ctx, in.AlgorithmId, time.Duration(in.Timeout)*time.Second)
+}
+
+// handleSetConfig 保存算法配置并通知算法热加载
+func handleSetConfig(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmConfigPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	if in.Config == nil {
+		return nil, gerror.NewCode(gcode.CodeMissingParameter, "config is required")
+	}
+	revision, err := AlgorithmConfig().Set(ctx, in.AlgorithmId, in.Config)
+	if err != nil {
+		return nil, err
+	}
+	return g.Map{"revision": revision}, nil
+}
+
+// handleGetConfig 查询算法配置
+func handleGetConfig(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmConfigPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	config, err := AlgorithmConfig().Get(ctx, in.AlgorithmId, in.Revision)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	content, err := gjson.Decode(config.Content)
+	if err != nil {
+		return nil, err
+	}
+	return g.Map{"revision": config.Revision, "config": content}, nil
+}
+
+// handleSetSchedule 替换算法的全部运行时间窗
+func handleSetSchedule(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmSchedulePayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	if err := Schedule().Replace(ctx, in.AlgorithmId, in.Schedules); err != nil {
+		return nil, err
+	}
+	return Schedule().Transitions(ctx, in.AlgorithmId)
+}
+
+// handleGetSchedule 查询算法运行时间窗
+func handleGetSchedule(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmSchedulePayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return Schedule().List(ctx, in.AlgorithmId)
+}
+
+// handleBatchAdd 批量下发算法，任务ID为 cmdId。创建任务后立即回复任务和各条目的初始状态，
+// 进度通过 getBatchJob 或 GET /algorithm/batch/{jobId} 查询
+func handleBatchAdd(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmBatchAddPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	inputs := make([]model.AlgorithmInstallInput, len(in.Items))
+	for i, item := range in.Items {
+		inputs[i] = model.AlgorithmInstallInput{AlgorithmAddInput: item}
+	}
+	return Batch().Add(ctx, in.CmdId, inputs)
+}
+
+// handleBatchDelete 批量删除算法，任务ID为 cmdId，立即回复任务初始状态
+func handleBatchDelete(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmBatchPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return Batch().Delete(ctx, in.CmdId, in.AlgorithmIds)
+}
+
+// handleBatchActivate 批量启动算法，任务ID为 cmdId，立即回复任务初始状态
+func handleBatchActivate(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in AlgorithmBatchPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return Batch().Activate(ctx, in.CmdId, in.AlgorithmIds)
+}
+
+// handleGetBatchJob 查询批量任务进度
+func handleGetBatchJob(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in BatchJobPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return Batch().Get(in.JobId)
+}
+
+// handleSetThrottle 实时调整下载限速，回复调整后的限速状态
+func handleSetThrottle(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in DownloadThrottlePayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	if in.Reset {
+		Throttle().Reset(ctx)
+	} else if err := Throttle().Set(ctx, in.DownloadThrottle); err != nil {
+		return nil, err
+	}
+	return Throttle().Status(ctx), nil
+}
+
+// handleGetThrottle 查询下载限速状态和进行中的下载
+func handleGetThrottle(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	return Throttle().Status(ctx), nil
+}
+
+// handleSetDeviceConfig 保存并应用设备配置，回滚时间窗内失去连接则自动回滚，回复应用后的状态
+func handleSetDeviceConfig(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in DeviceConfigPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	if in.Config == nil {
+		return nil, gerror.NewCode(gcode.CodeMissingParameter, "config is required")
+	}
+	var window time.Duration
+	if in.RollbackWindow != "" {
+		var err error
+		if window, err = time.ParseDuration(in.RollbackWindow); err != nil || window <= 0 {
+			return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid rollbackWindow: %s", in.RollbackWindow)
+		}
+	}
+	return DeviceConfig().Set(ctx, *in.Config, window)
+}
+
+// handleGetDeviceConfig 查询当前应用的设备配置和生效的设置，指定 revision 时查询该版本
+func handleGetDeviceConfig(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in DeviceConfigPayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	if in.Revision > 0 {
+		revision, err := DeviceConfig().Get(ctx, in.Revision)
+		if err != nil {
+			return nil, err
+		}
+		if revision == nil {
+			return nil, gerror.NewCodef(gcode.CodeNotFound, "device config revision %d not found", in.Revision)
+		}
+		return revision, nil
+	}
+	return DeviceConfig().Status(ctx), nil
+}
+
+// handleUpgradeFirmware 下载校验新版本程序并替换，回复后重启，重启后未通过健康检查时自动回滚。
+// 升级结果随心跳上报
+func handleUpgradeFirmware(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+	var in FirmwareUpgradePayload
+	if err := scanPayload(ctx, payload, &in); err != nil {
+		return nil, err
+	}
+	return Upgrade().Start(ctx, in.CmdId, model.FirmwareUpgradeInput{
+		FirmwareVersion: in.FirmwareVersion,
+		Url:             in.Url,
+		FileSize:        in.FileSize,
+		Md5:             in.Md5,
+		Sha256:          in.Sha256,
+		Digest:          in.Digest,
+		HealthTimeout:   in.HealthTimeout,
+	})
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model/do"
+	"demo/internal/model/entity"
+)
+
+// commandTime 解析命令 timestamp，支持 Unix 秒、Unix 毫秒和日期时间字符串
+func commandTime(timestamp string) (time.Time, error) {
+	if n, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		// 毫秒时间戳从 2001 年起超过 1e12
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	t, err := gtime.StrToTime(timestamp)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Time, nil
+}
+
+// checkTimestamp 拒绝 timestamp 与设备时间相差超过 command.maxSkew 的命令，防止重放截获的命令
+func (s *sCommand) checkTimestamp(timestamp string) error {
+	if s.maxSkew <= 0 {
+		return nil
+	}
+	t, err := commandTime(timestamp)
+	if err != nil {
+		return gerror.WrapCodef(gcode.CodeInvalidParameter, err, "invalid command timestamp: %s", timestamp)
+	}
+	if skew := time.Since(t); skew > s.maxSkew || skew < -s.maxSkew {
+		return gerror.NewCodef(gcode.CodeInvalidRequest, "command timestamp %s is outside the allowed skew of %s", timestamp, s.maxSkew)
+	}
+	return nil
+}
+
+// claim 登记开始执行的命令。cmdId 已登记时返回 false，
+// 已完成的命令同时返回缓存的执行结果，执行中的命令返回 nil
+func (s *sCommand) claim(ctx context.Context, envelope CommandEnvelope) (bool, *CommandReply, error) {
+	result, err := dao.ProcessedCommand.Ctx(ctx).Data(do.ProcessedCommand{
+		CmdId:  envelope.CmdId,
+		Method: envelope.Method,
+		Status: consts.CommandStatusRunning,
+	}).InsertIgnore()
+	if err != nil {
+		return false, nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return true, nil, nil
+	}
+
+	var processed *entity.ProcessedCommand
+	err = dao.ProcessedCommand.Ctx(ctx).Where(dao.ProcessedCommand.Columns().CmdId, envelope.CmdId).Scan(&processed)
+	if err != nil || processed == nil || processed.Status != consts.CommandStatusDone {
+		return false, nil, err
+	}
+	var reply *CommandReply
+	if err = gjson.DecodeTo(processed.Reply, &reply); err != nil {
+		return false, nil, gerror.Wrapf(err, "decode cached reply of command %s failed", envelope.CmdId)
+	}
+	return false, reply, nil
+}
+
+// complete 缓存命令执行结果
+func (s *sCommand) complete(ctx context.Context, reply *CommandReply) error {
+	content, err := gjson.Encode(reply)
+	if err != nil {
+		return err
+	}
+	_, err = dao.ProcessedCommand.Ctx(ctx).Where(dao.ProcessedCommand.Columns().CmdId, reply.CmdId).Data(do.ProcessedCommand{
+		Status: consts.CommandStatusDone,
+		Reply:  string(content),
+	}).Update()
+	return err
+}
+
+// startDedup 清除上次运行中断的命令记录，使云端重发时重新执行，并定时清理过期记录
+func (s *sCommand) startDedup(ctx context.Context) {
+	_, err := dao.ProcessedCommand.Ctx(ctx).Where(dao.ProcessedCommand.Columns().Status, consts.CommandStatusRunning).Delete()
+	if err != nil {
+		logger(consts.LoggerCommand).Warningf(ctx, "Clear interrupted commands failed: %v", err)
+	}
+	gtimer.AddSingleton(ctx, time.Hour, s.cleanProcessed)
+}
+
+// cleanProcessed 删除超过保留时间的命令记录。早于 command.maxSkew 的命令会被拒绝，
+// 保留时间不短于该窗口即可防止重复执行
+func (s *sCommand) cleanProcessed(ctx context.Context) {
+	retention := s.retention
+	if retention < s.maxSkew {
+		retention = s.maxSkew
+	}
+	before := gtime.Now().Add(-retention)
+	result, err := dao.ProcessedCommand.Ctx(ctx).WhereLT(dao.ProcessedCommand.Columns().CreatedAt, before).Delete()
+	if err != nil {
+		logger(consts.LoggerCommand).Warningf(ctx, "Clean processed commands failed: %v", err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		logger(consts.LoggerCommand).Infof(ctx, "Cleaned %d processed commands before %s", affected, before)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model/do"
+)
+
+// newDedupCommand 返回使用测试数据库的命令服务，method "echo" 记录执行次数
+func newDedupCommand(t *testing.T, calls *int) *sCommand {
+	t.Helper()
+	useTestDatabase(t)
+	if _, err := dao.ProcessedCommand.Ctx(context.Background()).Where("1=1").Delete(); err != nil {
+		t.Fatal(err)
+	}
+	s := &sCommand{
+		handlers:  make(map[string]CommandHandler),
+		maxSkew:   5 * time.Minute,
+		retention: time.Hour,
+	}
+	s.Register("echo", func(ctx context.Context, payload *gjson.Json) (interface{}, error) {
+		*calls++
+		return g.Map{"calls": *calls}, nil
+	})
+	return s
+}
+
+// dedupPayload 生成 v1 命令
+func dedupPayload(cmdId, timestamp string) []byte {
+	return gjson.MustEncode(g.Map{
+		"version":   ProtocolVersion1,
+		"cmdId":     cmdId,
+		"method":    "echo",
+		"timestamp": timestamp,
+	})
+}
+
+// insertProcessed 写入一条命令记录
+func insertProcessed(t *testing.T, cmdId, status, reply string, createdAt *gtime.Time) {
+	t.Helper()
+	_, err := dao.ProcessedCommand.Ctx(context.Background()).Data(do.ProcessedCommand{
+		CmdId:     cmdId,
+		Method:    "echo",
+		Status:    status,
+		Reply:     reply,
+		CreatedAt: createdAt,
+	}).Insert()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// processedStatus 返回命令记录的状态，不存在时返回空
+func processedStatus(t *testing.T, cmdId string) string {
+	t.Helper()
+	value, err := dao.ProcessedCommand.Ctx(context.Background()).
+		Where(dao.ProcessedCommand.Columns().CmdId, cmdId).Value(dao.ProcessedCommand.Columns().Status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value.String()
+}
+
+func TestCommandDedup(t *testing.T) {
+	cached := gjson.MustEncodeString(&CommandReply{CmdId: "cmd-1", Method: "echo", Message: "cached"})
+	tests := []struct {
+		name        string
+		status      string // 已有记录的状态，为空时没有记录
+		reply       string
+		wantCalls   int
+		wantReply   bool
+		wantMessage string
+	}{
+		{name: "new command", wantCalls: 1, wantReply: true, wantMessage: "success"},
+		{name: "duplicate after done", status: consts.CommandStatusDone, reply: cached, wantReply: true, wantMessage: "cached"},
+		{name: "duplicate while running", status: consts.CommandStatusRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			s := newDedupCommand(t, &calls)
+			if tt.status != "" {
+				insertProcessed(t, "cmd-1", tt.status, tt.reply, nil)
+			}
+			reply := s.Dispatch(context.Background(), dedupPayload("cmd-1", strconv.FormatInt(time.Now().Unix(), 10)))
+			if calls != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if (reply != nil) != tt.wantReply {
+				t.Fatalf("reply = %+v, want reply %v", reply, tt.wantReply)
+			}
+			if reply != nil && reply.Message != tt.wantMessage {
+				t.Fatalf("reply message = %q, want %q", reply.Message, tt.wantMessage)
+			}
+		})
+	}
+
+	t.Run("redelivered command", func(t *testing.T) {
+		var calls int
+		s := newDedupCommand(t, &calls)
+		payload := dedupPayload("cmd-2", strconv.FormatInt(time.Now().UnixMilli(), 10))
+		first := s.Dispatch(context.Background(), payload)
+		second := s.Dispatch(context.Background(), payload)
+		if calls != 1 {
+			t.Fatalf("handler called %d times, want 1", calls)
+		}
+		if first == nil || second == nil || gjson.MustEncodeString(first) != gjson.MustEncodeString(second) {
+			t.Fatalf("cached reply %+v differs from first reply %+v", second, first)
+		}
+		if status := processedStatus(t, "cmd-2"); status != consts.CommandStatusDone {
+			t.Fatalf("status = %q, want %q", status, consts.CommandStatusDone)
+		}
+	})
+}
+
+func TestCommandTimestamp(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		timestamp string
+		maxSkew   time.Duration
+		wantCode  gcode.Code
+	}{
+		{name: "seconds", timestamp: strconv.FormatInt(now.Unix(), 10), wantCode: gcode.CodeOK},
+		{name: "seconds too old", timestamp: strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10), wantCode: gcode.CodeInvalidRequest},
+		{name: "seconds in future", timestamp: strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10), wantCode: gcode.CodeInvalidRequest},
+		{name: "milliseconds", timestamp: strconv.FormatInt(now.Add(-4*time.Minute).UnixMilli(), 10), wantCode: gcode.CodeOK},
+		{name: "milliseconds too old", timestamp: strconv.FormatInt(now.Add(-6*time.Minute).UnixMilli(), 10), wantCode: gcode.CodeInvalidRequest},
+		{name: "milliseconds in future", timestamp: strconv.FormatInt(now.Add(6*time.Minute).UnixMilli(), 10), wantCode: gcode.CodeInvalidRequest},
+		{name: "date string", timestamp: now.Format(time.RFC3339), wantCode: gcode.CodeOK},
+		{name: "date string too old", timestamp: now.Add(-time.Hour).Format(time.RFC3339), wantCode: gcode.CodeInvalidRequest},
+		{name: "date string in future", timestamp: now.Add(time.Hour).Format("2006-01-02 15:04:05"), wantCode: gcode.CodeInvalidRequest},
+		{name: "invalid", timestamp: "yesterday", wantCode: gcode.CodeInvalidParameter},
+		{name: "skew disabled", timestamp: "1", maxSkew: -1, wantCode: gcode.CodeOK},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			s := newDedupCommand(t, &calls)
+			if tt.maxSkew != 0 {
+				s.maxSkew = tt.maxSkew
+			}
+			reply := s.Dispatch(context.Background(), dedupPayload("ts-"+strconv.Itoa(i), tt.timestamp))
+			if reply == nil || reply.Code != tt.wantCode.Code() {
+				t.Fatalf("reply = %+v, want code %d", reply, tt.wantCode.Code())
+			}
+			// 被拒绝的命令不登记，时间校准后重发仍会执行
+			wantStatus := ""
+			if tt.wantCode == gcode.CodeOK {
+				wantStatus = consts.CommandStatusDone
+			}
+			if status := processedStatus(t, "ts-"+strconv.Itoa(i)); status != wantStatus {
+				t.Fatalf("status = %q, want %q", status, wantStatus)
+			}
+		})
+	}
+}
+
+func TestCommandDedupCleanup(t *testing.T) {
+	var calls int
+	s := newDedupCommand(t, &calls)
+	s.maxSkew = 2 * time.Hour
+	s.retention = time.Hour
+	now := gtime.Now()
+	insertProcessed(t, "interrupted", consts.CommandStatusRunning, "", now)
+	insertProcessed(t, "recent", consts.CommandStatusDone, "{}", now.Add(-90*time.Minute))
+	insertProcessed(t, "expired", consts.CommandStatusDone, "{}", now.Add(-3*time.Hour))
+
+	// 启动时清除中断的命令，云端重发时重新执行
+	s.startDedup(context.Background())
+	if status := processedStatus(t, "interrupted"); status != "" {
+		t.Fatalf("interrupted command status = %q, want cleared", status)
+	}
+	reply := s.Dispatch(context.Background(), gjson.MustEncode(g.Map{
+		"version": ProtocolVersion1, "cmdId": "interrupted", "method": "echo", "timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+	}))
+	if calls != 1 || reply == nil || reply.Code != gcode.CodeOK.Code() {
+		t.Fatalf("redelivered interrupted command: calls %d, reply %+v", calls, reply)
+	}
+
+	// 保留时间短于 maxSkew 时按 maxSkew 保留，仍可能被接受的命令不会被清理
+	s.cleanProcessed(context.Background())
+	if status := processedStatus(t, "recent"); status != consts.CommandStatusDone {
+		t.Fatalf("command within maxSkew was cleaned, status %q", status)
+	}
+	if status := processedStatus(t, "expired"); status != "" {
+		t.Fatalf("expired command status = %q, want cleaned", status)
+	}
+}
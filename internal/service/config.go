@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gvalid"
+
+	"demo/internal/model"
+)
+
+// ConfigEnvPrefix 覆盖配置的环境变量前缀，配置路径的各段转为大写下划线形式，
+// 如 mqtt.broker 对应 I800_MQTT_BROKER，mqtt.heartbeatInterval 对应 I800_MQTT_HEARTBEAT_INTERVAL。
+// 列表值用逗号分隔，对象和对象列表用 JSON
+const ConfigEnvPrefix = "I800_"
+
+// 输出配置时替换敏感值的掩码
+const configMask = "******"
+
+// 配置输出格式
+const (
+	ConfigFormatYaml = "yaml"
+	ConfigFormatJson = "json"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// 数据库连接中的密码，如 mysql:root:12345678@tcp(127.0.0.1:3306)/test
+var configLinkPassword = regexp.MustCompile(`^([a-z0-9]+):([^:@]*):([^@]+)@`)
+
+// sConfig 运行配置服务。启动时将配置文件、默认值和环境变量合并后写回 g.Cfg()，
+// 各服务仍可用 g.Cfg() 读取合并后的配置，model.Config 中的配置项在启动时校验
+type sConfig struct {
+	mu        sync.Mutex
+	config    *model.Config
+	overrides map[string]string // 配置路径 -> 覆盖它的环境变量
+}
+
+var (
+	configService *sConfig
+	configOnce    sync.Once
+)
+
+// Config 获取运行配置服务单例
+func Config() *sConfig {
+	configOnce.Do(func() {
+		configService = &sConfig{
+			overrides: make(map[string]string),
+		}
+		gvalid.RegisterRule("size", ruleSize)
+		gvalid.RegisterRule("duration-min", ruleDuration)
+		gvalid.RegisterRule("duration-gt", ruleDuration)
+	})
+	return configService
+}
+
+// Load 合并配置并校验，校验失败时返回所有错误。需在其他服务读取配置前调用
+func (s *sConfig) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	adapter, ok := g.Cfg().GetAdapter().(*gcfg.AdapterFile)
+	if !ok {
+		return gerror.NewCode(gcode.CodeInvalidConfiguration, "unsupported config adapter")
+	}
+	// 没有配置文件时全部使用默认值和环境变量
+	data := make(map[string]interface{})
+	if adapter.Available(ctx) {
+		fileData, err := adapter.Data(ctx)
+		if err != nil {
+			return gerror.WrapCode(gcode.CodeInvalidConfiguration, err, "load config file failed")
+		}
+		if fileData != nil {
+			data = fileData
+		}
+	} else {
+		g.Log().Warningf(ctx, "Config file %s not found, using defaults", adapter.GetFileName())
+	}
+	j := gjson.New(data, true)
+
+	// 默认值
+	configFields(reflect.TypeOf(model.Config{}), "", func(path string, field reflect.StructField) {
+		if value, ok := field.Tag.Lookup("d"); ok && j.Get(path).IsNil() {
+			_ = j.Set(path, configValue(value, field.Type))
+		}
+	})
+
+	// 环境变量，可覆盖 model.Config 和配置文件中已有的配置项
+	keys := make(map[string]reflect.Type)
+	configFields(reflect.TypeOf(model.Config{}), "", func(path string, field reflect.StructField) {
+		keys[path] = field.Type
+	})
+	configLeaves(j.Map(), "", func(path string) {
+		if _, ok := keys[path]; !ok {
+			keys[path] = nil
+		}
+	})
+	envNames := make(map[string]string, len(keys))
+	for path := range keys {
+		envNames[configEnvName(path)] = path
+	}
+	s.overrides = make(map[string]string)
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, ConfigEnvPrefix) {
+			continue
+		}
+		path, ok := envNames[name]
+		if !ok {
+			g.Log().Warningf(ctx, "Environment variable %s does not match any config key, ignored", name)
+			continue
+		}
+		if err := j.Set(path, configValue(value, keys[path])); err != nil {
+			return gerror.WrapCodef(gcode.CodeInvalidConfiguration, err, "apply %s failed", name)
+		}
+		s.overrides[path] = name
+	}
+
+	// 写回 g.Cfg()，之后读取的都是合并后的配置。SetContent 只在已有内容时清除缓存，需手动清除
+	adapter.SetContent(j.MustToJsonString(), adapter.GetFileName())
+	adapter.Clear()
+
+	config := &model.Config{}
+	if err := j.Scan(config); err != nil {
+		return gerror.WrapCode(gcode.CodeInvalidConfiguration, err, "invalid config")
+	}
+	s.config = config
+	return s.validate(ctx, j)
+}
+
+// Get 返回合并后的运行配置，未调用 Load 时先加载
+func (s *sConfig) Get() *model.Config {
+	s.mu.Lock()
+	config := s.config
+	s.mu.Unlock()
+	if config == nil {
+		ctx := gctx.GetInitCtx()
+		if err := s.Load(ctx); err != nil {
+			g.Log().Errorf(ctx, "%v", err)
+		}
+		s.mu.Lock()
+		config = s.config
+		s.mu.Unlock()
+	}
+	if config == nil {
+		config = &model.Config{}
+	}
+	return config
+}
+
+// Print 返回合并后的全部配置，密码、令牌等敏感值以掩码替换
+func (s *sConfig) Print(ctx context.Context, format string) (string, error) {
+	data, err := g.Cfg().Data(ctx)
+	if err != nil {
+		return "", err
+	}
+	j := gjson.New(maskConfig("", data))
+	switch format {
+	case ConfigFormatJson:
+		return j.MustToJsonIndentString(), nil
+	case ConfigFormatYaml, "":
+		var b strings.Builder
+		s.mu.Lock()
+		paths := make([]string, 0, len(s.overrides))
+		for path := range s.overrides {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(&b, "# %s overridden by %s\n", path, s.overrides[path])
+		}
+		s.mu.Unlock()
+		b.WriteString(j.MustToYamlString())
+		return b.String(), nil
+	default:
+		return "", gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported format: %s", format)
+	}
+}
+
+// validate 校验时长格式和 model.Config 的 v 标签规则，错误信息使用配置路径
+func (s *sConfig) validate(ctx context.Context, j *gjson.Json) error {
+	var errs []string
+	configFields(reflect.TypeOf(model.Config{}), "", func(path string, field reflect.StructField) {
+		if err := checkConfigValue(j.Get(path).Val(), field.Type); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+	})
+	// 逐项按 v 标签校验合并后的原始值，required-if 等规则引用同一节中的其他配置项
+	configFields(reflect.TypeOf(model.Config{}), "", func(path string, field reflect.StructField) {
+		rules := field.Tag.Get("v")
+		if rules == "" {
+			return
+		}
+		section := ""
+		if i := strings.LastIndex(path, "."); i > 0 {
+			section = path[:i]
+		}
+		err := g.Validator().Rules(rules).Data(j.Get(path).Val()).Assoc(j.Get(section).Map()).Run(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, err.FirstError()))
+		}
+	})
+	if len(errs) > 0 {
+		return gerror.NewCodef(gcode.CodeInvalidConfiguration, "invalid config:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// configFields 遍历配置结构体的叶子字段，path 为点分隔的 json 名称
+func configFields(t reflect.Type, prefix string, fn func(path string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := field.Tag.Get("json")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if field.Type.Kind() == reflect.Struct {
+			configFields(field.Type, path, fn)
+			continue
+		}
+		fn(path, field)
+	}
+}
+
+// configLeaves 遍历配置文件中的叶子配置项，列表视为叶子
+func configLeaves(data map[string]interface{}, prefix string, fn func(path string)) {
+	for key, value := range data {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if m, ok := value.(map[string]interface{}); ok {
+			configLeaves(m, path, fn)
+			continue
+		}
+		fn(path)
+	}
+}
+
+// configEnvName 配置路径对应的环境变量名
+func configEnvName(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		parts[i] = gstr.CaseSnakeScreaming(part)
+	}
+	return ConfigEnvPrefix + strings.Join(parts, "_")
+}
+
+// configValue 按配置项类型转换默认值和环境变量的值：JSON 对象和数组按 JSON 解析，
+// 字符串列表按逗号分隔，数值和布尔值无法解析时保留原值，由 validate 报告
+func configValue(value string, t reflect.Type) interface{} {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		if decoded, err := gjson.Decode(trimmed); err == nil {
+			return decoded
+		}
+	}
+	if t == nil || t == durationType {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			items := make([]string, 0)
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(trimmed); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(trimmed, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// checkConfigValue 检查配置项的原始值能否转换为字段类型，转换时无法解析的值会被忽略
+func checkConfigValue(value interface{}, t reflect.Type) error {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return nil
+	}
+	var err error
+	switch {
+	case t == durationType:
+		if _, err = gtime.ParseDuration(s); err != nil {
+			return gerror.Newf("invalid duration %q, e.g. 30s", s)
+		}
+		return nil
+	case t.Kind() == reflect.Bool:
+		_, err = strconv.ParseBool(s)
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		_, err = strconv.ParseInt(s, 10, 64)
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		_, err = strconv.ParseUint(s, 10, 64)
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		_, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil {
+		return gerror.Newf("invalid %s %q", t.Kind(), s)
+	}
+	return nil
+}
+
+// maskConfig 替换敏感配置项的值：名称含 password、secret、token 的配置项，
+// Authorization 请求头，以及地址和数据库连接中的密码
+func maskConfig(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskConfig(k, item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskConfig(key, item)
+		}
+		return masked
+	case json.Number:
+		// 配置文件中的数值以 json.Number 保存，转换后输出为数值
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case string:
+		if v != "" && configSensitive(key) {
+			return configMask
+		}
+		if u, err := url.Parse(v); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				return strings.Replace(v, u.User.String()+"@", url.User(u.User.Username()).String()+":"+configMask+"@", 1)
+			}
+		}
+		if m := configLinkPassword.FindStringSubmatch(v); m != nil {
+			return m[1] + ":" + m[2] + ":" + configMask + "@" + v[len(m[0]):]
+		}
+		return v
+	default:
+		if value != nil && configSensitive(key) {
+			return configMask
+		}
+		return value
+	}
+}
+
+// configSensitive 配置项名称是否表示敏感值
+func configSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "secret", "token"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return key == "authorization"
+}
+
+// ruleSize 校验 100MB 等大小写法
+func ruleSize(ctx context.Context, in gvalid.RuleFuncInput) error {
+	if value := in.Value.String(); value != "" && gfile.StrToSize(value) < 0 {
+		return gerror.Newf("invalid size %q, e.g. 100MB", value)
+	}
+	return nil
+}
+
+// ruleDuration 校验时长下限。duration-min:1s 要求不小于 1s，参数也可以是同一节中的配置项，
+// 如 duration-min:backoffMin；duration-gt:0 要求大于 0。写法错误的时长由 checkConfigValue 报告
+func ruleDuration(ctx context.Context, in gvalid.RuleFuncInput) error {
+	value, err := gtime.ParseDuration(in.Value.String())
+	if err != nil {
+		return nil
+	}
+	name, param, _ := strings.Cut(in.Rule, ":")
+	bound, err := gtime.ParseDuration(param)
+	if err != nil {
+		// 参数为配置项时与该项的值比较
+		if bound, err = gtime.ParseDuration(in.Data.MapStrVar()[param].String()); err != nil {
+			return nil
+		}
+	}
+	switch {
+	case name == "duration-gt" && value <= bound:
+		return gerror.Newf("must be greater than %s", param)
+	case name == "duration-min" && value < bound:
+		return gerror.Newf("must not be less than %s", param)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+)
+
+func TestConfigDurationBounds(t *testing.T) {
+	defer loadTestConfig(t, g.Map{})
+	adapter, ok := g.Cfg().GetAdapter().(*gcfg.AdapterFile)
+	if !ok {
+		t.Fatal("unsupported config adapter")
+	}
+	tests := []struct {
+		name    string
+		content g.Map
+		wantErr string // 为空时应通过校验
+	}{
+		{name: "defaults"},
+		{name: "heartbeat at minimum", content: g.Map{"mqtt": g.Map{"heartbeatInterval": "1s"}}},
+		{name: "heartbeat too short", content: g.Map{"mqtt": g.Map{"heartbeatInterval": "500ms"}}, wantErr: "mqtt.heartbeatInterval"},
+		{name: "mqtt timeout zero", content: g.Map{"mqtt": g.Map{"timeout": "0s"}}, wantErr: "mqtt.timeout"},
+		{name: "backoffMin too short", content: g.Map{"runtime": g.Map{"backoffMin": "100ms"}}, wantErr: "runtime.backoffMin"},
+		{name: "backoffMax equals backoffMin", content: g.Map{"runtime": g.Map{"backoffMin": "5s", "backoffMax": "5s"}}},
+		{name: "backoffMax below backoffMin", content: g.Map{"runtime": g.Map{"backoffMin": "10s", "backoffMax": "5s"}}, wantErr: "runtime.backoffMax"},
+		{name: "backoffMax below default backoffMin", content: g.Map{"runtime": g.Map{"backoffMax": "500ms"}}, wantErr: "runtime.backoffMax"},
+		{name: "stopTimeout zero", content: g.Map{"runtime": g.Map{"stopTimeout": "0s"}}, wantErr: "runtime.stopTimeout"},
+		{name: "stopTimeout negative", content: g.Map{"runtime": g.Map{"stopTimeout": "-1s"}}, wantErr: "runtime.stopTimeout"},
+		{name: "healthTimeout zero", content: g.Map{"upgrade": g.Map{"healthTimeout": "0s"}}, wantErr: "upgrade.healthTimeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter.SetContent(gjson.MustEncodeString(tt.content), adapter.GetFileName())
+			adapter.Clear()
+			err := Config().Load(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("load config: %v", err)
+				}
+				return
+			}
+			if err == nil || gerror.Code(err) != gcode.CodeInvalidConfiguration || !strings.Contains(err.Error(), tt.wantErr+":") {
+				t.Fatalf("load config error = %v, want %s rejected", err, tt.wantErr)
+			}
+		})
+	}
+}
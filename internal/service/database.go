@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"demo/internal/consts"
+)
+
+const (
+	// 初始化SQL文件
+	databaseInitFile = "data/init.sql"
+	// 增量迁移SQL目录，文件按名称顺序执行，如 001_xxx.sql
+	databaseMigrationDir = "data/migrations"
+)
+
+// sDatabase 数据库初始化与迁移服务
+type sDatabase struct{}
+
+var (
+	databaseService *sDatabase
+	databaseOnce    sync.Once
+)
+
+// Database 获取数据库服务单例
+func Database() *sDatabase {
+	databaseOnce.Do(func() {
+		databaseService = &sDatabase{}
+	})
+	return databaseService
+}
+
+// Init 执行初始化SQL并应用未执行的迁移
+func (s *sDatabase) Init(ctx context.Context) error {
+	if !gfile.Exists(databaseInitFile) {
+		logger(consts.LoggerDatabase).Warning(ctx, "SQL init file not found:", databaseInitFile)
+		return nil
+	}
+	sqlContent := gfile.GetContents(databaseInitFile)
+	if sqlContent == "" {
+		logger(consts.LoggerDatabase).Warning(ctx, "SQL init file is empty:", databaseInitFile)
+		return nil
+	}
+	if _, err := g.DB().Exec(ctx, sqlContent); err != nil {
+		return gerror.Wrap(err, "execute init sql failed")
+	}
+	return s.Migrate(ctx)
+}
+
+// Migrate 依次执行尚未应用的迁移文件，已执行的迁移记录在 schema_migrations 表中
+func (s *sDatabase) Migrate(ctx context.Context) error {
+	db := g.DB()
+	if _, err := db.Exec(ctx, "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` TEXT PRIMARY KEY, `applied_at` DATETIME DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		return gerror.Wrap(err, "create schema_migrations failed")
+	}
+	applied, err := s.AppliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, version := range s.Migrations() {
+		if applied[version] {
+			continue
+		}
+		content := gfile.GetContents(filepath.Join(databaseMigrationDir, version))
+		err = db.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+			if _, err := tx.Exec(content); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO `schema_migrations` (`version`) VALUES (?)", version)
+			return err
+		})
+		if err != nil {
+			return gerror.Wrapf(err, "apply migration %s failed", version)
+		}
+		logger(consts.LoggerDatabase).Infof(ctx, "Applied migration %s", version)
+	}
+	return nil
+}
+
+// Migrations 返回迁移目录中的全部迁移文件名，按执行顺序排列
+func (s *sDatabase) Migrations() []string {
+	files, _ := gfile.ScanDirFile(databaseMigrationDir, "*.sql")
+	versions := make([]string, 0, len(files))
+	for _, file := range files {
+		versions = append(versions, gfile.Basename(file))
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// AppliedMigrations 返回已执行的迁移集合
+func (s *sDatabase) AppliedMigrations(ctx context.Context) (map[string]bool, error) {
+	rows, err := g.DB().GetAll(ctx, "SELECT `version` FROM `schema_migrations`")
+	if err != nil {
+		return nil, gerror.Wrap(err, "query schema_migrations failed")
+	}
+	applied := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		applied[row["version"].String()] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// RegistrationPayload 设备注册消息内容，云端据此选择下发命令使用的协议版本
+type RegistrationPayload struct {
+	DeviceId         string   `json:"deviceId"`
+	Version          string   `json:"version"`
+	Timestamp        int64    `json:"timestamp"`
+	Transport        string   `json:"transport"`
+	ProtocolVersions []string `json:"protocolVersions"` // 支持的命令协议版本，按主版本升序
+}
+
+// sDevice 设备身份信息
+type sDevice struct {
+	id string
+}
+
+var (
+	deviceService *sDevice
+	deviceOnce    sync.Once
+)
+
+// Device 获取设备服务单例
+func Device() *sDevice {
+	deviceOnce.Do(func() {
+		id := Config().Get().Device.Id
+		if id == "" {
+			// 未配置设备ID时使用主机名
+			id, _ = os.Hostname()
+		}
+		deviceService = &sDevice{id: id}
+	})
+	return deviceService
+}
+
+// Id 返回设备ID
+func (s *sDevice) Id() string {
+	return s.id
+}
+
+// Registration 生成注册消息内容
+func (s *sDevice) Registration() RegistrationPayload {
+	return RegistrationPayload{
+		DeviceId:         s.id,
+		Version:          consts.Version,
+		Timestamp:        gtime.Timestamp(),
+		Transport:        Transport().Name(),
+		ProtocolVersions: Codec().Versions(),
+	}
+}
+
+// Identity 返回设备身份和连接信息，口令打码
+func (s *sDevice) Identity() *model.DeviceIdentity {
+	config := Config().Get()
+	hostname, _ := os.Hostname()
+	clientId := config.Mqtt.ClientId
+	if clientId == "" {
+		clientId = s.id
+	}
+	return &model.DeviceIdentity{
+		DeviceId:         s.id,
+		Version:          consts.Version,
+		Hostname:         hostname,
+		Transport:        Transport().Name(),
+		MqttBroker:       gconv.String(maskConfig("broker", config.Mqtt.Broker)),
+		MqttClientId:     clientId,
+		MqttVersion:      config.Mqtt.Version,
+		CommandTopic:     fmt.Sprintf(consts.TopicCommand, s.id),
+		ProtocolVersions: Codec().Versions(),
+		ServerAddress:    config.Server.Address,
+		Database:         gconv.String(maskConfig("link", config.Database.Default.Link)),
+		Upgrade:          Upgrade().Status(),
+	}
+}
+
+// Register 通过命令通道上报注册消息，失败时按心跳间隔重试直到成功
+func (s *sDevice) Register(ctx context.Context) {
+	register := func(ctx context.Context) bool {
+		payload, err := gjson.Encode(s.Registration())
+		if err == nil {
+			err = Transport().SendRegistration(ctx, payload)
+		}
+		if err != nil {
+			logger(consts.LoggerCommand).Warningf(ctx, "Register device failed: %v", err)
+			return false
+		}
+		logger(consts.LoggerCommand).Infof(ctx, "Registered device %s, protocol versions %v", s.id, Codec().Versions())
+		return true
+	}
+	if register(ctx) {
+		return
+	}
+	gtimer.AddSingleton(ctx, Heartbeat().interval, func(ctx context.Context) {
+		if register(ctx) {
+			gtimer.Exit()
+		}
+	})
+}
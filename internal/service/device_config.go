@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/do"
+	"demo/internal/model/entity"
+)
+
+// deviceConfigCheckInterval 回滚时间窗内检查连接的间隔
+const deviceConfigCheckInterval = time.Second
+
+// deviceConfigMinHeartbeat 允许下发的最小心跳间隔
+const deviceConfigMinHeartbeat = time.Second
+
+// sDeviceConfig 云端下发的设备配置服务。每次下发保存为新版本并立即应用，
+// 在回滚时间窗内与云端的连接断开、或时间窗结束时仍未连接，则回滚到上一个已确认的版本，
+// 避免错误的配置使设备失联。当前应用的版本随心跳上报
+type sDeviceConfig struct {
+	mu         sync.Mutex
+	window     time.Duration                 // 默认回滚时间窗，deviceConfig.rollbackWindow
+	heartbeat  time.Duration                 // 配置文件中的心跳间隔
+	levels     map[string]string             // 被下发配置覆盖的子系统在下发前的日志级别
+	applied    *model.DeviceSettingsRevision // 当前应用的版本，nil 为只使用配置文件
+	rollbackAt time.Time                     // 当前版本待确认时的回滚时间窗结束时间
+	stop       chan struct{}                 // 停止当前版本的连接检查
+}
+
+var (
+	deviceConfigService *sDeviceConfig
+	deviceConfigOnce    sync.Once
+)
+
+// DeviceConfig 获取设备配置服务单例
+func DeviceConfig() *sDeviceConfig {
+	deviceConfigOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		deviceConfigService = &sDeviceConfig{
+			window:    g.Cfg().MustGet(ctx, "deviceConfig.rollbackWindow", "5m").Duration(),
+			heartbeat: Config().Get().Mqtt.HeartbeatInterval,
+			levels:    make(map[string]string),
+		}
+	})
+	return deviceConfigService
+}
+
+// Start 应用最近一个未回滚的版本，待确认的版本重新开始回滚时间窗。
+// 需在日志级别设置之后、连接云端之前调用
+func (s *sDeviceConfig) Start(ctx context.Context) {
+	var (
+		record  *entity.DeviceConfig
+		columns = dao.DeviceConfig.Columns()
+	)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := dao.DeviceConfig.Ctx(ctx).
+		WhereIn(columns.Status, g.Slice{consts.DeviceConfigPending, consts.DeviceConfigConfirmed}).
+		OrderDesc(columns.Revision).
+		Limit(1).
+		Scan(&record)
+	if err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Query device config failed: %v", err)
+		return
+	}
+	if record == nil {
+		return
+	}
+	revision, err := s.revision(record)
+	if err == nil {
+		err = s.apply(ctx, revision.Settings)
+	}
+	if err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Apply device config revision %d failed: %v", record.Revision, err)
+		return
+	}
+	s.applied = revision
+	logger(consts.LoggerApp).Infof(ctx, "Device config revision %d applied", revision.Revision)
+	if revision.Status == consts.DeviceConfigPending {
+		s.watch(ctx, revision.Revision, s.window)
+	}
+}
+
+// Applied 返回当前应用的版本号和状态，没有下发的配置时返回 0
+func (s *sDeviceConfig) Applied() (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == nil {
+		return 0, ""
+	}
+	return s.applied.Revision, s.applied.Status
+}
+
+// Status 返回当前应用的版本和生效的设置
+func (s *sDeviceConfig) Status(ctx context.Context) *model.DeviceSettingsStatus {
+	s.mu.Lock()
+	status := &model.DeviceSettingsStatus{}
+	if s.applied != nil {
+		status.Revision = s.applied.Revision
+		status.Status = s.applied.Status
+		status.Settings = s.applied.Settings
+		if !s.rollbackAt.IsZero() {
+			status.RollbackAt = gtime.New(s.rollbackAt)
+		}
+	}
+	s.mu.Unlock()
+	status.HeartbeatInterval = Heartbeat().Interval().String()
+	status.LogLevels = Logging().Levels()
+	status.Download = Throttle().Status(ctx)
+	return status
+}
+
+// Get 查询指定版本，不存在时返回 nil
+func (s *sDeviceConfig) Get(ctx context.Context, revision int) (*model.DeviceSettingsRevision, error) {
+	var record *entity.DeviceConfig
+	err := dao.DeviceConfig.Ctx(ctx).Where(dao.DeviceConfig.Columns().Revision, revision).Scan(&record)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return s.revision(record)
+}
+
+// Set 校验并保存新版本，替换当前版本的全部设置后立即应用。
+// window 内失去连接则自动回滚，0 使用 deviceConfig.rollbackWindow
+func (s *sDeviceConfig) Set(ctx context.Context, settings model.DeviceSettings, window time.Duration) (*model.DeviceSettingsStatus, error) {
+	if err := s.validate(&settings); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		window = s.window
+	}
+	content, err := json.Marshal(settings)
+	if err != nil {
+		return nil, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid device config")
+	}
+
+	s.mu.Lock()
+	revision, err := s.save(ctx, content)
+	if err == nil {
+		s.stopWatch()
+		s.applied = &model.DeviceSettingsRevision{
+			Revision:  revision,
+			Status:    consts.DeviceConfigPending,
+			Settings:  settings,
+			CreatedAt: gtime.Now(),
+			UpdatedAt: gtime.Now(),
+		}
+		if err = s.apply(ctx, settings); err == nil {
+			s.watch(ctx, revision, window)
+		}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		if revision > 0 {
+			s.rollback(ctx, revision, err.Error())
+		}
+		return nil, err
+	}
+	logger(consts.LoggerApp).Infof(ctx, "Device config revision %d applied, rollback window %s", revision, window)
+	return s.Status(ctx), nil
+}
+
+// save 保存为新版本，尚未确认的版本标记为被替换，调用方持有锁
+func (s *sDeviceConfig) save(ctx context.Context, content []byte) (revision int, err error) {
+	columns := dao.DeviceConfig.Columns()
+	err = dao.DeviceConfig.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		latest, err := tx.Model(dao.DeviceConfig.Table()).Ctx(ctx).Max(columns.Revision)
+		if err != nil {
+			return err
+		}
+		revision = int(latest) + 1
+		_, err = tx.Model(dao.DeviceConfig.Table()).Ctx(ctx).
+			Where(columns.Status, consts.DeviceConfigPending).
+			Data(do.DeviceConfig{Status: consts.DeviceConfigSuperseded, UpdatedAt: gtime.Now()}).
+			Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(dao.DeviceConfig.Table()).Ctx(ctx).Data(do.DeviceConfig{
+			Revision: revision,
+			Content:  string(content),
+			Status:   consts.DeviceConfigPending,
+		}).Insert()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+// watch 在回滚时间窗内检查与云端的连接：连接过后又断开，或时间窗结束时仍未连接则回滚，
+// 否则在时间窗结束时确认。未启用云端通道时到期直接确认。调用方持有锁
+func (s *sDeviceConfig) watch(ctx context.Context, revision int, window time.Duration) {
+	stop := make(chan struct{})
+	s.stop = stop
+	s.rollbackAt = time.Now().Add(window)
+	deadline := s.rollbackAt
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(deviceConfigCheckInterval)
+		defer ticker.Stop()
+		online := false
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				connected := Transport().IsConnected()
+				switch {
+				case online && !connected:
+					s.rollback(ctx, revision, "connection lost within rollback window")
+					return
+				case !now.Before(deadline) && !connected && Transport().Enabled():
+					s.rollback(ctx, revision, "not connected at the end of rollback window")
+					return
+				case !now.Before(deadline):
+					s.confirm(ctx, revision)
+					return
+				}
+				online = online || connected
+			}
+		}
+	}()
+}
+
+// stopWatch 停止当前版本的连接检查，调用方持有锁
+func (s *sDeviceConfig) stopWatch() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.rollbackAt = time.Time{}
+}
+
+// confirm 确认版本，之后失去连接不再回滚
+func (s *sDeviceConfig) confirm(ctx context.Context, revision int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == nil || s.applied.Revision != revision {
+		return
+	}
+	if err := s.setStatus(ctx, revision, consts.DeviceConfigConfirmed, ""); err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Confirm device config revision %d failed: %v", revision, err)
+		return
+	}
+	s.applied.Status = consts.DeviceConfigConfirmed
+	s.applied.UpdatedAt = gtime.Now()
+	s.stop = nil
+	s.rollbackAt = time.Time{}
+	logger(consts.LoggerApp).Infof(ctx, "Device config revision %d confirmed", revision)
+}
+
+// rollback 回滚版本，恢复上一个已确认的版本，没有时恢复配置文件的设置
+func (s *sDeviceConfig) rollback(ctx context.Context, revision int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == nil || s.applied.Revision != revision {
+		return
+	}
+	s.stop = nil
+	s.rollbackAt = time.Time{}
+	if err := s.setStatus(ctx, revision, consts.DeviceConfigReverted, reason); err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Revert device config revision %d failed: %v", revision, err)
+	}
+
+	var (
+		record  *entity.DeviceConfig
+		target  *model.DeviceSettingsRevision
+		columns = dao.DeviceConfig.Columns()
+	)
+	err := dao.DeviceConfig.Ctx(ctx).
+		Where(columns.Status, consts.DeviceConfigConfirmed).
+		WhereLT(columns.Revision, revision).
+		OrderDesc(columns.Revision).
+		Limit(1).
+		Scan(&record)
+	if err == nil && record != nil {
+		target, err = s.revision(record)
+	}
+	if err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Query previous device config failed, restoring config file settings: %v", err)
+		target = nil
+	}
+	settings := model.DeviceSettings{}
+	if target != nil {
+		settings = target.Settings
+	}
+	if err = s.apply(ctx, settings); err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Restore device config failed: %v", err)
+	}
+	s.applied = target
+	previous := 0
+	if target != nil {
+		previous = target.Revision
+	}
+	logger(consts.LoggerApp).Warningf(ctx, "Device config revision %d reverted to revision %d: %s", revision, previous, reason)
+}
+
+// setStatus 更新版本状态
+func (s *sDeviceConfig) setStatus(ctx context.Context, revision int, status, reason string) error {
+	_, err := dao.DeviceConfig.Ctx(ctx).
+		Where(dao.DeviceConfig.Columns().Revision, revision).
+		Data(do.DeviceConfig{Status: status, Reason: reason, UpdatedAt: gtime.Now()}).
+		Update()
+	return err
+}
+
+// apply 应用设置，未设置的心跳间隔恢复配置文件的设置。日志级别只修改上一版本或本版本设置的子系统，
+// 本版本不再设置的恢复为下发前的级别。调用方持有锁
+func (s *sDeviceConfig) apply(ctx context.Context, settings model.DeviceSettings) error {
+	interval := s.heartbeat
+	if settings.HeartbeatInterval != "" {
+		interval, _ = time.ParseDuration(settings.HeartbeatInterval)
+	}
+	Heartbeat().SetInterval(ctx, interval)
+
+	for subsystem, level := range s.levels {
+		if _, ok := settings.LogLevels[subsystem]; ok {
+			continue
+		}
+		if err := Logging().SetLevel(subsystem, level); err != nil {
+			return err
+		}
+		delete(s.levels, subsystem)
+	}
+	for subsystem, level := range settings.LogLevels {
+		if _, ok := s.levels[subsystem]; !ok {
+			s.levels[subsystem] = Logging().Level(subsystem)
+		}
+		if err := Logging().SetLevel(subsystem, level); err != nil {
+			return err
+		}
+	}
+
+	// 未下发限速时只撤销之前下发的限速，保留通过接口调整的设置
+	if settings.Download != nil {
+		return Throttle().set(ctx, *settings.Download, ThrottleSourceRemote)
+	}
+	if Throttle().Source() == ThrottleSourceRemote {
+		Throttle().Reset(ctx)
+	}
+	return nil
+}
+
+// validate 校验设置，补全限速时间窗的默认时区
+func (s *sDeviceConfig) validate(settings *model.DeviceSettings) error {
+	if settings.HeartbeatInterval != "" {
+		interval, err := time.ParseDuration(settings.HeartbeatInterval)
+		if err != nil || interval < deviceConfigMinHeartbeat {
+			return gerror.NewCodef(gcode.CodeInvalidParameter, "invalid heartbeatInterval: %s", settings.HeartbeatInterval)
+		}
+	}
+	for subsystem, level := range settings.LogLevels {
+		if err := Logging().CheckLevel(subsystem, level); err != nil {
+			return err
+		}
+	}
+	if settings.Download != nil {
+		return normalizeThrottle(settings.Download)
+	}
+	return nil
+}
+
+// revision 将数据库记录转为版本
+func (s *sDeviceConfig) revision(record *entity.DeviceConfig) (*model.DeviceSettingsRevision, error) {
+	revision := &model.DeviceSettingsRevision{
+		Revision:  record.Revision,
+		Status:    record.Status,
+		Reason:    record.Reason,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(record.Content), &revision.Settings); err != nil {
+		return nil, gerror.Wrapf(err, "invalid device config revision %d", record.Revision)
+	}
+	return revision, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+func TestDeviceConfigApplyLogLevels(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	ctx := context.Background()
+	subsystems := []string{consts.LoggerMqtt, consts.LoggerDownload, consts.LoggerStorage}
+	for _, subsystem := range subsystems {
+		level := Logging().Level(subsystem)
+		defer func(subsystem string) { _ = Logging().SetLevel(subsystem, level) }(subsystem)
+		if err := Logging().SetLevel(subsystem, "info"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(step string, want map[string]string) {
+		t.Helper()
+		for subsystem, level := range want {
+			if got := Logging().Level(subsystem); got != level {
+				t.Fatalf("%s: %s level = %s, want %s", step, subsystem, got, level)
+			}
+		}
+	}
+
+	s := &sDeviceConfig{heartbeat: Config().Get().Mqtt.HeartbeatInterval, levels: make(map[string]string)}
+	if err := s.apply(ctx, model.DeviceSettings{LogLevels: map[string]string{consts.LoggerMqtt: "debug"}}); err != nil {
+		t.Fatal(err)
+	}
+	// 下发后通过接口调整的子系统不受后续版本影响
+	if err := Logging().SetLevel(consts.LoggerStorage, "error"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.apply(ctx, model.DeviceSettings{LogLevels: map[string]string{consts.LoggerDownload: "warning"}}); err != nil {
+		t.Fatal(err)
+	}
+	check("second revision", map[string]string{
+		consts.LoggerMqtt:     "info",
+		consts.LoggerDownload: "warning",
+		consts.LoggerStorage:  "error",
+	})
+	// 回滚到配置文件的设置只恢复下发过的子系统
+	if err := s.apply(ctx, model.DeviceSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	check("reverted", map[string]string{
+		consts.LoggerMqtt:     "info",
+		consts.LoggerDownload: "info",
+		consts.LoggerStorage:  "error",
+	})
+	if len(s.levels) != 0 {
+		t.Fatalf("pre-push levels %v not cleared", s.levels)
+	}
+}
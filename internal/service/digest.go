@@ -0,0 +1,168 @@
+package service
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// 支持的摘要算法，按强度从弱到强排列
+const (
+	DigestMd5    = "md5"
+	DigestSha1   = "sha1"
+	DigestSha256 = "sha256"
+	DigestSha512 = "sha512"
+)
+
+// digestStrength 摘要算法强度，数值越大越强
+var digestStrength = map[string]int{
+	DigestMd5:    1,
+	DigestSha1:   2,
+	DigestSha256: 3,
+	DigestSha512: 4,
+}
+
+// digestHexLen 各摘要算法的十六进制长度
+var digestHexLen = map[string]int{
+	DigestMd5:    32,
+	DigestSha1:   40,
+	DigestSha256: 64,
+	DigestSha512: 128,
+}
+
+// Digest 文件摘要，Algo 为算法名，Hex 为小写十六进制值
+type Digest struct {
+	Algo string `json:"algo"`
+	Hex  string `json:"hex"`
+}
+
+// String 返回 algo:hex 形式
+func (d Digest) String() string {
+	return d.Algo + ":" + d.Hex
+}
+
+// IsEmpty 判断摘要是否为空
+func (d Digest) IsEmpty() bool {
+	return d.Algo == "" || d.Hex == ""
+}
+
+// NewDigest 根据算法名和十六进制值创建摘要，并校验格式
+func NewDigest(algo, hexValue string) (Digest, error) {
+	algo = strings.ToLower(strings.TrimSpace(algo))
+	hexValue = strings.ToLower(strings.TrimSpace(hexValue))
+	size, ok := digestHexLen[algo]
+	if !ok {
+		return Digest{}, gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported digest algorithm: %s", algo)
+	}
+	if len(hexValue) != size {
+		return Digest{}, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid %s digest length: %d", algo, len(hexValue))
+	}
+	if _, err := hex.DecodeString(hexValue); err != nil {
+		return Digest{}, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid %s digest: %s", algo, hexValue)
+	}
+	return Digest{Algo: algo, Hex: hexValue}, nil
+}
+
+// ParseDigest 解析 algo:hex 形式的摘要
+func ParseDigest(s string) (Digest, error) {
+	algo, hexValue, ok := strings.Cut(s, ":")
+	if !ok {
+		return Digest{}, gerror.NewCodef(gcode.CodeInvalidParameter, "digest must be in the form algo:hex: %s", s)
+	}
+	return NewDigest(algo, hexValue)
+}
+
+// StrongestDigest 从下发的 md5、sha256 和通用 digest 字段中选出最强的摘要。
+// 旧版云端只下发 md5，此时直接返回 md5 摘要；同一算法给出不同的值时拒绝。
+func StrongestDigest(md5Hex, sha256Hex, digest string) (Digest, error) {
+	var candidates []Digest
+	if md5Hex != "" {
+		d, err := NewDigest(DigestMd5, md5Hex)
+		if err != nil {
+			return Digest{}, err
+		}
+		candidates = append(candidates, d)
+	}
+	if sha256Hex != "" {
+		d, err := NewDigest(DigestSha256, sha256Hex)
+		if err != nil {
+			return Digest{}, err
+		}
+		candidates = append(candidates, d)
+	}
+	if digest != "" {
+		d, err := ParseDigest(digest)
+		if err != nil {
+			return Digest{}, err
+		}
+		candidates = append(candidates, d)
+	}
+	if len(candidates) == 0 {
+		return Digest{}, gerror.NewCode(gcode.CodeInvalidParameter, "no digest provided")
+	}
+	strongest := candidates[0]
+	for i, d := range candidates {
+		for _, other := range candidates[:i] {
+			if other.Algo == d.Algo && other.Hex != d.Hex {
+				return Digest{}, gerror.NewCodef(gcode.CodeInvalidParameter, "conflicting %s digests: %s, %s", d.Algo, other.Hex, d.Hex)
+			}
+		}
+		if digestStrength[d.Algo] > digestStrength[strongest.Algo] {
+			strongest = d
+		}
+	}
+	return strongest, nil
+}
+
+// NewDigestHash 返回指定算法的 hash 实例
+func NewDigestHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case DigestMd5:
+		return md5.New(), nil
+	case DigestSha1:
+		return sha1.New(), nil
+	case DigestSha256:
+		return sha256.New(), nil
+	case DigestSha512:
+		return sha512.New(), nil
+	}
+	return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "unsupported digest algorithm: %s", algo)
+}
+
+// FileDigest 计算文件的指定算法摘要
+func FileDigest(path, algo string) (Digest, error) {
+	h, err := NewDigestHash(algo)
+	if err != nil {
+		return Digest{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Digest{}, gerror.Wrapf(err, "open %s failed", path)
+	}
+	defer f.Close()
+	if _, err = io.Copy(h, f); err != nil {
+		return Digest{}, gerror.Wrapf(err, "read %s failed", path)
+	}
+	return Digest{Algo: algo, Hex: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// VerifyFile 校验文件摘要是否与期望值一致
+func VerifyFile(path string, expected Digest) error {
+	actual, err := FileDigest(path, expected.Algo)
+	if err != nil {
+		return err
+	}
+	if actual.Hex != expected.Hex {
+		return gerror.NewCodef(gcode.CodeValidationFailed, "%s mismatch: expected %s, got %s", expected.Algo, expected.Hex, actual.Hex)
+	}
+	return nil
+}
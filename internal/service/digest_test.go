@@ -0,0 +1,73 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// "hello" 的摘要
+const (
+	helloMd5    = "5d41402abc4b2a76b9719d911017c592"
+	helloSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func TestStrongestDigest(t *testing.T) {
+	tests := []struct {
+		name                string
+		md5, sha256, digest string
+		want                string // 期望的 algo:hex，为空时期望参数错误
+	}{
+		{name: "md5 only from legacy sender", md5: helloMd5, want: "md5:" + helloMd5},
+		{name: "sha256 field", sha256: helloSha256, want: "sha256:" + helloSha256},
+		{name: "sha256 prefix", digest: "sha256:" + helloSha256, want: "sha256:" + helloSha256},
+		{name: "strongest wins", md5: helloMd5, digest: "sha256:" + helloSha256, want: "sha256:" + helloSha256},
+		{name: "upper-case hex", md5: strings.ToUpper(helloMd5), digest: "SHA256:" + strings.ToUpper(helloSha256), want: "sha256:" + helloSha256},
+		{name: "same value twice", sha256: helloSha256, digest: "sha256:" + helloSha256, want: "sha256:" + helloSha256},
+		{name: "conflicting md5 and digest", md5: helloMd5, digest: "md5:" + strings.Repeat("0", 32)},
+		{name: "conflicting sha256 and digest", sha256: helloSha256, digest: "sha256:" + strings.Repeat("0", 64)},
+		{name: "unknown algorithm", digest: "crc32:3610a686"},
+		{name: "missing prefix", digest: helloSha256},
+		{name: "wrong length", md5: helloMd5[:30]},
+		{name: "not hex", sha256: strings.Repeat("z", 64)},
+		{name: "nothing provided"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := StrongestDigest(tt.md5, tt.sha256, tt.digest)
+			if tt.want == "" {
+				if gerror.Code(err) != gcode.CodeInvalidParameter {
+					t.Fatalf("got %v, %v, want an invalid parameter error", d, err)
+				}
+				return
+			}
+			if err != nil || d.String() != tt.want {
+				t.Fatalf("got %v, %v, want %s", d, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.zip")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, digest := range []string{"md5:" + helloMd5, "sha256:" + strings.ToUpper(helloSha256)} {
+		expected, err := ParseDigest(digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = VerifyFile(path, expected); err != nil {
+			t.Fatalf("verify %s: %v", digest, err)
+		}
+	}
+	expected, _ := NewDigest(DigestSha256, strings.Repeat("0", 64))
+	if err := VerifyFile(path, expected); gerror.Code(err) != gcode.CodeValidationFailed {
+		t.Fatalf("verify mismatch: %v", err)
+	}
+}
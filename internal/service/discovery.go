@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/ipv4"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// DNS-SD 服务类型，实例名为 <deviceId>._i800._tcp.local.
+const (
+	discoveryServiceType = "_i800._tcp.local."
+	discoveryDomain      = "local."
+	discoveryGroup       = "224.0.0.251:5353"
+	// mDNS 记录的 class 最高位为 cache-flush 标志，表示该记录替换接收方缓存中的同名记录
+	discoveryCacheFlush = dnsmessage.Class(0x8000)
+)
+
+// DiscoveryOptions mDNS 发现参数
+type DiscoveryOptions struct {
+	DeviceId  string        // 本机设备ID
+	Version   string        // 本机应用版本
+	Port      int           // 本机 HTTP 端口
+	Host      string        // 宣告的 IPv4 地址，为空时使用网卡地址
+	Mirror    bool          // 是否提供算法包镜像
+	Group     string        // 组播地址，默认 224.0.0.251:5353
+	Interface string        // 组播网卡名，为空时使用系统默认网卡
+	Interval  time.Duration // 宣告和查询间隔
+	Ttl       time.Duration // 记录有效期，超过有效期没有再次宣告的设备从列表移除
+}
+
+// sDiscovery 局域网设备发现服务，通过 mDNS/DNS-SD 宣告本机的设备ID、版本和 HTTP 端口，
+// 同时查询并缓存同一局域网内的其他设备。组播回环开启，同一主机或同一进程中的多个实例可以互相发现
+type sDiscovery struct {
+	opts   DiscoveryOptions
+	group  *net.UDPAddr
+	conn   *net.UDPConn
+	pc     *ipv4.PacketConn
+	mu     sync.RWMutex
+	peers  map[string]*model.Peer // deviceId -> 设备
+	stopCh chan struct{}
+}
+
+var (
+	discoveryService *sDiscovery
+	discoveryOnce    sync.Once
+)
+
+// Discovery 获取设备发现服务单例，按 discovery 配置创建，HTTP 端口默认取自 server.address
+func Discovery() *sDiscovery {
+	discoveryOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		cfg := g.Cfg()
+		port := cfg.MustGet(ctx, "discovery.port").Int()
+		if port == 0 {
+			_, p, _ := net.SplitHostPort(Config().Get().Server.Address)
+			port, _ = strconv.Atoi(p)
+		}
+		discoveryService = NewDiscovery(DiscoveryOptions{
+			DeviceId:  Device().Id(),
+			Version:   consts.Version,
+			Port:      port,
+			Host:      cfg.MustGet(ctx, "discovery.host").String(),
+			Mirror:    Mirror().Enabled(),
+			Group:     cfg.MustGet(ctx, "discovery.group", discoveryGroup).String(),
+			Interface: cfg.MustGet(ctx, "discovery.interface").String(),
+			Interval:  cfg.MustGet(ctx, "discovery.interval", "30s").Duration(),
+			Ttl:       cfg.MustGet(ctx, "discovery.ttl", "2m").Duration(),
+		})
+	})
+	return discoveryService
+}
+
+// NewDiscovery 按参数创建设备发现实例，未设置的参数使用默认值
+func NewDiscovery(opts DiscoveryOptions) *sDiscovery {
+	if opts.Group == "" {
+		opts.Group = discoveryGroup
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.Ttl <= 0 {
+		opts.Ttl = 4 * opts.Interval
+	}
+	return &sDiscovery{
+		opts:  opts,
+		peers: make(map[string]*model.Peer),
+	}
+}
+
+// Enabled discovery.enabled 为 true 时启用
+func (s *sDiscovery) Enabled() bool {
+	return g.Cfg().MustGet(context.Background(), "discovery.enabled").Bool()
+}
+
+// Start 加入组播组，宣告本机并查询其他设备
+func (s *sDiscovery) Start(ctx context.Context) (err error) {
+	if s.opts.Port <= 0 {
+		return gerror.New("discovery needs a fixed HTTP port, set discovery.port or server.address")
+	}
+	if s.group, err = net.ResolveUDPAddr("udp4", s.opts.Group); err != nil {
+		return gerror.Wrapf(err, "invalid discovery group %s", s.opts.Group)
+	}
+	var ifi *net.Interface
+	if s.opts.Interface != "" {
+		if ifi, err = net.InterfaceByName(s.opts.Interface); err != nil {
+			return gerror.Wrapf(err, "invalid discovery interface %s", s.opts.Interface)
+		}
+	}
+	// ListenMulticastUDP 设置 SO_REUSEADDR，多个实例可以监听同一组播端口
+	if s.conn, err = net.ListenMulticastUDP("udp4", ifi, s.group); err != nil {
+		return gerror.Wrapf(err, "listen on %s failed", s.opts.Group)
+	}
+	s.pc = ipv4.NewPacketConn(s.conn)
+	if ifi != nil {
+		_ = s.pc.SetMulticastInterface(ifi)
+	}
+	_ = s.pc.SetMulticastTTL(255)
+	_ = s.pc.SetMulticastLoopback(true)
+
+	s.stopCh = make(chan struct{})
+	go s.receive(context.WithoutCancel(ctx))
+	go s.loop(context.WithoutCancel(ctx))
+	logger(consts.LoggerDiscovery).Infof(ctx, "Discovery started, announcing %s on %s port %d", s.opts.DeviceId, s.opts.Group, s.opts.Port)
+	return nil
+}
+
+// Stop 发送 TTL 为 0 的告别宣告，使其他设备立即移除本机，并关闭连接
+func (s *sDiscovery) Stop(ctx context.Context) {
+	if s.conn == nil {
+		return
+	}
+	close(s.stopCh)
+	if err := s.announce(0); err != nil {
+		logger(consts.LoggerDiscovery).Warningf(ctx, "Send discovery goodbye failed: %v", err)
+	}
+	_ = s.conn.Close()
+}
+
+// Peers 返回当前在线的设备，按 deviceId 排序
+func (s *sDiscovery) Peers() []model.Peer {
+	now := gtime.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	peers := make([]model.Peer, 0, len(s.peers))
+	for _, peer := range s.peers {
+		if peer.ExpiresAt.After(now) {
+			peers = append(peers, *peer)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].DeviceId < peers[j].DeviceId })
+	return peers
+}
+
+// loop 启动时立即宣告和查询，之后按间隔重复并清理过期设备
+func (s *sDiscovery) loop(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.announce(s.opts.Ttl); err != nil {
+			logger(consts.LoggerDiscovery).Warningf(ctx, "Send discovery announcement failed: %v", err)
+		}
+		if err := s.query(); err != nil {
+			logger(consts.LoggerDiscovery).Warningf(ctx, "Send discovery query failed: %v", err)
+		}
+		s.expire(ctx)
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// receive 读取组播报文，回答对本服务类型的查询并记录其他设备的宣告
+func (s *sDiscovery) receive(ctx context.Context) {
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.stopCh:
+				return
+			default:
+			}
+			logger(consts.LoggerDiscovery).Warningf(ctx, "Read discovery packet failed: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		var msg dnsmessage.Message
+		if err = msg.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		if msg.Header.Response {
+			s.handleResponse(ctx, &msg, src)
+		} else if s.isServiceQuery(&msg) {
+			if err = s.announce(s.opts.Ttl); err != nil {
+				logger(consts.LoggerDiscovery).Warningf(ctx, "Answer discovery query failed: %v", err)
+			}
+		}
+	}
+}
+
+// isServiceQuery 判断查询是否包含本服务类型
+func (s *sDiscovery) isServiceQuery(msg *dnsmessage.Message) bool {
+	for _, q := range msg.Questions {
+		if strings.EqualFold(q.Name.String(), discoveryServiceType) &&
+			(q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) {
+			return true
+		}
+	}
+	return false
+}
+
+// query 发送本服务类型的 PTR 查询
+func (s *sDiscovery) query() error {
+	name, err := dnsmessage.NewName(discoveryServiceType)
+	if err != nil {
+		return err
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}
+	return s.send(&msg)
+}
+
+// announce 发送本机的 PTR、SRV、TXT 和 A 记录，ttl 为 0 表示下线
+func (s *sDiscovery) announce(ttl time.Duration) error {
+	label := strings.ReplaceAll(s.opts.DeviceId, ".", "-")
+	service, err := dnsmessage.NewName(discoveryServiceType)
+	if err != nil {
+		return err
+	}
+	instance, err := dnsmessage.NewName(label + "." + discoveryServiceType)
+	if err != nil {
+		return gerror.Wrapf(err, "invalid discovery instance name for %s", s.opts.DeviceId)
+	}
+	host, err := dnsmessage.NewName(label + "." + discoveryDomain)
+	if err != nil {
+		return gerror.Wrapf(err, "invalid discovery host name for %s", s.opts.DeviceId)
+	}
+	var (
+		seconds = uint32(ttl.Seconds())
+		flush   = dnsmessage.ClassINET | discoveryCacheFlush
+		mirror  = "0"
+	)
+	if s.opts.Mirror {
+		mirror = "1"
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{
+				Header: dnsmessage.ResourceHeader{Name: service, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: seconds},
+				Body:   &dnsmessage.PTRResource{PTR: instance},
+			},
+			{
+				Header: dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeSRV, Class: flush, TTL: seconds},
+				Body:   &dnsmessage.SRVResource{Port: uint16(s.opts.Port), Target: host},
+			},
+			{
+				Header: dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeTXT, Class: flush, TTL: seconds},
+				Body: &dnsmessage.TXTResource{TXT: []string{
+					"id=" + s.opts.DeviceId,
+					"version=" + s.opts.Version,
+					"port=" + strconv.Itoa(s.opts.Port),
+					"mirror=" + mirror,
+				}},
+			},
+		},
+	}
+	for _, ip := range s.addresses() {
+		msg.Additionals = append(msg.Additionals, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: host, Type: dnsmessage.TypeA, Class: flush, TTL: seconds},
+			Body:   &dnsmessage.AResource{A: [4]byte(ip.To4())},
+		})
+	}
+	return s.send(&msg)
+}
+
+// addresses 宣告的 IPv4 地址：配置的 discovery.host，或组播网卡(未指定时为全部网卡)的非回环地址
+func (s *sDiscovery) addresses() []net.IP {
+	if ip := net.ParseIP(s.opts.Host).To4(); ip != nil {
+		return []net.IP{ip}
+	}
+	var addrs []net.Addr
+	if s.opts.Interface != "" {
+		if ifi, err := net.InterfaceByName(s.opts.Interface); err == nil {
+			addrs, _ = ifi.Addrs()
+		}
+	} else {
+		addrs, _ = net.InterfaceAddrs()
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			ips = append(ips, ipNet.IP.To4())
+		}
+	}
+	return ips
+}
+
+// send 编码并发送到组播地址
+func (s *sDiscovery) send(msg *dnsmessage.Message) error {
+	packet, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = s.conn.WriteToUDP(packet, s.group)
+	return err
+}
+
+// handleResponse 解析宣告中本服务类型的实例，更新设备列表
+func (s *sDiscovery) handleResponse(ctx context.Context, msg *dnsmessage.Message, src *net.UDPAddr) {
+	var (
+		instances = make(map[string]uint32) // 实例名 -> PTR TTL
+		srv       = make(map[string]*dnsmessage.SRVResource)
+		txt       = make(map[string][]string)
+		hosts     = make(map[string][]string)
+	)
+	for _, r := range append(msg.Answers, msg.Additionals...) {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == discoveryServiceType {
+				instances[strings.ToLower(body.PTR.String())] = r.Header.TTL
+			}
+		case *dnsmessage.SRVResource:
+			srv[name] = body
+		case *dnsmessage.TXTResource:
+			txt[name] = body.TXT
+		case *dnsmessage.AResource:
+			hosts[name] = append(hosts[name], net.IP(body.A[:]).String())
+		}
+	}
+
+	for instance, ttl := range instances {
+		record, ok := srv[instance]
+		if !ok {
+			continue
+		}
+		fields := make(map[string]string)
+		for _, item := range txt[instance] {
+			key, value, _ := strings.Cut(item, "=")
+			fields[key] = value
+		}
+		deviceId := fields["id"]
+		if deviceId == "" {
+			deviceId = strings.TrimSuffix(instance, "."+discoveryServiceType)
+		}
+		if deviceId == s.opts.DeviceId {
+			continue
+		}
+		if ttl == 0 {
+			s.mu.Lock()
+			if _, ok = s.peers[deviceId]; ok {
+				delete(s.peers, deviceId)
+				logger(consts.LoggerDiscovery).Infof(ctx, "Peer %s left", deviceId)
+			}
+			s.mu.Unlock()
+			continue
+		}
+
+		// 报文来源地址在宣告的地址中或没有宣告地址时使用来源地址，否则使用宣告的第一个地址
+		addresses := hosts[strings.ToLower(record.Target.String())]
+		host := src.IP.String()
+		if len(addresses) > 0 && !slices.Contains(addresses, host) {
+			host = addresses[0]
+		}
+		now := gtime.Now()
+		peer := &model.Peer{
+			DeviceId:  deviceId,
+			Version:   fields["version"],
+			Host:      host,
+			Port:      int(record.Port),
+			Url:       fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(int(record.Port)))),
+			Addresses: addresses,
+			Mirror:    fields["mirror"] == "1",
+			LastSeen:  now,
+			ExpiresAt: now.Add(time.Duration(ttl) * time.Second),
+		}
+		s.mu.Lock()
+		if _, ok = s.peers[deviceId]; !ok {
+			logger(consts.LoggerDiscovery).Infof(ctx, "Peer %s discovered at %s", deviceId, peer.Url)
+		}
+		s.peers[deviceId] = peer
+		s.mu.Unlock()
+	}
+}
+
+// expire 移除超过有效期没有再次宣告的设备
+func (s *sDiscovery) expire(ctx context.Context) {
+	now := gtime.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, peer := range s.peers {
+		if !peer.ExpiresAt.After(now) {
+			delete(s.peers, id)
+			logger(consts.LoggerDiscovery).Infof(ctx, "Peer %s expired", id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"demo/internal/model"
+)
+
+// startTestDiscovery 在 group 上启动宣告 127.0.0.1 的发现实例
+func startTestDiscovery(t *testing.T, group, deviceId string, port int) *sDiscovery {
+	t.Helper()
+	d := NewDiscovery(DiscoveryOptions{
+		DeviceId: deviceId,
+		Version:  "test",
+		Port:     port,
+		Host:     "127.0.0.1",
+		Group:    group,
+		Interval: 200 * time.Millisecond,
+		Ttl:      time.Second,
+	})
+	if err := d.Start(context.Background()); err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+	return d
+}
+
+// peerIds 返回发现实例当前看到的设备ID
+func peerIds(d *sDiscovery) []string {
+	ids := make([]string, 0)
+	for _, peer := range d.Peers() {
+		ids = append(ids, peer.DeviceId)
+	}
+	return ids
+}
+
+// crashDiscovery 关闭连接但不发送告别宣告，模拟设备掉线
+func crashDiscovery(s *sDiscovery) {
+	close(s.stopCh)
+	_ = s.conn.Close()
+}
+
+func TestDiscoveryPeers(t *testing.T) {
+	// 使用随机端口，不干扰本机真实的 mDNS
+	_, port, _ := net.SplitHostPort(freeAddress(t))
+	group := "224.0.0.251:" + port
+
+	a := startTestDiscovery(t, group, "dev-a", 8001)
+	t.Cleanup(func() { a.Stop(context.Background()) })
+	b := startTestDiscovery(t, group, "dev-b", 8002)
+	c := startTestDiscovery(t, group, "dev-c", 8003)
+
+	all := map[*sDiscovery][]string{
+		a: {"dev-b", "dev-c"},
+		b: {"dev-a", "dev-c"},
+		c: {"dev-a", "dev-b"},
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for d, want := range all {
+		for !slices.Equal(peerIds(d), want) {
+			if time.Now().After(deadline) {
+				t.Skipf("%s sees %v, want %v; multicast loopback may be unavailable", d.opts.DeviceId, peerIds(d), want)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	var peer model.Peer
+	for _, p := range a.Peers() {
+		if p.DeviceId == "dev-b" {
+			peer = p
+		}
+	}
+	if peer.Host != "127.0.0.1" || peer.Port != 8002 || peer.Version != "test" ||
+		peer.Url != "http://127.0.0.1:"+strconv.Itoa(8002) {
+		t.Fatalf("unexpected peer %+v", peer)
+	}
+
+	// 告别宣告使其他设备立即移除
+	b.Stop(context.Background())
+	waitFor(t, 500*time.Millisecond, "dev-b removed after goodbye", func() bool {
+		return slices.Equal(peerIds(a), []string{"dev-c"})
+	})
+
+	// 掉线的设备在记录有效期后过期
+	crashDiscovery(c)
+	crashed := time.Now()
+	waitFor(t, 3*time.Second, "dev-c expired", func() bool {
+		return len(peerIds(a)) == 0
+	})
+	if elapsed := time.Since(crashed); elapsed < 500*time.Millisecond {
+		t.Fatalf("dev-c removed after %s, before its ttl", elapsed)
+	}
+	a.expire(context.Background())
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.peers) != 0 {
+		t.Fatalf("expired peers not removed: %v", a.peers)
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/gclient"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+	"go.opentelemetry.io/otel/attribute"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// sDownload 算法包下载服务，负责下载或复制本地算法包并校验文件摘要
+type sDownload struct {
+	client       *gclient.Client // 从 algorithmDataUrl 下载，使用 download 配置的代理、证书和主机认证
+	clientErr    error           // 下载客户端配置错误，此时拒绝从 algorithmDataUrl 下载
+	mirrorClient *gclient.Client // 从站点内镜像设备直接下载
+	stallTimeout time.Duration   // 超过该时间没有收到数据的下载视为卡死
+	mu           sync.Mutex
+	active       map[string]*downloadTask // 目标文件 -> 进行中的下载
+}
+
+// downloadTask 进行中的下载，记录最近一次收到数据的时间用于检测卡死
+type downloadTask struct {
+	url        string
+	tag        string
+	size       int64
+	origin     bool // 从 algorithmDataUrl 下载，否则为镜像设备
+	started    time.Time
+	written    atomic.Int64
+	updated    atomic.Int64 // UnixNano
+	throughput atomic.Int64 // 最近一个采样周期的每秒字节数
+	// 采样周期内的统计，只在下载协程中访问
+	sampleStart time.Time
+	sampleBytes int64
+}
+
+// downloadTagKey 下载标识在 context 中的键
+type downloadTagKey struct{}
+
+// downloadSampleInterval 下载速率的采样周期
+const downloadSampleInterval = time.Second
+
+// WithDownloadTag 为 ctx 中发起的下载设置标识，调用方可据此用 Download().Progress 查询进度
+func WithDownloadTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, downloadTagKey{}, tag)
+}
+
+// Write 统计接收字节数并刷新进度时间和速率
+func (t *downloadTask) Write(p []byte) (int, error) {
+	now := time.Now()
+	t.written.Add(int64(len(p)))
+	t.updated.Store(now.UnixNano())
+	t.sampleBytes += int64(len(p))
+	if elapsed := now.Sub(t.sampleStart); elapsed >= downloadSampleInterval {
+		t.throughput.Store(int64(float64(t.sampleBytes) / elapsed.Seconds()))
+		t.sampleStart, t.sampleBytes = now, 0
+	}
+	return len(p), nil
+}
+
+// progress 返回下载进度，第一个采样周期内按平均速率计算。只有从 algorithmDataUrl 下载且当前有生效的限速时
+// Throttled 为 true
+func (t *downloadTask) progress() model.DownloadProgress {
+	written := t.written.Load()
+	throughput := t.throughput.Load()
+	if throughput == 0 {
+		if elapsed := time.Since(t.started); elapsed > 0 {
+			throughput = int64(float64(written) / elapsed.Seconds())
+		}
+	}
+	return model.DownloadProgress{
+		Url:        t.url,
+		Size:       t.size,
+		Written:    written,
+		Throughput: throughput,
+		Throttled:  t.origin && Throttle().Rate(context.Background()) > 0,
+		StartedAt:  gtime.New(t.started),
+	}
+}
+
+var (
+	downloadService *sDownload
+	downloadOnce    sync.Once
+)
+
+// Download 获取下载服务单例
+func Download() *sDownload {
+	downloadOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		downloadService = &sDownload{
+			mirrorClient: newMirrorClient(ctx),
+			stallTimeout: g.Cfg().MustGet(ctx, "download.stallTimeout", "5m").Duration(),
+			active:       make(map[string]*downloadTask),
+		}
+		downloadService.client, downloadService.clientErr = newDownloadClient(ctx)
+		if downloadService.clientErr != nil {
+			logger(consts.LoggerDownload).Errorf(ctx, "Invalid download client config: %v", downloadService.clientErr)
+		}
+	})
+	return downloadService
+}
+
+// Fetch 下载 url 到 dst，下载过程中计算摘要，校验失败时删除临时文件。
+// size 大于 0 时同时校验文件大小。先依次尝试站点内的镜像设备，超过 download.stallTimeout 没有收到数据的
+// 镜像设备视为失败，都失败时从 url 下载，只有从 url 下载时受 Throttle() 限速并使用配置的代理。
+func (s *sDownload) Fetch(ctx context.Context, url, dst string, size int64, digest Digest) error {
+	for _, mirror := range Mirror().Urls(digest) {
+		err := s.fetchFrom(ctx, mirror, dst, size, digest, false)
+		if err == nil {
+			return nil
+		}
+		logger(consts.LoggerDownload).Infof(ctx, "Fetch %s from mirror failed, trying next source: %v", digest, err)
+	}
+	return s.fetchFrom(ctx, url, dst, size, digest, true)
+}
+
+// fetchFrom 从单个地址下载，记录进行中的任务和下载指标。origin 为 false 时是镜像设备
+func (s *sDownload) fetchFrom(ctx context.Context, url, dst string, size int64, digest Digest, origin bool) error {
+	now := time.Now()
+	tag, _ := ctx.Value(downloadTagKey{}).(string)
+	task := &downloadTask{url: url, tag: tag, size: size, origin: origin, started: now, sampleStart: now}
+	task.updated.Store(now.UnixNano())
+	s.mu.Lock()
+	s.active[dst] = task
+	s.mu.Unlock()
+	// 镜像设备卡住时取消下载，换下一个来源
+	if !origin && s.stallTimeout > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go s.watchStall(ctx, task, cancel)
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, dst)
+		s.mu.Unlock()
+	}()
+
+	ctx, span := Tracing().StartSpan(ctx, "download.fetch",
+		attribute.String("download.url", url),
+		attribute.Int64("download.size", size),
+	)
+	started := time.Now()
+	written, err := s.fetch(ctx, task, dst, size, digest)
+	if cause := context.Cause(ctx); err != nil && cause != nil && cause != ctx.Err() {
+		err = cause
+	}
+	Metrics().ObserveDownload(written, time.Since(started), err)
+	span.SetAttributes(attribute.Int64("download.written", written))
+	Tracing().EndSpan(span, err)
+	return err
+}
+
+// watchStall 超过 stallTimeout 没有收到数据时取消 ctx
+func (s *sDownload) watchStall(ctx context.Context, task *downloadTask, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(s.stallTimeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, task.updated.Load())) > s.stallTimeout {
+				cancel(gerror.Newf("download %s stalled: no data for %s", task.url, s.stallTimeout))
+				return
+			}
+		}
+	}
+}
+
+// fetch 执行下载和校验，返回实际接收的字节数
+func (s *sDownload) fetch(ctx context.Context, task *downloadTask, dst string, size int64, digest Digest) (written int64, err error) {
+	url, client := task.url, s.mirrorClient
+	if task.origin {
+		if s.clientErr != nil {
+			return 0, gerror.Wrapf(s.clientErr, "download %s failed", url)
+		}
+		client = s.client
+	}
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return 0, gerror.Wrapf(err, "download %s failed", url)
+	}
+	defer resp.Close()
+	if resp.StatusCode != 200 {
+		return 0, gerror.Newf("download %s failed: http status %d", url, resp.StatusCode)
+	}
+	var body io.Reader = resp.Body
+	if task.origin {
+		body = Throttle().Reader(ctx, body)
+	}
+	if written, err = s.save(ctx, io.TeeReader(body, task), dst, size, digest); err != nil {
+		return written, err
+	}
+	logger(consts.LoggerDownload).Infof(ctx, "Downloaded %s to %s (%d bytes, %s verified)", url, dst, written, digest.Algo)
+	return written, nil
+}
+
+// Copy 从本地文件(上传或导入目录)复制算法包到 dst，与下载相同地校验大小和摘要
+func (s *sDownload) Copy(ctx context.Context, src, dst string, size int64, digest Digest) (err error) {
+	ctx, span := Tracing().StartSpan(ctx, "download.copy",
+		attribute.String("download.src", src),
+		attribute.Int64("download.size", size),
+	)
+	defer func() { Tracing().EndSpan(span, err) }()
+	f, err := os.Open(src)
+	if err != nil {
+		return gerror.Wrapf(err, "open package %s failed", src)
+	}
+	defer f.Close()
+	written, err := s.save(ctx, f, dst, size, digest)
+	if err != nil {
+		return err
+	}
+	logger(consts.LoggerDownload).Infof(ctx, "Copied %s to %s (%d bytes, %s verified)", src, dst, written, digest.Algo)
+	return nil
+}
+
+// save 写入临时文件并流式计算摘要，校验通过后重命名为 dst，返回写入的字节数
+func (s *sDownload) save(ctx context.Context, r io.Reader, dst string, size int64, digest Digest) (written int64, err error) {
+	h, err := NewDigestHash(digest.Algo)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, gerror.Wrapf(err, "create directory for %s failed", dst)
+	}
+	tmp := dst + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, gerror.Wrapf(err, "create %s failed", tmp)
+	}
+	written, err = io.Copy(io.MultiWriter(f, h), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return written, gerror.Wrapf(err, "write %s failed", tmp)
+	}
+
+	if err = s.verify(ctx, written, size, h.Sum(nil), digest); err != nil {
+		_ = os.Remove(tmp)
+		return written, err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return written, gerror.Wrapf(err, "rename %s failed", tmp)
+	}
+	return written, nil
+}
+
+// verify 校验文件大小和摘要，摘要在下载过程中已流式计算
+func (s *sDownload) verify(ctx context.Context, written, size int64, sum []byte, digest Digest) (err error) {
+	_, span := Tracing().StartSpan(ctx, "download.verify", attribute.String("digest.algo", digest.Algo))
+	defer func() { Tracing().EndSpan(span, err) }()
+	if size > 0 && written != size {
+		return gerror.NewCodef(gcode.CodeValidationFailed, "file size mismatch: expected %d, got %d", size, written)
+	}
+	if actual := hex.EncodeToString(sum); actual != digest.Hex {
+		return gerror.NewCodef(gcode.CodeValidationFailed, "%s mismatch: expected %s, got %s", digest.Algo, digest.Hex, actual)
+	}
+	return nil
+}
+
+// Stalled 返回超过 download.stallTimeout 没有收到数据的下载地址
+func (s *sDownload) Stalled() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stalled := make([]string, 0)
+	for _, task := range s.active {
+		if time.Since(time.Unix(0, task.updated.Load())) > s.stallTimeout {
+			stalled = append(stalled, task.url)
+		}
+	}
+	return stalled
+}
+
+// ActiveCount 返回进行中的下载数量
+func (s *sDownload) ActiveCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.active)
+}
+
+// Active 返回进行中的下载进度，按开始时间排序
+func (s *sDownload) Active() []model.DownloadProgress {
+	s.mu.Lock()
+	tasks := make([]*downloadTask, 0, len(s.active))
+	for _, task := range s.active {
+		tasks = append(tasks, task)
+	}
+	s.mu.Unlock()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].started.Before(tasks[j].started)
+	})
+	list := make([]model.DownloadProgress, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, task.progress())
+	}
+	return list
+}
+
+// Progress 返回指定标识的进行中的下载进度，没有时返回 nil
+func (s *sDownload) Progress(tag string) *model.DownloadProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.active {
+		if task.tag == tag {
+			progress := task.progress()
+			return &progress
+		}
+	}
+	return nil
+}
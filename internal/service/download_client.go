@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/gclient"
+	"github.com/gogf/gf/v2/util/gconv"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// downloadHostAuth 访问私有对象存储等主机时附加的认证信息，按 download.hosts 配置：
+//
+//	download:
+//	  hosts:
+//	    - host: "*.oss.example.com"  # 主机名，*. 开头匹配子域名
+//	      token: "xxx"               # Authorization: Bearer xxx
+//	      username: ""               # 或 Basic 认证
+//	      password: ""
+//	      headers:                   # 其他请求头
+//	        x-oss-security-token: "xxx"
+type downloadHostAuth struct {
+	Host     string            `json:"host"`
+	Token    string            `json:"token"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// matches 主机名是否匹配，忽略端口和大小写
+func (a *downloadHostAuth) matches(host string) bool {
+	pattern := strings.ToLower(a.Host)
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// header 返回需要附加的请求头
+func (a *downloadHostAuth) header() http.Header {
+	header := make(http.Header)
+	for key, value := range a.Headers {
+		header.Set(key, value)
+	}
+	switch {
+	case a.Token != "":
+		header.Set("Authorization", "Bearer "+a.Token)
+	case a.Username != "":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password)))
+	}
+	return header
+}
+
+// hostAuthTransport 按请求的主机附加认证头。每一跳重定向都重新匹配，
+// 跳转到其他主机时移除所有配置的认证头，避免令牌泄露给签名地址所在的主机
+type hostAuthTransport struct {
+	base  http.RoundTripper
+	hosts []downloadHostAuth
+	keys  []string // 所有配置的请求头名称
+}
+
+func (t *hostAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for _, key := range t.keys {
+		req.Header.Del(key)
+	}
+	for i := range t.hosts {
+		if t.hosts[i].matches(req.URL.Hostname()) {
+			for key, values := range t.hosts[i].header() {
+				req.Header[key] = values
+			}
+			break
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newDownloadClient 按 download 配置创建从 algorithmDataUrl 下载的客户端：
+//
+//	download:
+//	  proxy: "http://proxy.corp:3128"  # HTTP 和 HTTPS 下载使用的代理，为空时使用 HTTP_PROXY 等环境变量
+//	  proxyUsername: ""                # 代理 Basic 认证，也可写在 proxy 地址中
+//	  proxyPassword: ""
+//	  noProxy: "localhost,10.0.0.0/8,.corp.local"  # 不经过代理的主机，格式同 NO_PROXY
+//	  caFile: "/etc/i800/corp-ca.pem"  # 额外信任的 CA 证书(PEM)，与系统证书一起使用
+//	  insecureSkipVerify: false        # 不校验服务端证书，算法包仍按摘要校验
+//
+// 镜像设备之间的下载不使用该客户端
+func newDownloadClient(ctx context.Context) (*gclient.Client, error) {
+	cfg := g.Cfg()
+	client := g.Client()
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return nil, gerror.NewCode(gcode.CodeInternalError, "unexpected http client transport")
+	}
+
+	// 代理
+	proxy := httpproxy.FromEnvironment()
+	if proxyUrl := cfg.MustGet(ctx, "download.proxy").String(); proxyUrl != "" {
+		u, err := url.Parse(proxyUrl)
+		if err != nil || u.Host == "" {
+			return nil, gerror.NewCodef(gcode.CodeInvalidConfiguration, "invalid download.proxy: %s", proxyUrl)
+		}
+		if username := cfg.MustGet(ctx, "download.proxyUsername").String(); username != "" {
+			u.User = url.UserPassword(username, cfg.MustGet(ctx, "download.proxyPassword").String())
+		}
+		proxy.HTTPProxy, proxy.HTTPSProxy = u.String(), u.String()
+		proxy.NoProxy = ""
+	}
+	if noProxy := cfg.MustGet(ctx, "download.noProxy"); !noProxy.IsNil() {
+		proxy.NoProxy = strings.Join(noProxy.Strings(), ",")
+	}
+	proxyFunc := proxy.ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	// 证书
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.MustGet(ctx, "download.insecureSkipVerify").Bool(),
+	}
+	if caFile := cfg.MustGet(ctx, "download.caFile").String(); caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, gerror.WrapCodef(gcode.CodeInvalidConfiguration, err, "read download.caFile %s failed", caFile)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, gerror.NewCodef(gcode.CodeInvalidConfiguration, "no certificates found in download.caFile %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// 按主机的认证
+	var hosts []downloadHostAuth
+	if err := gconv.Structs(cfg.MustGet(ctx, "download.hosts").Maps(), &hosts); err != nil {
+		return nil, gerror.WrapCode(gcode.CodeInvalidConfiguration, err, "invalid download.hosts")
+	}
+	if len(hosts) > 0 {
+		keys := map[string]bool{"Authorization": true}
+		for _, host := range hosts {
+			if host.Host == "" {
+				return nil, gerror.NewCode(gcode.CodeInvalidConfiguration, "download.hosts: host is required")
+			}
+			for key := range host.Headers {
+				keys[http.CanonicalHeaderKey(key)] = true
+			}
+		}
+		auth := &hostAuthTransport{base: transport, hosts: hosts}
+		for key := range keys {
+			auth.keys = append(auth.keys, key)
+		}
+		client.Transport = auth
+	}
+	return client, nil
+}
+
+// newMirrorClient 创建从镜像设备下载的客户端。镜像设备在站点局域网内，按 mirror 配置缩短连接和等待响应头的时限，
+// 不可达的设备尽快换下一个来源：
+//
+//	mirror:
+//	  connectTimeout: "3s"  # 建立连接的时限
+//	  headerTimeout: "10s"  # 发出请求后等待响应头的时限
+func newMirrorClient(ctx context.Context) *gclient.Client {
+	cfg := g.Cfg()
+	connectTimeout := cfg.MustGet(ctx, "mirror.connectTimeout", "3s").Duration()
+	client := g.Client()
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = connectTimeout
+		transport.ResponseHeaderTimeout = cfg.MustGet(ctx, "mirror.headerTimeout", "10s").Duration()
+	}
+	return client
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/model"
+)
+
+func TestFetchMirrorFallback(t *testing.T) {
+	loadTestConfig(t, g.Map{"mirror": g.Map{"headerTimeout": "200ms"}})
+	ctx := context.Background()
+	content := []byte("algorithm package content")
+	sum := sha256.Sum256(content)
+	digest, err := NewDigest(DigestSha256, hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	// 不回复响应头的设备
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer silent.Close()
+	// 发送部分数据后卡住的设备
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "25")
+		_, _ = w.Write(content[:5])
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stalled.Close()
+	// 超出 maxPeers 不会被尝试的设备
+	var skippedHits atomic.Int32
+	skipped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skippedHits.Add(1)
+		_, _ = w.Write(content)
+	}))
+	defer skipped.Close()
+	var originHits atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		originHits.Add(1)
+		_, _ = w.Write(content)
+	}))
+	defer origin.Close()
+
+	mirror := Mirror()
+	peers, maxPeers := mirror.peers, mirror.maxPeers
+	mirror.peers, mirror.maxPeers = []string{silent.URL, stalled.URL, skipped.URL}, 2
+	defer func() { mirror.peers, mirror.maxPeers = peers, maxPeers }()
+	if urls := mirror.Urls(digest); len(urls) != 2 {
+		t.Fatalf("mirror urls = %v, want 2", urls)
+	}
+
+	s := &sDownload{
+		client:       g.Client(),
+		mirrorClient: newMirrorClient(ctx),
+		stallTimeout: 300 * time.Millisecond,
+		active:       make(map[string]*downloadTask),
+	}
+	dst := filepath.Join(t.TempDir(), "package.zip")
+	started := time.Now()
+	if err = s.Fetch(ctx, origin.URL, dst, int64(len(content)), digest); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("fetch took %s", elapsed)
+	}
+	if originHits.Load() != 1 || skippedHits.Load() != 0 {
+		t.Fatalf("origin hits %d, skipped peer hits %d", originHits.Load(), skippedHits.Load())
+	}
+	if got, err := os.ReadFile(dst); err != nil || string(got) != string(content) {
+		t.Fatalf("downloaded %q, %v", got, err)
+	}
+	if s.ActiveCount() != 0 {
+		t.Fatalf("%d downloads still active", s.ActiveCount())
+	}
+}
+
+func TestDownloadProgressThrottled(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	ctx := context.Background()
+	defer Throttle().Reset(ctx)
+	tests := []struct {
+		name      string
+		rateLimit int64
+		origin    bool
+		want      bool
+	}{
+		{name: "origin unlimited", origin: true},
+		{name: "origin limited", rateLimit: 1 << 20, origin: true, want: true},
+		{name: "mirror limited", rateLimit: 1 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Throttle().Set(ctx, model.DownloadThrottle{RateLimit: tt.rateLimit}); err != nil {
+				t.Fatal(err)
+			}
+			task := &downloadTask{origin: tt.origin, started: time.Now()}
+			if got := task.progress().Throttled; got != tt.want {
+				t.Fatalf("throttled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// healthCheck 单项检查，返回状态和说明
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) (status, message string)
+}
+
+const (
+	// 存活检查的定时任务间隔
+	healthTickInterval = time.Second
+	// 定时任务超过该时长没有执行时认为进程已卡死
+	healthTickTimeout = 10 * time.Second
+)
+
+// sHealth 健康检查服务，提供存活检查和就绪检查
+type sHealth struct {
+	transportRequired bool          // 命令通道断开时就绪检查是否失败，否则只告警
+	timeout           time.Duration // 单项检查超时
+	lastTick          atomic.Int64  // 存活检查定时任务最近一次执行的时间，UnixNano
+}
+
+var (
+	healthService *sHealth
+	healthOnce    sync.Once
+)
+
+// Health 获取健康检查服务单例
+func Health() *sHealth {
+	healthOnce.Do(func() {
+		ctx := context.Background()
+		healthService = &sHealth{
+			// health.mqttRequired 为 transportRequired 的旧名称
+			transportRequired: g.Cfg().MustGet(ctx, "health.transportRequired",
+				g.Cfg().MustGet(ctx, "health.mqttRequired", false)).Bool(),
+			timeout: g.Cfg().MustGet(ctx, "health.timeout", "3s").Duration(),
+		}
+		healthService.tick(ctx)
+		gtimer.AddSingleton(ctx, healthTickInterval, healthService.tick)
+	})
+	return healthService
+}
+
+// Liveness 存活检查，只检查进程本身仍在调度执行，不检查外部依赖和下载任务，
+// 避免云端或下载异常导致进程被反复重启
+func (s *sHealth) Liveness(ctx context.Context) *model.HealthReport {
+	return s.run(ctx, []healthCheck{
+		{"process", s.checkProcess},
+	})
+}
+
+// Readiness 就绪检查，检查数据库、迁移、命令通道、磁盘空间和下载任务
+func (s *sHealth) Readiness(ctx context.Context) *model.HealthReport {
+	return s.run(ctx, []healthCheck{
+		{"database", s.checkDatabase},
+		{"migrations", s.checkMigrations},
+		{"transport", s.checkTransport},
+		{"disk", s.checkDisk},
+		{"download", s.checkDownload},
+	})
+}
+
+// run 依次执行检查并汇总，任一检查失败时整体失败，有告警时整体告警
+func (s *sHealth) run(ctx context.Context, checks []healthCheck) *model.HealthReport {
+	report := &model.HealthReport{
+		Status: consts.HealthStatusOk,
+		Checks: make([]model.HealthCheck, 0, len(checks)),
+		Time:   gtime.Now(),
+	}
+	for _, item := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		started := time.Now()
+		status, message := item.check(checkCtx)
+		cancel()
+		report.Checks = append(report.Checks, model.HealthCheck{
+			Name:     item.name,
+			Status:   status,
+			Message:  message,
+			Duration: time.Since(started).Milliseconds(),
+		})
+		switch {
+		case status == consts.HealthStatusFail:
+			report.Status = consts.HealthStatusFail
+		case status == consts.HealthStatusWarn && report.Status == consts.HealthStatusOk:
+			report.Status = consts.HealthStatusWarn
+		}
+	}
+	return report
+}
+
+// checkDatabase 在事务中执行一条写语句，确认 SQLite 可写
+func (s *sHealth) checkDatabase(ctx context.Context) (string, string) {
+	err := g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Exec("DELETE FROM `schema_migrations` WHERE `version` = ''")
+		return err
+	})
+	if err != nil {
+		return consts.HealthStatusFail, err.Error()
+	}
+	return consts.HealthStatusOk, "writable"
+}
+
+// checkMigrations 确认迁移目录中的全部迁移都已执行
+func (s *sHealth) checkMigrations(ctx context.Context) (string, string) {
+	applied, err := Database().AppliedMigrations(ctx)
+	if err != nil {
+		return consts.HealthStatusFail, err.Error()
+	}
+	var pending []string
+	for _, version := range Database().Migrations() {
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	if len(pending) > 0 {
+		return consts.HealthStatusFail, "pending: " + strings.Join(pending, ", ")
+	}
+	return consts.HealthStatusOk, fmt.Sprintf("%d applied", len(applied))
+}
+
+// checkTransport 检查 MQTT 或 HTTP 命令通道与云端的连接，health.transportRequired 为 false 时断开只告警
+func (s *sHealth) checkTransport(ctx context.Context) (string, string) {
+	transport := Transport()
+	if !transport.Enabled() {
+		return consts.HealthStatusOk, transport.Name() + " disabled"
+	}
+	if transport.IsConnected() {
+		return consts.HealthStatusOk, transport.Name() + " connected"
+	}
+	if s.transportRequired {
+		return consts.HealthStatusFail, transport.Name() + " disconnected"
+	}
+	return consts.HealthStatusWarn, transport.Name() + " disconnected"
+}
+
+// tick 记录存活检查定时任务的执行时间
+func (s *sHealth) tick(ctx context.Context) {
+	s.lastTick.Store(time.Now().UnixNano())
+}
+
+// checkProcess 检查定时任务仍按间隔执行，进程没有卡死
+func (s *sHealth) checkProcess(ctx context.Context) (string, string) {
+	if since := time.Since(time.Unix(0, s.lastTick.Load())); since > healthTickTimeout {
+		return consts.HealthStatusFail, fmt.Sprintf("timer not run for %s", since.Truncate(time.Second))
+	}
+	return consts.HealthStatusOk, fmt.Sprintf("pid %d, %d goroutines", os.Getpid(), runtime.NumGoroutine())
+}
+
+// checkDisk 检查算法存储所在文件系统剩余空间不低于 storage.reserve
+func (s *sHealth) checkDisk(ctx context.Context) (string, string) {
+	storePath := Algorithm().StorePath()
+	if !gfile.Exists(storePath) {
+		storePath = "."
+	}
+	_, free, err := diskSpace(storePath)
+	if err != nil {
+		return consts.HealthStatusFail, err.Error()
+	}
+	if free < 0 {
+		return consts.HealthStatusOk, "free space unknown"
+	}
+	message := fmt.Sprintf("%s free", gfile.FormatSize(free))
+	if free < Storage().reserve {
+		return consts.HealthStatusFail, message + ", below reserve " + gfile.FormatSize(Storage().reserve)
+	}
+	return consts.HealthStatusOk, message
+}
+
+// checkDownload 检查进行中的下载没有卡死
+func (s *sHealth) checkDownload(ctx context.Context) (string, string) {
+	if stalled := Download().Stalled(); len(stalled) > 0 {
+		return consts.HealthStatusFail, "stalled: " + strings.Join(stalled, ", ")
+	}
+	return consts.HealthStatusOk, fmt.Sprintf("%d active", Download().ActiveCount())
+}
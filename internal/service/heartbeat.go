@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// HeartbeatPayload 心跳消息内容
+type HeartbeatPayload struct {
+	DeviceId   string                         `json:"deviceId"`
+	Version    string                         `json:"version"`
+	Timestamp  int64                          `json:"timestamp"`
+	Algorithms []model.AlgorithmRuntimeStatus `json:"algorithms"`
+	// ConfigRevision 当前应用的设备配置版本，0 为只使用配置文件
+	ConfigRevision int    `json:"configRevision"`
+	ConfigStatus   string `json:"configStatus,omitempty"`
+	// Upgrade 最近一次程序自升级的状态
+	Upgrade *model.FirmwareUpgradeStatus `json:"upgrade,omitempty"`
+}
+
+// sHeartbeat 定时通过命令通道上报设备心跳和算法运行状态
+type sHeartbeat struct {
+	mu       sync.Mutex
+	interval time.Duration
+	timer    *gtimer.Entry
+}
+
+var (
+	heartbeatService *sHeartbeat
+	heartbeatOnce    sync.Once
+)
+
+// Heartbeat 获取心跳服务单例
+func Heartbeat() *sHeartbeat {
+	heartbeatOnce.Do(func() {
+		heartbeatService = &sHeartbeat{
+			interval: Config().Get().Mqtt.HeartbeatInterval,
+		}
+	})
+	return heartbeatService
+}
+
+// Start 启动定时心跳上报
+func (s *sHeartbeat) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		return
+	}
+	s.start(ctx)
+}
+
+// Interval 返回当前心跳间隔
+func (s *sHeartbeat) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// SetInterval 修改心跳间隔，已启动时按新间隔重新计时
+func (s *sHeartbeat) SetInterval(ctx context.Context, interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if interval == s.interval {
+		return
+	}
+	s.interval = interval
+	if s.timer != nil {
+		s.timer.Close()
+		s.start(ctx)
+	}
+}
+
+// start 按当前间隔启动定时器，调用方持有锁
+func (s *sHeartbeat) start(ctx context.Context) {
+	s.timer = gtimer.AddSingleton(ctx, s.interval, func(ctx context.Context) {
+		if err := s.Publish(ctx); err != nil {
+			logger(consts.LoggerMqtt).Warningf(ctx, "Publish heartbeat failed: %v", err)
+		}
+	})
+}
+
+// Publish 立即发布一次心跳
+func (s *sHeartbeat) Publish(ctx context.Context) error {
+	payload, err := gjson.Encode(s.Payload())
+	if err != nil {
+		return err
+	}
+	return Transport().SendHeartbeat(ctx, payload)
+}
+
+// Payload 生成心跳内容
+func (s *sHeartbeat) Payload() HeartbeatPayload {
+	payload := HeartbeatPayload{
+		DeviceId:   Device().Id(),
+		Version:    consts.Version,
+		Timestamp:  gtime.Timestamp(),
+		Algorithms: Supervisor().Status(),
+		Upgrade:    Upgrade().Status(),
+	}
+	payload.ConfigRevision, payload.ConfigStatus = DeviceConfig().Applied()
+	return payload
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+func TestMain(m *testing.M) {
+	// 测试程序同时作为算法启动器，供设置了资源限制的守护测试使用
+	RunProcessLauncher()
+	code := m.Run()
+	if testDatabaseDir != "" {
+		_ = os.RemoveAll(testDatabaseDir)
+	}
+	os.Exit(code)
+}
+
+var (
+	testDatabaseDir  string
+	testDatabaseOnce sync.Once
+	testDatabaseErr  error
+)
+
+// useTestDatabase 让 g.DB() 使用临时目录中的 SQLite 数据库并执行初始化和迁移，
+// 同一测试进程共用一个数据库，各测试自行清理用到的表
+func useTestDatabase(t *testing.T) {
+	t.Helper()
+	testDatabaseOnce.Do(func() {
+		if testDatabaseDir, testDatabaseErr = os.MkdirTemp("", "service-test-"); testDatabaseErr != nil {
+			return
+		}
+		testDatabaseErr = gdb.SetConfigGroup(gdb.DefaultGroupName, gdb.ConfigGroup{{
+			Link: "sqlite::@file(" + filepath.Join(testDatabaseDir, "sqlite.db") + ")",
+		}})
+		if testDatabaseErr != nil {
+			return
+		}
+		// 初始化和迁移文件按项目根目录的相对路径读取
+		wd, err := os.Getwd()
+		if testDatabaseErr = err; err != nil {
+			return
+		}
+		if testDatabaseErr = os.Chdir("../.."); testDatabaseErr != nil {
+			return
+		}
+		defer func() { _ = os.Chdir(wd) }()
+		testDatabaseErr = Database().Init(context.Background())
+	})
+	if testDatabaseErr != nil {
+		t.Fatalf("init test database: %v", testDatabaseErr)
+	}
+}
+
+// loadTestConfig 以 content 作为配置文件内容加载运行配置，未设置的配置项使用默认值
+func loadTestConfig(t *testing.T, content g.Map) {
+	t.Helper()
+	adapter, ok := g.Cfg().GetAdapter().(*gcfg.AdapterFile)
+	if !ok {
+		t.Fatal("unsupported config adapter")
+	}
+	adapter.SetContent(gjson.MustEncodeString(content), adapter.GetFileName())
+	adapter.Clear()
+	if err := Config().Load(context.Background()); err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+}
+
+// freeAddress 返回本机一个空闲的 TCP 地址
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// startTestBroker 在 address 上启动进程内 MQTT broker，允许所有客户端连接
+func startTestBroker(t *testing.T, address string) *mqttserver.Server {
+	t.Helper()
+	server := mqttserver.New(&mqttserver.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: address})); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+// waitFor 等待 cond 成立，超时后测试失败
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 导入目录中元数据文件的扩展名，与算法包同名，如 face.zip 和 face.json
+const importMetadataExt = ".json"
+
+// sImport 离线导入服务，用于无法访问 algorithmDataUrl 的站点：
+// 通过 POST /algorithm/upload 上传，或将算法包和元数据放入 algorithm.importPath(如挂载的 U 盘)，
+// 与下发安装相同地校验摘要后安装，algorithm_data_url 记录为 local
+type sImport struct {
+	path       string
+	uploadPath string
+	interval   time.Duration
+	mu         sync.Mutex
+	seen       map[string]string // 导入目录中已处理的算法包 -> 算法包和元数据的修改时间和大小，文件变化后重新导入
+}
+
+var (
+	importService *sImport
+	importOnce    sync.Once
+)
+
+// Import 获取离线导入服务单例
+func Import() *sImport {
+	importOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		cfg := g.Cfg()
+		importService = &sImport{
+			path:       cfg.MustGet(ctx, "algorithm.importPath").String(),
+			uploadPath: cfg.MustGet(ctx, "algorithm.uploadPath", "data/uploads").String(),
+			interval:   cfg.MustGet(ctx, "algorithm.importInterval", "10s").Duration(),
+			seen:       make(map[string]string),
+		}
+	})
+	return importService
+}
+
+// UploadPath 上传的算法包在安装前暂存的目录
+func (s *sImport) UploadPath() string {
+	return s.uploadPath
+}
+
+// Start 配置了 algorithm.importPath 时定时扫描导入目录
+func (s *sImport) Start(ctx context.Context) {
+	if s.path == "" {
+		return
+	}
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Watching import directory %s every %s", s.path, s.interval)
+	gtimer.AddSingleton(ctx, s.interval, func(ctx context.Context) {
+		s.Scan(ctx)
+	})
+}
+
+// Install 按元数据校验并安装本地算法包
+func (s *sImport) Install(ctx context.Context, packageFile string, metadata []byte) (int64, error) {
+	meta, err := s.parseMetadata(ctx, metadata)
+	if err != nil {
+		return 0, err
+	}
+	return Algorithm().Install(ctx, model.AlgorithmInstallInput{
+		AlgorithmAddInput: model.AlgorithmAddInput{
+			AlgorithmId:        meta.AlgorithmId,
+			AlgorithmName:      meta.AlgorithmName,
+			AlgorithmVersion:   meta.AlgorithmVersion,
+			AlgorithmVersionId: meta.AlgorithmVersionId,
+			FileSize:           meta.FileSize,
+			Md5:                meta.Md5,
+			Sha256:             meta.Sha256,
+			Digest:             meta.Digest,
+		},
+		PackageFile: packageFile,
+	})
+}
+
+// Scan 安装导入目录中有元数据文件的 .zip 算法包。同一版本和摘要已安装的跳过；
+// 安装成功或元数据、摘要校验失败的在算法包或元数据变化前不再处理，其他失败(如存储空间不足)下次扫描重试
+func (s *sImport) Scan(ctx context.Context) {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		// 未插入 U 盘时目录不存在
+		if !os.IsNotExist(err) {
+			logger(consts.LoggerAlgorithm).Warningf(ctx, "Read import directory %s failed: %v", s.path, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".zip") {
+			continue
+		}
+		packageFile := filepath.Join(s.path, entry.Name())
+		metadataFile := strings.TrimSuffix(packageFile, filepath.Ext(packageFile)) + importMetadataExt
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// 元数据可能还在复制中，下次扫描再处理
+		metaInfo, err := os.Stat(metadataFile)
+		if err != nil {
+			continue
+		}
+		version := fmt.Sprintf("%d/%d/%d/%d", info.ModTime().UnixNano(), info.Size(), metaInfo.ModTime().UnixNano(), metaInfo.Size())
+		s.mu.Lock()
+		seen := s.seen[packageFile] == version
+		s.mu.Unlock()
+		if seen {
+			continue
+		}
+		metadata, err := os.ReadFile(metadataFile)
+		if err != nil {
+			continue
+		}
+		if err = s.importFile(ctx, packageFile, metadata); err != nil {
+			if !importPermanent(err) {
+				logger(consts.LoggerAlgorithm).Warningf(ctx, "Import %s failed, retrying on next scan: %v", packageFile, err)
+				continue
+			}
+			logger(consts.LoggerAlgorithm).Errorf(ctx, "Import %s failed: %v", packageFile, err)
+		}
+		s.mu.Lock()
+		s.seen[packageFile] = version
+		s.mu.Unlock()
+	}
+}
+
+// importPermanent 判断导入失败在文件变化前重试是否仍会失败，如元数据无效、摘要或大小不符
+func importPermanent(err error) bool {
+	switch gerror.Code(err) {
+	case gcode.CodeInvalidParameter, gcode.CodeMissingParameter, gcode.CodeValidationFailed:
+		return true
+	}
+	return false
+}
+
+// importFile 安装导入目录中的单个算法包，已安装相同版本时跳过
+func (s *sImport) importFile(ctx context.Context, packageFile string, metadata []byte) error {
+	meta, err := s.parseMetadata(ctx, metadata)
+	if err != nil {
+		return err
+	}
+	digest, err := StrongestDigest(meta.Md5, meta.Sha256, meta.Digest)
+	if err != nil {
+		return err
+	}
+	if installed, err := Algorithm().GetByAlgorithmId(ctx, meta.AlgorithmId); err == nil &&
+		installed.AlgorithmVersionId == meta.AlgorithmVersionId &&
+		installed.DigestAlgo == digest.Algo && installed.Digest == digest.Hex {
+		return nil
+	}
+	id, err := s.Install(ctx, packageFile, metadata)
+	if err != nil {
+		return err
+	}
+	logger(consts.LoggerAlgorithm).Infof(ctx, "Imported %s as algorithm %s (%d)", packageFile, meta.AlgorithmId, id)
+	return nil
+}
+
+// parseMetadata 解析并校验元数据
+func (s *sImport) parseMetadata(ctx context.Context, metadata []byte) (*model.AlgorithmMetadata, error) {
+	if len(metadata) == 0 {
+		return nil, gerror.NewCode(gcode.CodeMissingParameter, "algorithm metadata is required")
+	}
+	var meta *model.AlgorithmMetadata
+	if err := gjson.DecodeTo(metadata, &meta); err != nil {
+		return nil, gerror.WrapCode(gcode.CodeInvalidParameter, err, "invalid algorithm metadata")
+	}
+	if meta == nil {
+		return nil, gerror.NewCode(gcode.CodeMissingParameter, "algorithm metadata is required")
+	}
+	if err := g.Validator().Data(meta).Run(ctx); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
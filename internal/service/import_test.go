@@ -0,0 +1,138 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"demo/internal/dao"
+)
+
+// writeImportPackage 在 dir 中写入算法包 name.zip 和元数据 name.json，返回算法包路径
+func writeImportPackage(t *testing.T, dir, name string, metadata g.Map) string {
+	t.Helper()
+	packageFile := filepath.Join(dir, name+".zip")
+	f, err := os.Create(packageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for file, content := range map[string]string{
+		"manifest.json": `{"entrypoint":"run.sh"}`,
+		"run.sh":        "#!/bin/sh\nsleep 60\n",
+	} {
+		fw, err := w.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(packageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	meta := g.Map{
+		"algorithmId":        name,
+		"algorithmName":      name,
+		"algorithmVersion":   "1.0.0",
+		"algorithmVersionId": name + "-v1",
+		"fileSize":           len(content),
+		"sha256":             hex.EncodeToString(sum[:]),
+	}
+	for key, value := range metadata {
+		meta[key] = value
+	}
+	if err = os.WriteFile(filepath.Join(dir, name+importMetadataExt), gjson.MustEncode(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return packageFile
+}
+
+func TestImportScanRetry(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	useTestDatabase(t)
+	ctx := context.Background()
+	storePath := Algorithm().storePath
+	Algorithm().storePath = t.TempDir()
+	defer func() { Algorithm().storePath = storePath }()
+
+	tests := []struct {
+		name      string
+		metadata  g.Map
+		quota     int64 // 存储配额，用于模拟可恢复的失败
+		wantSeen  bool
+		installed bool
+	}{
+		{name: "import-ok", wantSeen: true, installed: true},
+		{name: "import-invalid-metadata", metadata: g.Map{"algorithmVersionId": "../v1"}, wantSeen: true},
+		{name: "import-digest-mismatch", metadata: g.Map{"sha256": "0000000000000000000000000000000000000000000000000000000000000000"}, wantSeen: true},
+		{name: "import-size-mismatch", metadata: g.Map{"fileSize": 1}, wantSeen: true},
+		{name: "import-quota-exceeded", quota: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			packageFile := writeImportPackage(t, dir, tt.name, tt.metadata)
+			s := &sImport{path: dir, seen: make(map[string]string)}
+			quota := Storage().quota
+			Storage().quota = tt.quota
+			s.Scan(ctx)
+			Storage().quota = quota
+
+			if _, seen := s.seen[packageFile]; seen != tt.wantSeen {
+				t.Fatalf("seen = %v, want %v", seen, tt.wantSeen)
+			}
+			count, err := dao.Algorithm.Ctx(ctx).Where(dao.Algorithm.Columns().AlgorithmId, tt.name).Count()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (count > 0) != tt.installed {
+				t.Fatalf("installed = %v, want %v", count > 0, tt.installed)
+			}
+			if tt.wantSeen {
+				return
+			}
+			// 可恢复的失败在下次扫描时重试
+			s.Scan(ctx)
+			if _, seen := s.seen[packageFile]; !seen {
+				t.Fatal("package not imported on retry")
+			}
+			if _, err = Algorithm().GetByAlgorithmId(ctx, tt.name); err != nil {
+				t.Fatalf("package not installed on retry: %v", err)
+			}
+		})
+	}
+
+	// 只修正元数据也会重新导入
+	dir := t.TempDir()
+	writeImportPackage(t, dir, "import-fixed", g.Map{"algorithmVersionId": "../v1"})
+	s := &sImport{path: dir, seen: make(map[string]string)}
+	s.Scan(ctx)
+	metadataFile := filepath.Join(dir, "import-fixed"+importMetadataExt)
+	meta := gjson.New(gfile.GetBytes(metadataFile))
+	_ = meta.Set("algorithmVersionId", "import-fixed-v1")
+	if err := os.WriteFile(metadataFile, meta.MustToJson(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.Scan(ctx)
+	if _, err := Algorithm().GetByAlgorithmId(ctx, "import-fixed"); err != nil {
+		t.Fatalf("fixed metadata not imported: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/glog"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 可单独设置级别的日志子系统
+var logSubsystems = []string{
+	consts.LoggerApp,
+	consts.LoggerAlgorithm,
+	consts.LoggerCommand,
+	consts.LoggerDatabase,
+	consts.LoggerDiscovery,
+	consts.LoggerDownload,
+	consts.LoggerMqtt,
+	consts.LoggerSchedule,
+	consts.LoggerStorage,
+	consts.LoggerSupervisor,
+}
+
+// 日志级别取值，从低到高，与 glog 级别位对应
+var logLevels = []struct {
+	name  string
+	level int
+}{
+	{"debug", glog.LEVEL_DEBU},
+	{"info", glog.LEVEL_INFO},
+	{"notice", glog.LEVEL_NOTI},
+	{"warning", glog.LEVEL_WARN},
+	{"error", glog.LEVEL_ERRO},
+	{"critical", glog.LEVEL_CRIT},
+}
+
+// sLogging 日志服务，统一 JSON 输出格式，按子系统管理日志实例和级别
+type sLogging struct {
+	mu          sync.Mutex
+	initialized map[string]bool // 已设置前缀的子系统
+}
+
+var (
+	loggingService *sLogging
+	loggingOnce    sync.Once
+)
+
+// Logging 获取日志服务单例
+func Logging() *sLogging {
+	loggingOnce.Do(func() {
+		loggingService = &sLogging{
+			initialized: make(map[string]bool),
+		}
+	})
+	return loggingService
+}
+
+// logger 返回子系统的日志实例
+func logger(subsystem string) *glog.Logger {
+	return Logging().Logger(subsystem)
+}
+
+// Setup 按配置设置日志格式和各子系统初始级别，log.format 为 json 时输出单行 JSON
+func (s *sLogging) Setup(ctx context.Context) error {
+	if g.Cfg().MustGet(ctx, "log.format", "json").String() == "json" {
+		glog.SetDefaultHandler(glog.HandlerJson)
+	}
+	for subsystem, level := range g.Cfg().MustGet(ctx, "log.levels").MapStrStr() {
+		if err := s.SetLevel(subsystem, level); err != nil {
+			return gerror.Wrapf(err, "invalid log.levels.%s", subsystem)
+		}
+	}
+	return nil
+}
+
+// Logger 返回子系统的日志实例，日志前缀为子系统名。
+// app 使用默认日志实例，框架自身的日志也输出到这里。
+func (s *sLogging) Logger(subsystem string) *glog.Logger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := subsystem
+	if subsystem == consts.LoggerApp {
+		name = ""
+	}
+	l := g.Log(name)
+	if !s.initialized[subsystem] {
+		if name != "" {
+			l.SetPrefix(name)
+		}
+		l.AppendCtxKeys(consts.CtxKeyCmdId)
+		s.initialized[subsystem] = true
+	}
+	return l
+}
+
+// Levels 返回全部子系统的当前日志级别
+func (s *sLogging) Levels() []model.LogLevel {
+	levels := make([]model.LogLevel, 0, len(logSubsystems))
+	for _, subsystem := range logSubsystems {
+		levels = append(levels, model.LogLevel{
+			Subsystem: subsystem,
+			Level:     s.Level(subsystem),
+		})
+	}
+	return levels
+}
+
+// Level 返回子系统的当前日志级别
+func (s *sLogging) Level(subsystem string) string {
+	return s.levelName(s.Logger(subsystem).GetLevel())
+}
+
+// SetLevel 修改子系统日志级别，立即生效，低于该级别的日志不再输出
+func (s *sLogging) SetLevel(subsystem, level string) error {
+	if err := s.CheckLevel(subsystem, level); err != nil {
+		return err
+	}
+	return s.Logger(subsystem).SetLevelStr(strings.ToLower(level))
+}
+
+// CheckLevel 校验子系统和级别名
+func (s *sLogging) CheckLevel(subsystem, level string) error {
+	known := false
+	for _, name := range logSubsystems {
+		known = known || name == subsystem
+	}
+	if !known {
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "unknown log subsystem: %s", subsystem)
+	}
+	for _, item := range logLevels {
+		if item.name == strings.ToLower(level) {
+			return nil
+		}
+	}
+	return gerror.NewCodef(gcode.CodeInvalidParameter, "invalid log level: %s", level)
+}
+
+// levelName 返回级别位中最低的级别名
+func (s *sLogging) levelName(level int) string {
+	for _, item := range logLevels {
+		if level&item.level != 0 {
+			return item.name
+		}
+	}
+	return "critical"
+}
+
+// Middleware 在响应头中返回请求的 Trace-Id，便于按 traceId 检索日志
+func (s *sLogging) Middleware(r *ghttp.Request) {
+	if traceId := gctx.CtxId(r.Context()); traceId != "" {
+		r.Response.Header().Set("Trace-Id", traceId)
+	}
+	r.Middleware.Next()
+}
+
+// CommandContext 为命令创建上下文：traceId 由 cmdId 确定性生成，cmdId 记录在日志中，
+// 同一命令的接收、下载、安装和回复日志可以按 traceId 或 cmdId 关联
+func (s *sLogging) CommandContext(cmdId string) context.Context {
+	if cmdId == "" {
+		return gctx.New()
+	}
+	ctx := context.WithValue(context.Background(), consts.CtxKeyCmdId, cmdId)
+	if traced, err := Tracing().remoteSpanContext(ctx, s.traceId(cmdId)); err == nil {
+		return traced
+	}
+	return gctx.WithCtx(ctx)
+}
+
+// traceId cmdId 本身是 32 位十六进制(如去掉横线的 UUID)时直接使用，否则取其 MD5
+func (s *sLogging) traceId(cmdId string) string {
+	id := strings.ToLower(strings.ReplaceAll(cmdId, "-", ""))
+	if len(id) == 32 {
+		if _, err := hex.DecodeString(id); err == nil && strings.Trim(id, "0") != "" {
+			return id
+		}
+	}
+	sum := md5.Sum([]byte(cmdId))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+)
+
+// 指标名前缀
+const metricsNamespace = "i800"
+
+// sMetrics Prometheus 指标服务，各模块通过 Observe/Inc 方法上报，/metrics 以文本格式导出
+type sMetrics struct {
+	registry *prometheus.Registry
+
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	mqttConnected    prometheus.Gauge
+	mqttReconnects   prometheus.Counter
+	mqttMessages     *prometheus.CounterVec
+	downloadBytes    prometheus.Counter
+	downloadDuration prometheus.Histogram
+	downloadFailures prometheus.Counter
+	dbDuration       *prometheus.HistogramVec
+}
+
+var (
+	metricsService *sMetrics
+	metricsOnce    sync.Once
+)
+
+// Metrics 获取指标服务单例
+func Metrics() *sMetrics {
+	metricsOnce.Do(func() {
+		metricsService = newMetrics()
+	})
+	return metricsService
+}
+
+func newMetrics() *sMetrics {
+	s := &sMetrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		mqttConnected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "mqtt_connected",
+			Help:      "Whether the MQTT client is connected to the broker.",
+		}),
+		mqttReconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "mqtt_reconnects_total",
+			Help:      "Successful MQTT reconnections after a lost connection.",
+		}),
+		mqttMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "mqtt_messages_total",
+			Help:      "MQTT messages by direction (in/out) and topic.",
+		}, []string{"direction", "topic"}),
+		downloadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "download_bytes_total",
+			Help:      "Bytes received while downloading algorithm packages.",
+		}),
+		downloadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "download_duration_seconds",
+			Help:      "Algorithm package download duration, including verification.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		}),
+		downloadFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "download_failures_total",
+			Help:      "Failed algorithm package downloads.",
+		}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "SQLite statement duration by operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9),
+		}, []string{"operation"}),
+	}
+	installed := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "algorithms_installed",
+		Help:      "Algorithms installed on the device.",
+	}, s.installedAlgorithms)
+
+	s.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		s.httpRequests, s.httpDuration,
+		s.mqttConnected, s.mqttReconnects, s.mqttMessages,
+		s.downloadBytes, s.downloadDuration, s.downloadFailures,
+		s.dbDuration, installed,
+	)
+	return s
+}
+
+// Handler 返回 Prometheus 文本格式的指标导出处理函数
+func (s *sMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware 统计 HTTP 请求数和耗时，按注册的路由而不是实际路径区分，避免标签基数过大
+func (s *sMetrics) Middleware(r *ghttp.Request) {
+	started := time.Now()
+	r.Middleware.Next()
+
+	route := r.URL.Path
+	if r.Router != nil {
+		route = r.Router.Uri
+	}
+	s.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(r.Response.Status)).Inc()
+	s.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(started).Seconds())
+}
+
+// SetMqttConnected 更新 MQTT 连接状态
+func (s *sMetrics) SetMqttConnected(connected bool) {
+	if connected {
+		s.mqttConnected.Set(1)
+	} else {
+		s.mqttConnected.Set(0)
+	}
+}
+
+// IncMqttReconnect 记录一次 MQTT 重连
+func (s *sMetrics) IncMqttReconnect() {
+	s.mqttReconnects.Inc()
+}
+
+// IncMqttMessage 记录一条收到(in)或发出(out)的 MQTT 消息
+func (s *sMetrics) IncMqttMessage(direction, topic string) {
+	s.mqttMessages.WithLabelValues(direction, topic).Inc()
+}
+
+// ObserveDownload 记录一次算法包下载，err 不为空时计为失败
+func (s *sMetrics) ObserveDownload(bytes int64, duration time.Duration, err error) {
+	s.downloadBytes.Add(float64(bytes))
+	s.downloadDuration.Observe(duration.Seconds())
+	if err != nil {
+		s.downloadFailures.Inc()
+	}
+}
+
+// ObserveDb 记录一次数据库语句耗时
+func (s *sMetrics) ObserveDb(operation string, duration time.Duration) {
+	s.dbDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// installedAlgorithms 采集时查询已安装算法数量
+func (s *sMetrics) installedAlgorithms() float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	columns := dao.Algorithm.Columns()
+	count, err := dao.Algorithm.Ctx(ctx).WhereNot(columns.LocalPath, "").Count()
+	if err != nil {
+		logger(consts.LoggerApp).Warningf(ctx, "Count installed algorithms failed: %v", err)
+		return 0
+	}
+	return float64(count)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/contrib/drivers/sqlite/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// 用带耗时统计的驱动替换 sqlite 驱动，所有经过 gdb 的语句都会上报到 db_query_duration_seconds
+func init() {
+	if err := gdb.Register("sqlite", &metricsDriver{Driver: sqlite.New()}); err != nil {
+		panic(err)
+	}
+}
+
+// metricsDriver 包装数据库驱动，创建的 DB 对象统计语句耗时
+type metricsDriver struct {
+	gdb.Driver
+}
+
+// New 创建包装后的 DB 对象
+func (d *metricsDriver) New(core *gdb.Core, node *gdb.ConfigNode) (gdb.DB, error) {
+	db, err := d.Driver.New(core, node)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsDB{DB: db}, nil
+}
+
+// metricsDB 统计 DoQuery/DoExec 耗时，其余方法交给被包装的 DB
+type metricsDB struct {
+	gdb.DB
+}
+
+// DoQuery 执行查询语句并记录耗时
+func (d *metricsDB) DoQuery(ctx context.Context, link gdb.Link, query string, args ...any) (gdb.Result, error) {
+	started := time.Now()
+	defer func() { Metrics().ObserveDb(sqlOperation(query), time.Since(started)) }()
+	return d.DB.DoQuery(ctx, link, query, args...)
+}
+
+// DoExec 执行写入语句并记录耗时
+func (d *metricsDB) DoExec(ctx context.Context, link gdb.Link, query string, args ...any) (sql.Result, error) {
+	started := time.Now()
+	defer func() { Metrics().ObserveDb(sqlOperation(query), time.Since(started)) }()
+	return d.DB.DoExec(ctx, link, query, args...)
+}
+
+// sqlOperation 取语句的首个关键字作为指标标签，只保留常见操作
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "replace", "create", "alter", "pragma":
+		return op
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gctx"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model/entity"
+)
+
+// MirrorPathPrefix 算法包镜像接口路径，完整路径为 /mirror/{algo}/{hex}
+const MirrorPathPrefix = "/mirror"
+
+// sMirror 站点内算法包镜像。mirror.enabled 为 true 时按摘要提供本机已校验的算法包，
+// 下载算法包时先依次尝试 mirror.peers 中的设备和发现的镜像设备，都失败时再从 algorithmDataUrl 下载，
+// 减少同一站点多台设备重复占用上行带宽。下载结果同样按摘要校验，不信任镜像内容
+type sMirror struct {
+	enabled  bool
+	peers    []string // 镜像设备地址，如 http://192.168.1.12:8000
+	maxPeers int      // 每次下载最多尝试的镜像设备数
+}
+
+var (
+	mirrorService *sMirror
+	mirrorOnce    sync.Once
+)
+
+// Mirror 获取算法包镜像服务单例
+func Mirror() *sMirror {
+	mirrorOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		cfg := g.Cfg()
+		mirrorService = &sMirror{
+			enabled:  cfg.MustGet(ctx, "mirror.enabled").Bool(),
+			maxPeers: cfg.MustGet(ctx, "mirror.maxPeers", 3).Int(),
+		}
+		for _, peer := range cfg.MustGet(ctx, "mirror.peers").Strings() {
+			if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer != "" {
+				mirrorService.peers = append(mirrorService.peers, peer)
+			}
+		}
+	})
+	return mirrorService
+}
+
+// Enabled 是否向其他设备提供算法包
+func (s *sMirror) Enabled() bool {
+	return s.enabled
+}
+
+// Urls 返回各镜像设备上指定摘要算法包的下载地址，先配置的设备，再通过 mDNS 发现的提供镜像的设备，
+// 最多 mirror.maxPeers 个
+func (s *sMirror) Urls(digest Digest) []string {
+	peers := append([]string(nil), s.peers...)
+	if Discovery().Enabled() {
+		for _, peer := range Discovery().Peers() {
+			if peer.Mirror && !slices.Contains(peers, peer.Url) {
+				peers = append(peers, peer.Url)
+			}
+		}
+	}
+	if len(peers) > s.maxPeers {
+		peers = peers[:max(s.maxPeers, 0)]
+	}
+	urls := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		urls = append(urls, fmt.Sprintf("%s%s/%s/%s", peer, MirrorPathPrefix, digest.Algo, digest.Hex))
+	}
+	return urls
+}
+
+// Lookup 按摘要查找本机已安装的算法包文件
+func (s *sMirror) Lookup(ctx context.Context, digest Digest) (string, error) {
+	var (
+		algorithm *entity.Algorithm
+		columns   = dao.Algorithm.Columns()
+		m         = dao.Algorithm.Ctx(ctx).WhereNot(columns.LocalPath, "")
+	)
+	match := m.Builder().Where(columns.DigestAlgo, digest.Algo).Where(columns.Digest, digest.Hex)
+	if digest.Algo == DigestMd5 {
+		match = match.WhereOr(columns.Md5, digest.Hex)
+	}
+	if err := m.Where(match).Scan(&algorithm); err != nil {
+		return "", err
+	}
+	if algorithm == nil {
+		return "", gerror.NewCodef(gcode.CodeNotFound, "package %s not found", digest)
+	}
+	packagePath := filepath.Join(algorithm.LocalPath, algorithmPackageFile)
+	if _, err := os.Stat(packagePath); err != nil {
+		return "", gerror.WrapCodef(gcode.CodeNotFound, err, "package %s not found", digest)
+	}
+	return packagePath, nil
+}
+
+// Serve 处理 GET /mirror/{algo}/{hex}，返回摘要匹配的算法包
+func (s *sMirror) Serve(r *ghttp.Request) {
+	if !s.enabled {
+		r.Response.WriteStatus(404)
+		return
+	}
+	digest, err := NewDigest(r.Get("algo").String(), r.Get("hex").String())
+	if err != nil {
+		r.Response.WriteStatus(400, err.Error())
+		return
+	}
+	packagePath, err := s.Lookup(r.Context(), digest)
+	if err != nil {
+		if gerror.Code(err) == gcode.CodeNotFound {
+			r.Response.WriteStatus(404)
+		} else {
+			r.Response.WriteStatus(500, err.Error())
+		}
+		return
+	}
+	logger(consts.LoggerDownload).Infof(r.Context(), "Serving package %s to %s", digest, r.GetClientIp())
+	r.Response.Header().Set("Digest", digest.String())
+	r.Response.ServeFile(packagePath)
+}
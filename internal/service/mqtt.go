@@ -1,91 +1,207 @@
 package service
 
 import (
+	"context"
+	"demo/internal/consts"
+	"demo/internal/model"
 	"demo/internal/model/entity"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
-	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/os/gctx"
 )
 
+// MQTT 协议版本，mqtt.version 配置取值
+const (
+	MqttVersion3 = 3 // MQTT v3.1.1，eclipse/paho.mqtt.golang
+	MqttVersion5 = 5 // MQTT v5，eclipse/paho.golang
+)
+
+// IMqtt MQTT 客户端接口，v3.1.1 和 v5 两种实现，按 mqtt.version 选择
+type IMqtt interface {
+	// Publish 发布消息，v5 实现附带默认的消息过期时间
+	Publish(topic string, qos byte, retained bool, payload interface{}) error
+	// PublishMessage 发布带 v5 属性的消息，v3.1.1 实现忽略这些属性
+	PublishMessage(msg *model.MqttMessage) error
+	// Subscribe 订阅主题，断线重连后自动恢复订阅
+	Subscribe(topic string, qos byte, callback MqttHandler) error
+	// GetMessages 获取接收到的消息列表
+	GetMessages(topic string, limit int) []entity.MqttMessage
+	// GetStatus 获取连接状态
+	GetStatus() map[string]interface{}
+	// IsConnected 检查当前是否与 broker 保持连接
+	IsConnected() bool
+}
+
+// MqttHandler 订阅消息回调
+type MqttHandler func(msg *model.MqttMessage)
+
 // 定义我们的 MQTT 服务结构体
 type sMqtt struct {
-	client   mqtt.Client          // Paho MQTT 客户端实例
-	messages []entity.MqttMessage // 内存中存储的消息（实际项目中应该用数据库）
-	msgMutex sync.RWMutex         // 消息操作的读写锁
+	client        mqtt.Client                 // Paho MQTT 客户端实例
+	messages      []entity.MqttMessage        // 内存中存储的消息（实际项目中应该用数据库）
+	msgMutex      sync.RWMutex                // 消息操作的读写锁
+	subscriptions map[string]mqttSubscription // 已订阅的主题，断线重连后重新订阅
+	subMutex      sync.Mutex                  // 订阅表的互斥锁
+	timeout       time.Duration               // 首次连接、发布和订阅等待确认的超时
+}
+
+// mqttSubscription 订阅信息
+type mqttSubscription struct {
+	qos      byte
+	callback mqtt.MessageHandler
 }
 
 var (
-	mqttService *sMqtt    // 用于存储单例的服务实例
+	mqttService IMqtt     // 用于存储单例的服务实例
 	mqttOnce    sync.Once // 保证单例只被创建一次
+	// mqttRetryInterval 首次连接失败后重试的间隔，v3 和 v5 实现相同
+	mqttRetryInterval = 10 * time.Second
 )
 
-// Mqtt 是获取 MQTT 服务单例的函数
-func Mqtt() *sMqtt {
+// Mqtt 获取 MQTT 服务单例，mqtt.version 为 5 时使用 MQTT v5 客户端
+func Mqtt() IMqtt {
 	mqttOnce.Do(func() {
-		// --- MQTT 客户端配置 ---
-		// 使用公共的 EMQ X 测试服务器，你也可以换成自己的
-		broker := "tcp://broker.emqx.io:1883"
-		clientId := "goframe-mqtt-client-example"
-
-		opts := mqtt.NewClientOptions()
-		opts.AddBroker(broker)
-		opts.SetClientID(clientId)
-		opts.SetKeepAlive(60 * time.Second)
-		// 设置一个默认的消息处理回调函数
-		opts.SetDefaultPublishHandler(func(client mqtt.Client, msg mqtt.Message) {
-			g.Log().Infof(gctx.New(), "MQTT Received Topic: %s, Payload: %s\n", msg.Topic(), msg.Payload())
-			// 将接收到的消息存储到内存中
-			if mqttService != nil {
-				mqttService.storeMessage(msg)
-			}
-		})
-		// 设置连接成功的回调
-		opts.OnConnect = func(client mqtt.Client) {
-			g.Log().Info(gctx.New(), "MQTT Connected")
-		}
-		// 设置连接丢失的回调
-		opts.OnConnectionLost = func(client mqtt.Client, err error) {
-			g.Log().Errorf(gctx.New(), "MQTT Connection Lost: %v", err)
+		ctx := gctx.GetInitCtx()
+		if Config().Get().Mqtt.Version == MqttVersion5 {
+			mqttService = newMqttV5(ctx)
+		} else {
+			mqttService = newMqttV3(ctx)
 		}
+	})
+	return mqttService
+}
 
-		// 创建客户端实例
-		client := mqtt.NewClient(opts)
-		if token := client.Connect(); token.Wait() && token.Error() != nil {
-			// 在实际项目中，这里应该处理失败，比如 panic 或重试
-			g.Log().Fatalf(gctx.New(), "MQTT Connect Error: %s", token.Error())
-		}
+// newMqttV3 创建 MQTT v3.1.1 客户端，连接在后台建立并自动重连
+func newMqttV3(ctx context.Context) *sMqtt {
+	// --- MQTT 客户端配置 ---
+	// 默认使用公共的 EMQ X 测试服务器，可通过 mqtt.broker 配置
+	config := Config().Get().Mqtt
+	// 回调可能在连接过程中触发，先创建服务实例供回调使用
+	service := &sMqtt{
+		messages:      make([]entity.MqttMessage, 0),
+		subscriptions: make(map[string]mqttSubscription),
+		timeout:       config.Timeout,
+	}
+	clientId := config.ClientId
+	if clientId == "" {
+		clientId = Device().Id()
+	}
 
-		mqttService = &sMqtt{
-			client:   client,
-			messages: make([]entity.MqttMessage, 0),
-		}
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(config.Broker)
+	opts.SetClientID(clientId)
+	opts.SetUsername(config.Username)
+	opts.SetPassword(config.Password)
+	opts.SetKeepAlive(60 * time.Second)
+	opts.SetConnectTimeout(config.Timeout)
+	// 首次连接失败时在后台重试，与 v5 实现一致，broker 不可用时服务仍可启动
+	opts.SetConnectRetry(true)
+	opts.SetConnectRetryInterval(mqttRetryInterval)
+	opts.SetAutoReconnect(true)
+	// 连接成功次数，首次之后的连接计为重连
+	var connects int32
+	// 设置一个默认的消息处理回调函数
+	opts.SetDefaultPublishHandler(func(client mqtt.Client, msg mqtt.Message) {
+		logger(consts.LoggerMqtt).Infof(gctx.New(), "MQTT Received Topic: %s, Payload: %s", msg.Topic(), msg.Payload())
+		Metrics().IncMqttMessage("in", msg.Topic())
+		// 将接收到的消息存储到内存中
+		service.storeMessage(msg)
 	})
-	return mqttService
+	// 设置连接成功的回调
+	opts.OnConnect = func(client mqtt.Client) {
+		logger(consts.LoggerMqtt).Info(gctx.New(), "MQTT Connected")
+		Metrics().SetMqttConnected(true)
+		if atomic.AddInt32(&connects, 1) > 1 {
+			Metrics().IncMqttReconnect()
+		}
+		// 重连后恢复订阅
+		service.resubscribe()
+	}
+	// 设置连接丢失的回调
+	opts.OnConnectionLost = func(client mqtt.Client, err error) {
+		logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT Connection Lost: %v", err)
+		Metrics().SetMqttConnected(false)
+	}
+
+	// 创建客户端实例
+	service.client = mqtt.NewClient(opts)
+	// 等待首次连接，失败时继续在后台重试
+	token := service.client.Connect()
+	if !token.WaitTimeout(config.Timeout) {
+		logger(consts.LoggerMqtt).Warningf(ctx, "MQTT not connected yet, retrying in background: %s", config.Broker)
+	} else if token.Error() != nil {
+		logger(consts.LoggerMqtt).Warningf(ctx, "MQTT not connected yet, retrying in background: %v", token.Error())
+	}
+	return service
 }
 
 // Publish 方法用于发布消息
 func (s *sMqtt) Publish(topic string, qos byte, retained bool, payload interface{}) error {
 	token := s.client.Publish(topic, qos, retained, payload)
-	// Wait 会等待消息发送完成
-	if token.Wait() && token.Error() != nil {
+	// 连接建立前发布的消息暂存到连接后发送，最多等待 mqtt.timeout
+	if !token.WaitTimeout(s.timeout) {
+		return gerror.Newf("publish %s timeout", topic)
+	}
+	if token.Error() != nil {
 		return token.Error()
 	}
+	Metrics().IncMqttMessage("out", topic)
 	return nil
 }
 
+// PublishMessage 发布消息，v3.1.1 不支持 ResponseTopic、过期时间等属性，只发布主题和内容
+func (s *sMqtt) PublishMessage(msg *model.MqttMessage) error {
+	return s.Publish(msg.Topic, msg.Qos, msg.Retained, msg.Payload)
+}
+
 // Subscribe 方法用于订阅主题
-func (s *sMqtt) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) error {
+func (s *sMqtt) Subscribe(topic string, qos byte, handler MqttHandler) error {
+	callback := func(client mqtt.Client, msg mqtt.Message) {
+		Metrics().IncMqttMessage("in", msg.Topic())
+		handler(&model.MqttMessage{
+			Topic:    msg.Topic(),
+			Payload:  msg.Payload(),
+			Qos:      msg.Qos(),
+			Retained: msg.Retained(),
+		})
+	}
+	// 先记录订阅，连接建立前订阅的主题在连接成功后由 resubscribe 发送
+	s.subMutex.Lock()
+	s.subscriptions[topic] = mqttSubscription{qos: qos, callback: callback}
+	s.subMutex.Unlock()
+	if !s.client.IsConnectionOpen() {
+		logger(consts.LoggerMqtt).Infof(gctx.New(), "MQTT not connected, subscribe %s after connect", topic)
+		return nil
+	}
 	token := s.client.Subscribe(topic, qos, callback)
-	if token.Wait() && token.Error() != nil {
+	if !token.WaitTimeout(s.timeout) {
+		return gerror.Newf("subscribe %s timeout", topic)
+	}
+	if token.Error() != nil {
 		return token.Error()
 	}
-	g.Log().Infof(gctx.New(), "Subscribed to topic: %s", topic)
+	logger(consts.LoggerMqtt).Infof(gctx.New(), "Subscribed to topic: %s", topic)
 	return nil
 }
 
+// resubscribe 重新订阅所有已记录的主题
+func (s *sMqtt) resubscribe() {
+	s.subMutex.Lock()
+	defer s.subMutex.Unlock()
+	for topic, sub := range s.subscriptions {
+		token := s.client.Subscribe(topic, sub.qos, sub.callback)
+		if !token.WaitTimeout(s.timeout) {
+			logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT Resubscribe %s timeout", topic)
+		} else if token.Error() != nil {
+			logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT Resubscribe %s Error: %v", topic, token.Error())
+		}
+	}
+}
+
 // storeMessage 存储接收到的消息到内存中（实际项目中应该存储到数据库）
 func (s *sMqtt) storeMessage(msg mqtt.Message) {
 	s.msgMutex.Lock()
@@ -141,13 +257,15 @@ func (s *sMqtt) GetMessages(topic string, limit int) []entity.MqttMessage {
 func (s *sMqtt) GetStatus() map[string]interface{} {
 	opts := s.client.OptionsReader()
 	return map[string]interface{}{
-		"connected": s.client.IsConnected(),
+		"connected": s.client.IsConnectionOpen(),
 		"client_id": opts.ClientID(),
 		"servers":   opts.Servers(),
+		"version":   MqttVersion3,
 	}
 }
 
-// IsConnected 检查MQTT是否连接
+// IsConnected 检查MQTT是否连接。
+// paho 的 IsConnected 在自动重连期间也返回 true，这里使用 IsConnectionOpen 反映实际连接状态
 func (s *sMqtt) IsConnected() bool {
-	return s.client.IsConnected()
+	return s.client.IsConnectionOpen()
 }
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+
+	"demo/internal/model"
+)
+
+// useTestRetryInterval 缩短首次连接的重试间隔
+func useTestRetryInterval(t *testing.T) {
+	interval := mqttRetryInterval
+	mqttRetryInterval = 100 * time.Millisecond
+	t.Cleanup(func() { mqttRetryInterval = interval })
+}
+
+// TestMqttV3ConnectRetry broker 不可用时客户端仍创建成功，broker 启动后在后台连上并恢复之前的订阅
+func TestMqttV3ConnectRetry(t *testing.T) {
+	address := freeAddress(t)
+	loadTestConfig(t, g.Map{"mqtt": g.Map{
+		"broker":   "tcp://" + address,
+		"clientId": "test-v3",
+		"timeout":  "300ms",
+	}})
+	useTestRetryInterval(t)
+
+	client := newMqttV3(context.Background())
+	t.Cleanup(func() { client.client.Disconnect(100) })
+	if client.IsConnected() {
+		t.Fatal("connected without a broker")
+	}
+	received := make(chan *model.MqttMessage, 10)
+	if err := client.Subscribe("test/v3/command", 1, func(msg *model.MqttMessage) {
+		received <- msg
+	}); err != nil {
+		t.Fatalf("subscribe before connect: %v", err)
+	}
+	if err := client.Publish("test/v3/reply", 1, false, "lost"); err == nil {
+		t.Fatal("publish without a broker should time out")
+	}
+
+	broker := startTestBroker(t, address)
+	waitFor(t, 5*time.Second, "mqtt v3 connect", client.IsConnected)
+
+	// 订阅在连接回调中异步恢复，重复发布直到收到
+	deadline := time.After(5 * time.Second)
+	for got := false; !got; {
+		if err := broker.Publish("test/v3/command", []byte("ping"), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-received:
+			if string(msg.Payload) != "ping" || msg.Topic != "test/v3/command" {
+				t.Fatalf("unexpected message %s: %s", msg.Topic, msg.Payload)
+			}
+			got = true
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("subscription not restored after connect")
+		}
+	}
+
+	replies := make(chan packets.Packet, 1)
+	if err := broker.Subscribe("test/v3/reply", 1, func(cl *mqttserver.Client, sub packets.Subscription, pk packets.Packet) {
+		replies <- pk
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Publish("test/v3/reply", 1, false, "pong"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	select {
+	case pk := <-replies:
+		if string(pk.Payload) != "pong" {
+			t.Fatalf("unexpected reply %s", pk.Payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reply not received by broker")
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+	"demo/internal/model/entity"
+)
+
+// sMqttV5 MQTT v5 客户端，支持请求/响应(ResponseTopic、CorrelationData)、消息过期、
+// 用户属性和原因码。会话在断线后保留 mqtt.sessionExpiry，期间下发的 QoS1 命令由 broker 暂存，
+// 云端为命令设置 MessageExpiry 后，过期未投递的命令由 broker 丢弃而不会在设备上线后执行
+type sMqttV5 struct {
+	cm            *autopaho.ConnectionManager
+	router        *paho.StandardRouter
+	clientId      string
+	servers       []string
+	timeout       time.Duration // 发布和订阅等待确认的超时
+	messageExpiry uint32        // 发出消息的默认过期秒数
+	connected     atomic.Bool
+	subscriptions map[string]byte // 已订阅的主题和 QoS，重连后重新订阅
+	subMutex      sync.Mutex
+}
+
+// newMqttV5 创建 MQTT v5 客户端，连接在后台建立并自动重连
+func newMqttV5(ctx context.Context) *sMqttV5 {
+	cfg := Config().Get().Mqtt
+	s := &sMqttV5{
+		router:        paho.NewStandardRouter(),
+		clientId:      cfg.ClientId,
+		timeout:       cfg.Timeout,
+		messageExpiry: uint32(cfg.MessageExpiry.Seconds()),
+		subscriptions: make(map[string]byte),
+	}
+	if s.clientId == "" {
+		s.clientId = Device().Id()
+	}
+	broker := cfg.Broker
+	serverUrl, err := url.Parse(strings.Replace(broker, "tcp://", "mqtt://", 1))
+	if err != nil {
+		logger(consts.LoggerMqtt).Fatalf(ctx, "Invalid mqtt.broker %s: %v", broker, err)
+	}
+	s.servers = []string{broker}
+	s.router.DefaultHandler(func(p *paho.Publish) {
+		logger(consts.LoggerMqtt).Infof(gctx.New(), "MQTT Received Topic: %s, Payload: %s", p.Topic, p.Payload)
+		Metrics().IncMqttMessage("in", p.Topic)
+	})
+
+	// 连接成功次数，首次之后的连接计为重连
+	var connects int32
+	config := autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{serverUrl},
+		KeepAlive:                     60,
+		CleanStartOnInitialConnection: false,
+		SessionExpiryInterval:         uint32(cfg.SessionExpiry.Seconds()),
+		ConnectTimeout:                s.timeout,
+		ReconnectBackoff:              autopaho.NewConstantBackoff(mqttRetryInterval),
+		OnConnectionUp: func(cm *autopaho.ConnectionManager, connack *paho.Connack) {
+			s.connected.Store(true)
+			logger(consts.LoggerMqtt).Infof(gctx.New(), "MQTT v5 Connected, session present: %v", connack.SessionPresent)
+			Metrics().SetMqttConnected(true)
+			if atomic.AddInt32(&connects, 1) > 1 {
+				Metrics().IncMqttReconnect()
+			}
+			// 会话过期或首次连接失败时订阅可能不存在，重新订阅
+			s.resubscribe(cm)
+		},
+		OnConnectError: func(err error) {
+			logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT v5 Connect Error: %v", err)
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID:      s.clientId,
+			PacketTimeout: s.timeout,
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+				func(pr paho.PublishReceived) (bool, error) {
+					s.router.Route(pr.Packet.Packet())
+					return true, nil
+				},
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				s.connectionLost(gerror.Newf("server disconnect, reason code 0x%02x", d.ReasonCode))
+			},
+			OnClientError: func(err error) {
+				s.connectionLost(err)
+			},
+		},
+	}
+	if s.cm, err = autopaho.NewConnection(context.Background(), config); err != nil {
+		logger(consts.LoggerMqtt).Fatalf(ctx, "MQTT v5 Connect Error: %v", err)
+	}
+	// 等待首次连接，失败时继续在后台重试
+	awaitCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	if err = s.cm.AwaitConnection(awaitCtx); err != nil {
+		logger(consts.LoggerMqtt).Warningf(ctx, "MQTT v5 not connected yet, retrying in background: %v", err)
+	}
+	return s
+}
+
+// connectionLost 记录连接断开，autopaho 随后自动重连
+func (s *sMqttV5) connectionLost(err error) {
+	if s.connected.Swap(false) {
+		logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT Connection Lost: %v", err)
+		Metrics().SetMqttConnected(false)
+	}
+}
+
+// Publish 发布消息，附带 mqtt.messageExpiry 过期时间
+func (s *sMqttV5) Publish(topic string, qos byte, retained bool, payload interface{}) error {
+	return s.PublishMessage(&model.MqttMessage{
+		Topic:    topic,
+		Qos:      qos,
+		Retained: retained,
+		Payload:  gconv.Bytes(payload),
+	})
+}
+
+// PublishMessage 发布带 v5 属性的消息，QoS>0 时 broker 返回的失败原因码作为错误返回
+func (s *sMqttV5) PublishMessage(msg *model.MqttMessage) error {
+	expiry := msg.MessageExpiry
+	if expiry == 0 {
+		expiry = s.messageExpiry
+	}
+	properties := &paho.PublishProperties{
+		ResponseTopic:   msg.ResponseTopic,
+		CorrelationData: msg.CorrelationData,
+	}
+	if expiry > 0 {
+		properties.MessageExpiry = &expiry
+	}
+	for key, value := range msg.UserProperties {
+		properties.User.Add(key, value)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	resp, err := s.cm.Publish(ctx, &paho.Publish{
+		Topic:      msg.Topic,
+		QoS:        msg.Qos,
+		Retain:     msg.Retained,
+		Payload:    msg.Payload,
+		Properties: properties,
+	})
+	if err != nil {
+		if resp != nil {
+			return gerror.Wrapf(err, "publish %s failed, reason code 0x%02x", msg.Topic, resp.ReasonCode)
+		}
+		return gerror.Wrapf(err, "publish %s failed", msg.Topic)
+	}
+	Metrics().IncMqttMessage("out", msg.Topic)
+	return nil
+}
+
+// Subscribe 订阅主题，消息的 v5 属性传给回调
+func (s *sMqttV5) Subscribe(topic string, qos byte, handler MqttHandler) error {
+	s.router.RegisterHandler(topic, func(p *paho.Publish) {
+		Metrics().IncMqttMessage("in", p.Topic)
+		msg := &model.MqttMessage{
+			Topic:    p.Topic,
+			Payload:  p.Payload,
+			Qos:      p.QoS,
+			Retained: p.Retain,
+		}
+		if p.Properties != nil {
+			msg.ResponseTopic = p.Properties.ResponseTopic
+			msg.CorrelationData = p.Properties.CorrelationData
+			if p.Properties.MessageExpiry != nil {
+				msg.MessageExpiry = *p.Properties.MessageExpiry
+			}
+			if len(p.Properties.User) > 0 {
+				msg.UserProperties = make(map[string]string, len(p.Properties.User))
+				for _, property := range p.Properties.User {
+					msg.UserProperties[property.Key] = property.Value
+				}
+			}
+		}
+		handler(msg)
+	})
+	s.subMutex.Lock()
+	s.subscriptions[topic] = qos
+	s.subMutex.Unlock()
+	if err := s.subscribe(s.cm, topic, qos); err != nil {
+		return err
+	}
+	logger(consts.LoggerMqtt).Infof(gctx.New(), "Subscribed to topic: %s", topic)
+	return nil
+}
+
+// subscribe 向 broker 发送订阅，失败时返回 SUBACK 原因码
+func (s *sMqttV5) subscribe(cm *autopaho.ConnectionManager, topic string, qos byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	suback, err := cm.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		if suback != nil && len(suback.Reasons) > 0 {
+			return gerror.Wrapf(err, "subscribe %s failed, reason code 0x%02x", topic, suback.Reasons[0])
+		}
+		return gerror.Wrapf(err, "subscribe %s failed", topic)
+	}
+	return nil
+}
+
+// resubscribe 重新订阅所有已记录的主题
+func (s *sMqttV5) resubscribe(cm *autopaho.ConnectionManager) {
+	s.subMutex.Lock()
+	defer s.subMutex.Unlock()
+	for topic, qos := range s.subscriptions {
+		if err := s.subscribe(cm, topic, qos); err != nil {
+			logger(consts.LoggerMqtt).Errorf(gctx.New(), "MQTT Resubscribe %s Error: %v", topic, err)
+		}
+	}
+}
+
+// GetMessages 获取接收到的消息列表，与 v3 实现一致暂不存储
+func (s *sMqttV5) GetMessages(topic string, limit int) []entity.MqttMessage {
+	return []entity.MqttMessage{}
+}
+
+// GetStatus 获取MQTT连接状态
+func (s *sMqttV5) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"connected": s.IsConnected(),
+		"client_id": s.clientId,
+		"servers":   s.servers,
+		"version":   MqttVersion5,
+	}
+}
+
+// IsConnected 检查MQTT是否连接
+func (s *sMqttV5) IsConnected() bool {
+	return s.connected.Load()
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+
+	"demo/internal/model"
+)
+
+// TestMqttV5ConnectRetry broker 不可用时客户端仍创建成功，broker 启动后在后台连上，
+// 收发的消息带有 v5 属性
+func TestMqttV5ConnectRetry(t *testing.T) {
+	address := freeAddress(t)
+	loadTestConfig(t, g.Map{"mqtt": g.Map{
+		"broker":        "tcp://" + address,
+		"clientId":      "test-v5",
+		"timeout":       "300ms",
+		"messageExpiry": "1m",
+	}})
+	useTestRetryInterval(t)
+
+	client := newMqttV5(context.Background())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = client.cm.Disconnect(ctx)
+	})
+	if client.IsConnected() {
+		t.Fatal("connected without a broker")
+	}
+
+	broker := startTestBroker(t, address)
+	waitFor(t, 5*time.Second, "mqtt v5 connect", client.IsConnected)
+
+	received := make(chan *model.MqttMessage, 1)
+	if err := client.Subscribe("test/v5/command", 1, func(msg *model.MqttMessage) {
+		received <- msg
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := broker.Publish("test/v5/command", []byte("ping"), false, 1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if string(msg.Payload) != "ping" {
+			t.Fatalf("unexpected payload %s", msg.Payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command not received")
+	}
+
+	replies := make(chan packets.Packet, 1)
+	if err := broker.Subscribe("test/v5/reply", 1, func(cl *mqttserver.Client, sub packets.Subscription, pk packets.Packet) {
+		replies <- pk
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err := client.PublishMessage(&model.MqttMessage{
+		Topic:           "test/v5/reply",
+		Qos:             1,
+		Payload:         []byte("pong"),
+		ResponseTopic:   "test/v5/response",
+		CorrelationData: []byte("cmd-1"),
+		UserProperties:  map[string]string{"traceparent": "00-1"},
+	})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	select {
+	case pk := <-replies:
+		if string(pk.Payload) != "pong" || pk.Properties.ResponseTopic != "test/v5/response" ||
+			string(pk.Properties.CorrelationData) != "cmd-1" {
+			t.Fatalf("unexpected reply %s, properties %+v", pk.Payload, pk.Properties)
+		}
+		if pk.Properties.MessageExpiryInterval != 60 {
+			t.Fatalf("message expiry %d, want 60", pk.Properties.MessageExpiryInterval)
+		}
+		if len(pk.Properties.User) != 1 || pk.Properties.User[0].Key != "traceparent" {
+			t.Fatalf("unexpected user properties %+v", pk.Properties.User)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reply not received by broker")
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	_ "time/tzdata" // 设备镜像可能没有时区数据库
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/os/gtimer"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/do"
+	"demo/internal/model/entity"
+	"demo/utility"
+)
+
+// 合并重叠时间窗时的最大长度，避免 cron 触发间隔小于 duration 时无限顺延
+const maxScheduleWindow = 7 * 24 * time.Hour
+
+// 计划动作
+const (
+	ScheduleActionStart = "start"
+	ScheduleActionStop  = "stop"
+)
+
+// sSchedule 算法运行时间窗调度服务，定时评估每个算法是否处于时间窗内并启停算法。
+// 只在期望状态发生变化时执行动作，手动启停会保持到下一次时间窗切换。
+type sSchedule struct {
+	mu       sync.Mutex
+	interval time.Duration
+	desired  map[string]bool // algorithmId -> 上次评估的期望运行状态
+	timer    *gtimer.Entry
+}
+
+// scheduleWindow 时间窗在某一时刻的状态
+type scheduleWindow struct {
+	active    bool
+	nextStart time.Time
+	nextStop  time.Time
+}
+
+var (
+	scheduleService *sSchedule
+	scheduleOnce    sync.Once
+)
+
+// Schedule 获取调度服务单例
+func Schedule() *sSchedule {
+	scheduleOnce.Do(func() {
+		scheduleService = &sSchedule{
+			interval: g.Cfg().MustGet(context.Background(), "schedule.interval", "30s").Duration(),
+			desired:  make(map[string]bool),
+		}
+	})
+	return scheduleService
+}
+
+// Start 立即评估一次并开始定时评估
+func (s *sSchedule) Start(ctx context.Context) {
+	if s.timer != nil {
+		return
+	}
+	s.Evaluate(ctx)
+	s.timer = gtimer.AddSingleton(ctx, s.interval, func(ctx context.Context) {
+		s.Evaluate(ctx)
+	})
+}
+
+// Evaluate 评估所有启用的时间窗，期望状态变化时启动或停止算法
+func (s *sSchedule) Evaluate(ctx context.Context) {
+	schedules, err := s.List(ctx, "")
+	if err != nil {
+		logger(consts.LoggerSchedule).Errorf(ctx, "Query algorithm schedules failed: %v", err)
+		return
+	}
+	desired := make(map[string]bool)
+	for _, item := range schedules {
+		if item.Enabled == 0 {
+			continue
+		}
+		desired[item.AlgorithmId] = desired[item.AlgorithmId] || item.Active
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for algorithmId, run := range desired {
+		if last, ok := s.desired[algorithmId]; ok && last == run {
+			continue
+		}
+		if run {
+			err = Algorithm().Start(ctx, algorithmId)
+		} else {
+			err = Algorithm().Stop(ctx, algorithmId, 0)
+		}
+		if err != nil {
+			logger(consts.LoggerSchedule).Warningf(ctx, "Scheduled %s of algorithm %s failed: %v", s.action(run), algorithmId, err)
+			continue
+		}
+		logger(consts.LoggerSchedule).Infof(ctx, "Scheduled %s of algorithm %s", s.action(run), algorithmId)
+		s.desired[algorithmId] = run
+	}
+	for algorithmId := range s.desired {
+		if _, ok := desired[algorithmId]; !ok {
+			delete(s.desired, algorithmId)
+		}
+	}
+}
+
+// List 返回时间窗及其当前状态，algorithmId 为空时返回全部
+func (s *sSchedule) List(ctx context.Context, algorithmId string) ([]model.ScheduleItem, error) {
+	var (
+		schedules []entity.AlgorithmSchedule
+		columns   = dao.AlgorithmSchedule.Columns()
+		m         = dao.AlgorithmSchedule.Ctx(ctx)
+	)
+	if algorithmId != "" {
+		m = m.Where(columns.AlgorithmId, algorithmId)
+	}
+	if err := m.OrderAsc(columns.Id).Scan(&schedules); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	items := make([]model.ScheduleItem, 0, len(schedules))
+	for _, schedule := range schedules {
+		item := model.ScheduleItem{AlgorithmSchedule: schedule}
+		window, err := s.window(schedule, now)
+		if err != nil {
+			logger(consts.LoggerSchedule).Warningf(ctx, "Invalid schedule %d: %v", schedule.Id, err)
+		} else {
+			item.Active = window.active
+			item.NextStart = s.gtime(window.nextStart)
+			item.NextStop = s.gtime(window.nextStop)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Transitions 返回按时间排序的计划启停动作
+func (s *sSchedule) Transitions(ctx context.Context, algorithmId string) ([]model.ScheduleTransition, error) {
+	items, err := s.List(ctx, algorithmId)
+	if err != nil {
+		return nil, err
+	}
+	transitions := make([]model.ScheduleTransition, 0)
+	for _, item := range items {
+		if item.Enabled == 0 {
+			continue
+		}
+		if item.NextStart != nil && !item.Active {
+			transitions = append(transitions, model.ScheduleTransition{
+				AlgorithmId: item.AlgorithmId,
+				ScheduleId:  item.Id,
+				Action:      ScheduleActionStart,
+				At:          item.NextStart,
+			})
+		}
+		if item.NextStop != nil {
+			transitions = append(transitions, model.ScheduleTransition{
+				AlgorithmId: item.AlgorithmId,
+				ScheduleId:  item.Id,
+				Action:      ScheduleActionStop,
+				At:          item.NextStop,
+			})
+		}
+	}
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].At.Before(transitions[j].At)
+	})
+	return transitions, nil
+}
+
+// Create 为算法新增时间窗
+func (s *sSchedule) Create(ctx context.Context, algorithmId string, in model.ScheduleInput) (id int64, err error) {
+	if _, err = Algorithm().GetByAlgorithmId(ctx, algorithmId); err != nil {
+		return 0, err
+	}
+	data, err := s.data(algorithmId, in)
+	if err != nil {
+		return 0, err
+	}
+	id, err = dao.AlgorithmSchedule.Ctx(ctx).Data(data).InsertAndGetId()
+	if err != nil {
+		return 0, err
+	}
+	s.Evaluate(ctx)
+	return id, nil
+}
+
+// Update 修改时间窗
+func (s *sSchedule) Update(ctx context.Context, id int64, in model.ScheduleInput) error {
+	var schedule *entity.AlgorithmSchedule
+	if err := dao.AlgorithmSchedule.Ctx(ctx).WherePri(id).Scan(&schedule); err != nil {
+		return err
+	}
+	if schedule == nil {
+		return gerror.NewCodef(gcode.CodeNotFound, "schedule %d not found", id)
+	}
+	data, err := s.data(schedule.AlgorithmId, in)
+	if err != nil {
+		return err
+	}
+	if _, err = dao.AlgorithmSchedule.Ctx(ctx).Data(data).WherePri(id).Update(); err != nil {
+		return err
+	}
+	s.Evaluate(ctx)
+	return nil
+}
+
+// Delete 删除时间窗，算法保持当前运行状态
+func (s *sSchedule) Delete(ctx context.Context, id int64) error {
+	_, err := dao.AlgorithmSchedule.Ctx(ctx).WherePri(id).Delete()
+	return err
+}
+
+// Replace 用新的时间窗列表替换算法的全部时间窗，用于云端推送
+func (s *sSchedule) Replace(ctx context.Context, algorithmId string, list []model.ScheduleInput) error {
+	if _, err := Algorithm().GetByAlgorithmId(ctx, algorithmId); err != nil {
+		return err
+	}
+	data := make([]do.AlgorithmSchedule, 0, len(list))
+	for _, in := range list {
+		item, err := s.data(algorithmId, in)
+		if err != nil {
+			return err
+		}
+		data = append(data, item)
+	}
+	columns := dao.AlgorithmSchedule.Columns()
+	err := dao.AlgorithmSchedule.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Model(dao.AlgorithmSchedule.Table()).Ctx(ctx).Where(columns.AlgorithmId, algorithmId).Delete()
+		if err != nil || len(data) == 0 {
+			return err
+		}
+		_, err = tx.Model(dao.AlgorithmSchedule.Table()).Ctx(ctx).Data(data).Insert()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.Evaluate(ctx)
+	return nil
+}
+
+// data 校验时间窗参数并转换为数据库对象
+func (s *sSchedule) data(algorithmId string, in model.ScheduleInput) (do.AlgorithmSchedule, error) {
+	if in.Timezone == "" {
+		in.Timezone = time.Local.String()
+	}
+	schedule := entity.AlgorithmSchedule{
+		AlgorithmId: algorithmId,
+		Cron:        in.Cron,
+		Duration:    in.Duration,
+		Timezone:    in.Timezone,
+		Enabled:     1,
+	}
+	if in.Enabled != nil && !*in.Enabled {
+		schedule.Enabled = 0
+	}
+	if _, err := s.window(schedule, time.Now()); err != nil {
+		return do.AlgorithmSchedule{}, err
+	}
+	return do.AlgorithmSchedule{
+		AlgorithmId: schedule.AlgorithmId,
+		Cron:        schedule.Cron,
+		Duration:    schedule.Duration,
+		Timezone:    schedule.Timezone,
+		Enabled:     schedule.Enabled,
+	}, nil
+}
+
+// window 计算算法时间窗在 now 时刻的状态
+func (s *sSchedule) window(schedule entity.AlgorithmSchedule, now time.Time) (scheduleWindow, error) {
+	return cronWindow(schedule.Cron, schedule.Duration, schedule.Timezone, now)
+}
+
+// cronWindow 计算时间窗在 now 时刻的状态。时间窗从 cron 触发时刻开始，持续 duration，
+// 相邻时间窗重叠时合并。
+func cronWindow(expr, durationStr, timezone string, now time.Time) (w scheduleWindow, err error) {
+	cron, err := utility.ParseCron(expr)
+	if err != nil {
+		return w, err
+	}
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil || duration <= 0 {
+		return w, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid duration: %s", durationStr)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return w, gerror.WrapCodef(gcode.CodeInvalidParameter, err, "invalid timezone: %s", timezone)
+	}
+	now = now.In(loc)
+
+	// now 之前最近一次触发仍在持续中时时间窗有效
+	lastStart := cron.Prev(now)
+	if lastStart.IsZero() || !lastStart.After(now.Add(-duration)) {
+		w.nextStart = cron.Next(now)
+		if !w.nextStart.IsZero() {
+			w.nextStop = cronWindowEnd(cron, w.nextStart, duration)
+		}
+		return w, nil
+	}
+	w.active = true
+	w.nextStop = cronWindowEnd(cron, lastStart, duration)
+	w.nextStart = cron.Next(w.nextStop)
+	return w, nil
+}
+
+// cronWindowEnd 从 start 开始的时间窗结束时间，期间再次触发则顺延，最多顺延到 start 之后 maxScheduleWindow
+func cronWindowEnd(cron *utility.CronSchedule, start time.Time, duration time.Duration) time.Time {
+	end := start.Add(duration)
+	limit := start.Add(maxScheduleWindow)
+	for fire := cron.Next(start); !fire.IsZero() && !fire.After(end) && end.Before(limit); fire = cron.Next(fire) {
+		end = fire.Add(duration)
+	}
+	if end.After(limit) {
+		end = limit
+	}
+	return end
+}
+
+func (s *sSchedule) gtime(t time.Time) *gtime.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return gtime.New(t)
+}
+
+func (s *sSchedule) action(run bool) string {
+	if run {
+		return ScheduleActionStart
+	}
+	return ScheduleActionStop
+}
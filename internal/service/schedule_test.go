@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronWindow(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		expr      string
+		duration  string
+		timezone  string
+		now       time.Time
+		active    bool
+		nextStart time.Time
+		nextStop  time.Time
+	}{
+		{
+			name: "before window", expr: "0 22 * * *", duration: "8h", timezone: "UTC",
+			now: at(2, 21, 59), nextStart: at(2, 22, 0), nextStop: at(3, 6, 0),
+		},
+		{
+			name: "window start", expr: "0 22 * * *", duration: "8h", timezone: "UTC",
+			now: at(2, 22, 0), active: true, nextStart: at(3, 22, 0), nextStop: at(3, 6, 0),
+		},
+		{
+			name: "after midnight", expr: "0 22 * * *", duration: "8h", timezone: "UTC",
+			now: at(3, 5, 59), active: true, nextStart: at(3, 22, 0), nextStop: at(3, 6, 0),
+		},
+		{
+			name: "window end", expr: "0 22 * * *", duration: "8h", timezone: "UTC",
+			now: at(3, 6, 0), nextStart: at(3, 22, 0), nextStop: at(4, 6, 0),
+		},
+		{
+			name: "weekdays only", expr: "0 22 * * 1-5", duration: "8h", timezone: "UTC",
+			// 2026-03-07 是周六，周五晚上的时间窗到周六 06:00 结束
+			now: at(7, 5, 0), active: true, nextStart: at(9, 22, 0), nextStop: at(7, 6, 0),
+		},
+		{
+			// 触发间隔小于时长时合并，最多到 maxScheduleWindow
+			name: "overlapping fires", expr: "0 * * * *", duration: "90m", timezone: "UTC",
+			now: at(2, 10, 30), active: true, nextStart: at(9, 11, 0), nextStop: at(9, 10, 0),
+		},
+		{
+			name: "configured timezone", expr: "0 22 * * *", duration: "8h", timezone: "Asia/Shanghai",
+			// 北京时间 22:00 是 UTC 14:00
+			now: at(2, 15, 0), active: true, nextStart: at(3, 14, 0), nextStop: at(2, 22, 0),
+		},
+		{
+			name: "window over spring forward", expr: "0 22 * * *", duration: "8h", timezone: "America/New_York",
+			// 2026-03-07 22:00 EST 开始，跨过 03-08 的夏令时切换后按绝对时长在 07:00 EDT 结束
+			now: at(8, 10, 59), active: true, nextStart: at(9, 2, 0), nextStop: at(8, 11, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := cronWindow(tt.expr, tt.duration, tt.timezone, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.active != tt.active {
+				t.Fatalf("active = %v, want %v", w.active, tt.active)
+			}
+			if !w.nextStart.Equal(tt.nextStart) {
+				t.Fatalf("nextStart = %s, want %s", w.nextStart, tt.nextStart)
+			}
+			if !w.nextStop.Equal(tt.nextStop) {
+				t.Fatalf("nextStop = %s, want %s", w.nextStop, tt.nextStop)
+			}
+		})
+	}
+
+	invalid := []struct {
+		expr, duration, timezone string
+	}{
+		{"0 0 31 2 *", "1h", "UTC"},
+		{"0 22 * *", "1h", "UTC"},
+		{"0 22 * * *", "0s", "UTC"},
+		{"0 22 * * *", "-1h", "UTC"},
+		{"0 22 * * *", "1d", "UTC"},
+		{"0 22 * * *", "1h", "Mars/Olympus"},
+	}
+	for _, tt := range invalid {
+		if _, err := cronWindow(tt.expr, tt.duration, tt.timezone, time.Now()); err == nil {
+			t.Errorf("cronWindow(%q, %q, %q) should fail", tt.expr, tt.duration, tt.timezone)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/entity"
+)
+
+// sStorage 算法存储管理服务：下载前检查磁盘空间和配额，按保留策略清理旧版本
+type sStorage struct {
+	mu              sync.Mutex
+	expansionFactor float64        // 算法包解压后的空间放大系数，下载前按 FileSize×系数 预留空间
+	quota           int64          // 算法存储目录配额，0 表示不限制
+	reserve         int64          // 文件系统始终保留的空闲空间
+	keepVersions    int            // 每个算法除当前版本外保留的历史版本数
+	reserved        int64          // 进行中的安装预留的空间，尚未写入的部分同样不可被其他安装使用
+	installing      map[string]int // 进行中的安装的版本目录(绝对路径) -> 安装数，清理时跳过
+}
+
+var (
+	storageService *sStorage
+	storageOnce    sync.Once
+)
+
+// Storage 获取存储管理服务单例
+func Storage() *sStorage {
+	storageOnce.Do(func() {
+		config := Config().Get().Storage
+		storageService = &sStorage{
+			expansionFactor: config.ExpansionFactor,
+			quota:           gfile.StrToSize(config.Quota),
+			reserve:         gfile.StrToSize(config.Reserve),
+			keepVersions:    config.KeepVersions,
+			installing:      make(map[string]int),
+		}
+		if storageService.quota < 0 {
+			storageService.quota = 0
+		}
+		if storageService.reserve < 0 {
+			storageService.reserve = 0
+		}
+	})
+	return storageService
+}
+
+// Preflight 下载前检查空间并为安装预留：需要 fileSize×放大系数 的空间，
+// 扣除其他进行中安装的预留后，文件系统剩余空间不能低于保留值，存储目录占用不能超过配额。
+// 安装结束(成功或失败)后调用 release 释放预留，释放前 Prune 不清理 versionPath
+func (s *sStorage) Preflight(ctx context.Context, versionPath string, fileSize int64) (release func(), err error) {
+	required := int64(float64(fileSize) * s.expansionFactor)
+	storePath := Algorithm().StorePath()
+	if err = os.MkdirAll(storePath, 0o755); err != nil {
+		return nil, gerror.Wrapf(err, "create store %s failed", storePath)
+	}
+	dir, err := filepath.Abs(versionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, free, err := diskSpace(storePath)
+	if err != nil {
+		return nil, gerror.Wrapf(err, "stat filesystem of %s failed", storePath)
+	}
+	// 预留按完整大小计算，已写入的部分会同时计入已用空间，结果偏保守
+	if free >= 0 && free-s.reserved-required < s.reserve {
+		return nil, gerror.NewCodef(gcode.CodeOperationFailed,
+			"insufficient disk space: need %s plus %s reserve, %s available, %s reserved by other installs",
+			gfile.FormatSize(required), gfile.FormatSize(s.reserve), gfile.FormatSize(free), gfile.FormatSize(s.reserved))
+	}
+	if s.quota > 0 {
+		used := s.dirSize(storePath)
+		if used+s.reserved+required > s.quota {
+			return nil, gerror.NewCodef(gcode.CodeOperationFailed,
+				"algorithm store quota exceeded: %s used, %s reserved, %s required, quota %s",
+				gfile.FormatSize(used), gfile.FormatSize(s.reserved), gfile.FormatSize(required), gfile.FormatSize(s.quota))
+		}
+	}
+	s.reserved += required
+	s.installing[dir]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.reserved -= required
+			if s.installing[dir]--; s.installing[dir] <= 0 {
+				delete(s.installing, dir)
+			}
+		})
+	}, nil
+}
+
+// Summary 返回算法存储目录的空间概况
+func (s *sStorage) Summary(ctx context.Context) (*model.StorageSummary, error) {
+	storePath := Algorithm().StorePath()
+	summary := &model.StorageSummary{
+		Path:       storePath,
+		Free:       -1,
+		Quota:      s.quota,
+		Reserve:    s.reserve,
+		Algorithms: make([]model.StorageAlgorithm, 0),
+	}
+	if !gfile.Exists(storePath) {
+		return summary, nil
+	}
+	var err error
+	if summary.Total, summary.Free, err = diskSpace(storePath); err != nil {
+		return nil, gerror.Wrapf(err, "stat filesystem of %s failed", storePath)
+	}
+	installed, err := s.installed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, algorithmDir := range s.subDirs(storePath) {
+		algorithmId := filepath.Base(algorithmDir)
+		algorithm, ok := installed[algorithmId]
+		item := model.StorageAlgorithm{
+			AlgorithmId: algorithmId,
+			Installed:   ok,
+			Versions:    make([]model.StorageVersion, 0),
+		}
+		for _, versionDir := range s.subDirs(algorithmDir) {
+			version := model.StorageVersion{
+				AlgorithmVersionId: filepath.Base(versionDir),
+				Size:               s.dirSize(versionDir),
+				Active:             ok && s.samePath(versionDir, algorithm.LocalPath),
+			}
+			item.Size += version.Size
+			item.Versions = append(item.Versions, version)
+		}
+		summary.Used += item.Size
+		summary.Algorithms = append(summary.Algorithms, item)
+	}
+	return summary, nil
+}
+
+// Prune 清理算法存储：删除已不在算法表中的算法目录、残留的未完成下载，
+// 并为每个算法保留当前版本和最近的 keep 个历史版本。
+// algorithmId 不为空时只清理该算法，keep 小于 0 时使用 storage.keepVersions 配置。
+func (s *sStorage) Prune(ctx context.Context, algorithmId string, keep int) (*model.StoragePruneOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keep < 0 {
+		keep = s.keepVersions
+	}
+	out := &model.StoragePruneOutput{Removed: make([]string, 0)}
+	storePath := Algorithm().StorePath()
+	if !gfile.Exists(storePath) {
+		return out, nil
+	}
+	installed, err := s.installed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remove := func(path string) {
+		size := s.dirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			logger(consts.LoggerStorage).Warningf(ctx, "Remove %s failed: %v", path, err)
+			return
+		}
+		out.Removed = append(out.Removed, path)
+		out.FreedBytes += size
+	}
+
+	for _, algorithmDir := range s.subDirs(storePath) {
+		id := filepath.Base(algorithmDir)
+		if algorithmId != "" && id != algorithmId {
+			continue
+		}
+		algorithm, ok := installed[id]
+		if !ok {
+			// 首次安装的算法在安装完成前不在算法表中
+			if !s.isInstalling(algorithmDir) {
+				remove(algorithmDir)
+			}
+			continue
+		}
+		// 历史版本按修改时间从新到旧排序
+		var previous []string
+		for _, versionDir := range s.subDirs(algorithmDir) {
+			if s.samePath(versionDir, algorithm.LocalPath) || s.isInstalling(versionDir) {
+				continue
+			}
+			s.removePartial(versionDir, remove)
+			previous = append(previous, versionDir)
+		}
+		sort.Slice(previous, func(i, j int) bool {
+			return s.modTime(previous[i]).After(s.modTime(previous[j]))
+		})
+		for i, versionDir := range previous {
+			if i >= keep {
+				remove(versionDir)
+			}
+		}
+	}
+	if len(out.Removed) > 0 {
+		logger(consts.LoggerStorage).Infof(ctx, "Pruned algorithm store: %d paths removed, %s freed", len(out.Removed), gfile.FormatSize(out.FreedBytes))
+	}
+	return out, nil
+}
+
+// isInstalling 判断目录是否为进行中安装的版本目录或其上级目录，调用方持有锁
+func (s *sStorage) isInstalling(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for dir := range s.installing {
+		if dir == abs || strings.HasPrefix(dir, abs+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removePartial 删除版本目录中残留的未完成下载文件
+func (s *sStorage) removePartial(versionDir string, remove func(path string)) {
+	files, _ := gfile.ScanDirFile(versionDir, "*.part")
+	for _, file := range files {
+		remove(file)
+	}
+}
+
+// installed 返回算法表中的全部算法，按 algorithmId 索引
+func (s *sStorage) installed(ctx context.Context) (map[string]entity.Algorithm, error) {
+	var algorithms []entity.Algorithm
+	if err := dao.Algorithm.Ctx(ctx).Scan(&algorithms); err != nil {
+		return nil, err
+	}
+	installed := make(map[string]entity.Algorithm, len(algorithms))
+	for _, algorithm := range algorithms {
+		installed[algorithm.AlgorithmId] = algorithm
+	}
+	return installed, nil
+}
+
+// subDirs 返回目录下的直接子目录
+func (s *sStorage) subDirs(path string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(path, entry.Name()))
+		}
+	}
+	return dirs
+}
+
+// dirSize 递归统计目录占用字节数
+func (s *sStorage) dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+func (s *sStorage) modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (s *sStorage) samePath(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -0,0 +1,16 @@
+//go:build linux
+
+package service
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace 返回 path 所在文件系统的总空间和非特权用户可用空间
+func diskSpace(path string) (total, free int64, err error) {
+	var stat unix.Statfs_t
+	if err = unix.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return int64(stat.Blocks) * stat.Bsize, int64(stat.Bavail) * stat.Bsize, nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package service
+
+// diskSpace 非 Linux 平台无法获取文件系统空间，free 返回 -1 表示未知
+func diskSpace(path string) (total, free int64, err error) {
+	return 0, -1, nil
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/glog"
+	"github.com/gogf/gf/v2/os/gtime"
+
+	"demo/internal/consts"
+	"demo/internal/dao"
+	"demo/internal/model"
+	"demo/internal/model/entity"
+)
+
+// 进程稳定运行超过该时长后，重启退避时间重置为最小值
+const supervisorStableDuration = time.Minute
+
+// sSupervisor 算法进程守护服务，负责启动已安装算法的当前版本，
+// 采集标准输出到滚动日志，异常退出后按指数退避重启
+type sSupervisor struct {
+	mu          sync.Mutex
+	processes   map[string]*algorithmProcess // algorithmId -> 进程
+	logPath     string
+	logConfig   g.Map
+	limits      model.ProcessLimits
+	backoffMin  time.Duration
+	backoffMax  time.Duration
+	stopTimeout time.Duration
+}
+
+// algorithmProcess 单个受守护的算法进程
+type algorithmProcess struct {
+	mu         sync.RWMutex
+	status     model.AlgorithmRuntimeStatus
+	workDir    string
+	entrypoint string
+	args       []string
+	env        []string
+	logger     *glog.Logger
+	cmd        *exec.Cmd
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
+}
+
+var (
+	supervisorService *sSupervisor
+	supervisorOnce    sync.Once
+)
+
+// Supervisor 获取算法进程守护服务单例
+func Supervisor() *sSupervisor {
+	supervisorOnce.Do(func() {
+		config := Config().Get().Runtime
+		supervisorService = &sSupervisor{
+			processes: make(map[string]*algorithmProcess),
+			logPath:   config.LogPath,
+			logConfig: g.Map{
+				"rotateSize":        config.LogRotateSize,
+				"rotateBackupLimit": config.LogRotateBackupLimit,
+			},
+			limits:      config.Limits,
+			backoffMin:  config.BackoffMin,
+			backoffMax:  config.BackoffMax,
+			stopTimeout: config.StopTimeout,
+		}
+	})
+	return supervisorService
+}
+
+// StartAll 启动所有已安装且期望状态为运行的算法的当前版本
+func (s *sSupervisor) StartAll(ctx context.Context) {
+	var (
+		algorithms []entity.Algorithm
+		columns    = dao.Algorithm.Columns()
+	)
+	err := dao.Algorithm.Ctx(ctx).
+		WhereNot(columns.LocalPath, "").
+		Where(columns.RunState, consts.RunStateRunning).
+		Scan(&algorithms)
+	if err != nil {
+		logger(consts.LoggerSupervisor).Errorf(ctx, "Query installed algorithms failed: %v", err)
+		return
+	}
+	for _, algorithm := range algorithms {
+		if err = s.start(ctx, algorithm); err != nil {
+			logger(consts.LoggerSupervisor).Warningf(ctx, "Start algorithm %s failed: %v", algorithm.AlgorithmId, err)
+		}
+	}
+}
+
+// StopAll 停止所有算法进程，用于服务退出
+func (s *sSupervisor) StopAll(ctx context.Context) {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.processes))
+	for id := range s.processes {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.Stop(ctx, id, 0)
+	}
+}
+
+// Start 按算法表中的当前版本启动算法，已在运行时直接返回
+func (s *sSupervisor) Start(ctx context.Context, algorithmId string) error {
+	algorithm, err := Algorithm().GetByAlgorithmId(ctx, algorithmId)
+	if err != nil {
+		return err
+	}
+	return s.start(ctx, *algorithm)
+}
+
+// Reload 停止算法当前进程并按算法表中的当前版本重新启动，
+// 期望状态为停止的算法只停止不启动
+func (s *sSupervisor) Reload(ctx context.Context, algorithmId string) error {
+	algorithm, err := Algorithm().GetByAlgorithmId(ctx, algorithmId)
+	if err != nil {
+		return err
+	}
+	s.Stop(ctx, algorithmId, 0)
+	if algorithm.RunState == consts.RunStateStopped {
+		return nil
+	}
+	return s.start(ctx, *algorithm)
+}
+
+// Stop 优雅停止算法进程并停止守护：先发送 SIGTERM，超过 timeout 仍未退出则 SIGKILL。
+// timeout 不大于 0 时使用 runtime.stopTimeout 配置，进程未运行时直接返回。
+func (s *sSupervisor) Stop(ctx context.Context, algorithmId string, timeout time.Duration) {
+	s.mu.Lock()
+	p, ok := s.processes[algorithmId]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if timeout <= 0 {
+		timeout = s.stopTimeout
+	}
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+	if cmd := p.runningCmd(); cmd != nil {
+		terminateProcess(cmd)
+	}
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		logger(consts.LoggerSupervisor).Warningf(ctx, "Algorithm %s did not exit within %s, killing", algorithmId, timeout)
+		if cmd := p.runningCmd(); cmd != nil {
+			killProcess(cmd)
+		}
+		<-p.done
+	}
+	logger(consts.LoggerSupervisor).Infof(ctx, "Algorithm %s stopped", algorithmId)
+}
+
+// Remove 停止算法进程并移除其运行状态，用于删除算法
+func (s *sSupervisor) Remove(ctx context.Context, algorithmId string) {
+	s.Stop(ctx, algorithmId, 0)
+	s.mu.Lock()
+	delete(s.processes, algorithmId)
+	s.mu.Unlock()
+}
+
+// NotifyReload 通知算法进程重新加载配置，Linux 下向主进程发送 SIGHUP，进程未运行时忽略
+func (s *sSupervisor) NotifyReload(ctx context.Context, algorithmId string) {
+	s.mu.Lock()
+	p, ok := s.processes[algorithmId]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if cmd := p.runningCmd(); cmd != nil {
+		reloadProcess(cmd)
+		logger(consts.LoggerSupervisor).Infof(ctx, "Algorithm %s notified to reload config", algorithmId)
+	}
+}
+
+// Status 返回所有受守护算法的运行状态，按 algorithmId 排序
+func (s *sSupervisor) Status() []model.AlgorithmRuntimeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]model.AlgorithmRuntimeStatus, 0, len(s.processes))
+	for _, p := range s.processes {
+		p.mu.RLock()
+		list = append(list, p.status)
+		p.mu.RUnlock()
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].AlgorithmId < list[j].AlgorithmId
+	})
+	return list
+}
+
+// start 为算法创建守护进程，已在守护中的算法直接返回
+func (s *sSupervisor) start(ctx context.Context, algorithm entity.Algorithm) error {
+	if algorithm.LocalPath == "" {
+		return gerror.NewCodef(gcode.CodeInvalidOperation, "algorithm %s is not installed", algorithm.AlgorithmId)
+	}
+	manifest, err := Algorithm().LoadManifest(algorithm.LocalPath)
+	if err != nil {
+		return err
+	}
+	// 新版本目录中还没有配置文件，启动前写入最新配置
+	if err = AlgorithmConfig().Materialize(ctx, &algorithm); err != nil {
+		return err
+	}
+	return s.spawn(ctx, algorithm, manifest)
+}
+
+// spawn 按清单创建算法进程并开始守护，已在守护中的算法直接返回
+func (s *sSupervisor) spawn(ctx context.Context, algorithm entity.Algorithm, manifest *model.AlgorithmManifest) error {
+	workDir, err := filepath.Abs(Algorithm().AppPath(algorithm.LocalPath))
+	if err != nil {
+		return err
+	}
+	entrypoint := filepath.Join(workDir, manifest.Entrypoint)
+	if !gfile.Exists(entrypoint) {
+		return gerror.NewCodef(gcode.CodeInvalidOperation, "entrypoint %s not found", entrypoint)
+	}
+
+	logger := glog.New()
+	logConfig := g.Map{
+		"path":   s.logPath,
+		"file":   algorithm.AlgorithmId + ".log",
+		"stdout": false,
+	}
+	for k, v := range s.logConfig {
+		logConfig[k] = v
+	}
+	if err = logger.SetConfigWithMap(logConfig); err != nil {
+		return gerror.Wrap(err, "init algorithm logger failed")
+	}
+
+	env := os.Environ()
+	for k, v := range manifest.Env {
+		env = append(env, k+"="+v)
+	}
+	env = append(env,
+		"I800_ALGORITHM_ID="+algorithm.AlgorithmId,
+		"I800_ALGORITHM_VERSION="+algorithm.AlgorithmVersion,
+		"I800_CONFIG_FILE="+filepath.Join(workDir, manifest.ConfigFile),
+	)
+
+	p := &algorithmProcess{
+		status: model.AlgorithmRuntimeStatus{
+			AlgorithmId:        algorithm.AlgorithmId,
+			AlgorithmVersionId: algorithm.AlgorithmVersionId,
+			State:              consts.ProcessStateStarting,
+		},
+		workDir:    workDir,
+		entrypoint: entrypoint,
+		args:       manifest.Args,
+		env:        env,
+		logger:     logger,
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if old, ok := s.processes[algorithm.AlgorithmId]; ok {
+		select {
+		case <-old.done:
+			// 已停止的进程记录直接替换
+		default:
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	s.processes[algorithm.AlgorithmId] = p
+	s.mu.Unlock()
+
+	// 进程守护的生命周期长于调用方，只继承 traceId 不继承取消
+	go s.supervise(context.WithoutCancel(ctx), p)
+	return nil
+}
+
+// supervise 运行并守护算法进程，直到 stopCh 关闭
+func (s *sSupervisor) supervise(ctx context.Context, p *algorithmProcess) {
+	defer close(p.done)
+	backoff := s.backoffMin
+	for {
+		startedAt := time.Now()
+		started, err := s.run(ctx, p)
+
+		select {
+		case <-p.stopCh:
+			p.setExit(consts.ProcessStateStopped, "stopped")
+			return
+		default:
+		}
+
+		// 未请求停止时进程退出都是意外退出，退出码为 0 也按崩溃处理
+		reason := "exited"
+		if err != nil {
+			reason = err.Error()
+		}
+		if !started {
+			reason = "start failed: " + reason
+		}
+		if time.Since(startedAt) >= supervisorStableDuration {
+			backoff = s.backoffMin
+		}
+		p.setExit(consts.ProcessStateCrashed, reason)
+		p.mu.Lock()
+		p.status.Backoff = backoff.Milliseconds()
+		p.mu.Unlock()
+		logger(consts.LoggerSupervisor).Warningf(ctx, "Algorithm %s %s, restarting in %s", p.status.AlgorithmId, reason, backoff)
+
+		select {
+		case <-p.stopCh:
+			p.setExit(consts.ProcessStateStopped, "stopped")
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.backoffMax {
+			backoff = s.backoffMax
+		}
+		p.mu.Lock()
+		p.status.Restarts++
+		p.mu.Unlock()
+	}
+}
+
+// run 启动一次算法进程并等待其退出，started 表示进程是否成功启动
+func (s *sSupervisor) run(ctx context.Context, p *algorithmProcess) (started bool, err error) {
+	cmd := exec.Command(p.entrypoint, p.args...)
+	cmd.Dir = p.workDir
+	cmd.Env = p.env
+	setProcessAttr(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return false, err
+	}
+	if err = startProcess(cmd, s.limits); err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.status.State = consts.ProcessStateRunning
+	p.status.Backoff = 0
+	p.status.Pid = cmd.Process.Pid
+	p.status.StartedAt = gtime.Now()
+	p.mu.Unlock()
+	// 启动期间收到停止请求时 Stop 拿不到进程句柄，这里补发终止
+	select {
+	case <-p.stopCh:
+		terminateProcess(cmd)
+	default:
+	}
+	logger(consts.LoggerSupervisor).Infof(ctx, "Algorithm %s started, pid %d", p.status.AlgorithmId, cmd.Process.Pid)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go p.pipeLog(&wg, "stdout", stdout)
+	go p.pipeLog(&wg, "stderr", stderr)
+	wg.Wait()
+	return true, cmd.Wait()
+}
+
+// pipeLog 按行将进程输出写入滚动日志
+func (p *algorithmProcess) pipeLog(wg *sync.WaitGroup, stream string, r io.Reader) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p.logger.Printf(context.Background(), "[%s] %s", stream, scanner.Text())
+	}
+}
+
+// runningCmd 返回当前运行中的进程，未运行时返回 nil
+func (p *algorithmProcess) runningCmd() *exec.Cmd {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return nil
+	}
+	return p.cmd
+}
+
+// setExit 记录进程退出状态
+func (p *algorithmProcess) setExit(state, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cmd = nil
+	p.status.State = state
+	p.status.Backoff = 0
+	p.status.Pid = 0
+	p.status.LastExit = reason
+	p.status.LastExitAt = gtime.Now()
+}
@@ -0,0 +1,127 @@
+//go:build linux
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+
+	"golang.org/x/sys/unix"
+
+	"demo/internal/model"
+)
+
+// setProcessAttr 让算法进程独立成进程组，便于连同子进程一起终止
+func setProcessAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess 向算法进程组发送 SIGTERM，请求优雅退出
+func terminateProcess(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// reloadProcess 向算法主进程发送 SIGHUP，通知重新加载配置。
+// 不发给整个进程组，避免未处理 SIGHUP 的子进程被终止。
+func reloadProcess(cmd *exec.Cmd) {
+	_ = cmd.Process.Signal(syscall.SIGHUP)
+}
+
+// killProcess 强制终止算法进程组
+func killProcess(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// processLauncherArg 算法启动器参数，程序以 "<程序> processLauncherArg <限制JSON> <入口> [参数...]" 运行时
+// 只设置资源限制并执行算法入口，见 startProcess
+const processLauncherArg = "__algorithm-launcher"
+
+// startProcess 启动算法进程。设置了资源限制时先执行本程序的启动器，由启动器设置限制后再执行算法入口，
+// 算法及其子进程从第一条指令起就受限制；启动器设置限制或执行入口失败时返回错误
+func startProcess(cmd *exec.Cmd, limits model.ProcessLimits) error {
+	if limits == (model.ProcessLimits{}) {
+		return cmd.Start()
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return gerror.Wrap(err, "locate executable failed")
+	}
+	encoded, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+	entrypoint := cmd.Path
+	cmd.Args = append([]string{exe, processLauncherArg, string(encoded), entrypoint}, cmd.Args[1:]...)
+	cmd.Path = exe
+	// 状态管道：启动器成功执行入口时随 exec 关闭，失败时写入原因
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	cmd.ExtraFiles = []*os.File{w}
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		return err
+	}
+	if reason, _ := io.ReadAll(r); len(reason) > 0 {
+		_ = cmd.Wait()
+		return gerror.Newf("launch %s failed: %s", entrypoint, reason)
+	}
+	return nil
+}
+
+// RunProcessLauncher 程序作为算法启动器运行时设置资源限制并执行算法入口，不再返回；否则直接返回。
+// 需在 main 开始处调用
+func RunProcessLauncher() {
+	if len(os.Args) < 4 || os.Args[1] != processLauncherArg {
+		return
+	}
+	status := os.NewFile(3, "launcher-status")
+	err := launch(os.Args[2], os.Args[3:])
+	_, _ = status.WriteString(err.Error())
+	os.Exit(127)
+}
+
+// launch 在当前进程设置资源限制后执行算法入口，成功时不返回
+func launch(encoded string, argv []string) error {
+	var limits model.ProcessLimits
+	if err := json.Unmarshal([]byte(encoded), &limits); err != nil {
+		return err
+	}
+	// nice 值按线程生效，设置和 exec 需在同一线程
+	runtime.LockOSThread()
+	rlimits := []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{"maxMemory", unix.RLIMIT_AS, limits.MaxMemory},
+		{"maxOpenFiles", unix.RLIMIT_NOFILE, limits.MaxOpenFiles},
+		{"maxCpuSeconds", unix.RLIMIT_CPU, limits.MaxCpuSeconds},
+	}
+	for _, limit := range rlimits {
+		if limit.value == 0 {
+			continue
+		}
+		// 使用 syscall.Setrlimit，exec 时 Go 运行时不会恢复启动前的 RLIMIT_NOFILE
+		if err := syscall.Setrlimit(limit.resource, &syscall.Rlimit{Cur: limit.value, Max: limit.value}); err != nil {
+			return fmt.Errorf("set %s to %d: %w", limit.name, limit.value, err)
+		}
+	}
+	if limits.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, limits.Nice); err != nil {
+			return fmt.Errorf("set nice to %d: %w", limits.Nice, err)
+		}
+	}
+	unix.CloseOnExec(3)
+	return syscall.Exec(argv[0], argv, os.Environ())
+}
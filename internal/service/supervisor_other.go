@@ -0,0 +1,33 @@
+//go:build !linux
+
+package service
+
+import (
+	"os/exec"
+
+	"demo/internal/model"
+)
+
+// setProcessAttr 非 Linux 平台不设置进程组
+func setProcessAttr(cmd *exec.Cmd) {}
+
+// terminateProcess 非 Linux 平台无法发送 SIGTERM，直接终止进程
+func terminateProcess(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+// reloadProcess 非 Linux 平台没有 SIGHUP，算法需自行监视配置文件变化
+func reloadProcess(cmd *exec.Cmd) {}
+
+// killProcess 强制终止算法进程
+func killProcess(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+// startProcess 非 Linux 平台不支持资源限制，直接启动
+func startProcess(cmd *exec.Cmd, limits model.ProcessLimits) error {
+	return cmd.Start()
+}
+
+// RunProcessLauncher 非 Linux 平台没有算法启动器
+func RunProcessLauncher() {}
@@ -0,0 +1,284 @@
+//go:build linux
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+	"demo/internal/model/entity"
+)
+
+// newTestSupervisor 创建使用临时日志目录和短退避时间的守护服务
+func newTestSupervisor(t *testing.T) *sSupervisor {
+	t.Helper()
+	loadTestConfig(t, g.Map{})
+	s := &sSupervisor{
+		processes:   make(map[string]*algorithmProcess),
+		logPath:     t.TempDir(),
+		logConfig:   g.Map{},
+		backoffMin:  100 * time.Millisecond,
+		backoffMax:  400 * time.Millisecond,
+		stopTimeout: 5 * time.Second,
+	}
+	t.Cleanup(func() { s.StopAll(context.Background()) })
+	return s
+}
+
+// spawnScript 将 script 作为算法入口写入临时版本目录并启动
+func spawnScript(t *testing.T, s *sSupervisor, algorithmId, script string) {
+	t.Helper()
+	versionPath := t.TempDir()
+	appPath := filepath.Join(versionPath, algorithmAppDir)
+	if err := os.MkdirAll(appPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(appPath, "run.sh"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	algorithm := entity.Algorithm{
+		AlgorithmId:        algorithmId,
+		AlgorithmVersion:   "1.0.0",
+		AlgorithmVersionId: "v1",
+		LocalPath:          versionPath,
+	}
+	manifest := &model.AlgorithmManifest{Entrypoint: "run.sh", ConfigFile: defaultAlgorithmConfigFile}
+	if err := s.spawn(context.Background(), algorithm, manifest); err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+}
+
+// processStatus 返回算法的运行状态
+func processStatus(s *sSupervisor, algorithmId string) model.AlgorithmRuntimeStatus {
+	for _, status := range s.Status() {
+		if status.AlgorithmId == algorithmId {
+			return status
+		}
+	}
+	return model.AlgorithmRuntimeStatus{}
+}
+
+// processLog 返回算法日志文件内容
+func processLog(t *testing.T, s *sSupervisor, algorithmId string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(s.logPath, algorithmId+".log"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// processAlive 判断进程是否存在且不是僵尸进程
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// 状态字段在进程名 ")" 之后
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func TestSupervisorStartAndCaptureLog(t *testing.T) {
+	s := newTestSupervisor(t)
+	spawnScript(t, s, "algo-log", `echo "started $I800_ALGORITHM_ID $I800_ALGORITHM_VERSION"
+echo "something failed" >&2
+exec sleep 30
+`)
+	waitFor(t, 5*time.Second, "process running", func() bool {
+		return processStatus(s, "algo-log").State == consts.ProcessStateRunning
+	})
+	status := processStatus(s, "algo-log")
+	if status.Pid == 0 || status.AlgorithmVersionId != "v1" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if !processAlive(status.Pid) {
+		t.Fatalf("process %d not alive", status.Pid)
+	}
+	waitFor(t, 5*time.Second, "log output", func() bool {
+		log := processLog(t, s, "algo-log")
+		return strings.Contains(log, "[stdout] started algo-log 1.0.0") && strings.Contains(log, "[stderr] something failed")
+	})
+
+	// 再次启动运行中的算法不创建新进程
+	spawnScript(t, s, "algo-log", "exit 0\n")
+	if pid := processStatus(s, "algo-log").Pid; pid != status.Pid {
+		t.Fatalf("pid changed from %d to %d", status.Pid, pid)
+	}
+}
+
+func TestSupervisorLimitsBeforeExec(t *testing.T) {
+	s := newTestSupervisor(t)
+	s.limits = model.ProcessLimits{Nice: 5, MaxOpenFiles: 64}
+	// 入口和它立即创建的子进程都应受限制
+	spawnScript(t, s, "algo-limits", `echo "nofile $(ulimit -n) nice $(cut -d' ' -f19 /proc/$$/stat)"
+sh -c 'echo "child nofile $(ulimit -n)"'
+exec sleep 30
+`)
+	waitFor(t, 5*time.Second, "limits logged", func() bool {
+		return strings.Contains(processLog(t, s, "algo-limits"), "child nofile")
+	})
+	log := processLog(t, s, "algo-limits")
+	if !strings.Contains(log, "[stdout] nofile 64 nice 5") || !strings.Contains(log, "[stdout] child nofile 64") {
+		t.Fatalf("limits not applied before exec:\n%s", log)
+	}
+	if status := processStatus(s, "algo-limits"); status.State != consts.ProcessStateRunning {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestSupervisorLimitsFailure(t *testing.T) {
+	s := newTestSupervisor(t)
+	// 超过 fs.nr_open，root 也无法设置
+	s.limits = model.ProcessLimits{MaxOpenFiles: 1 << 40}
+	spawnScript(t, s, "algo-unlimited", "echo started\nexec sleep 30\n")
+	waitFor(t, 5*time.Second, "start failure", func() bool {
+		return processStatus(s, "algo-unlimited").LastExit != ""
+	})
+	status := processStatus(s, "algo-unlimited")
+	if status.State != consts.ProcessStateCrashed || !strings.Contains(status.LastExit, "set maxOpenFiles") {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if strings.Contains(processLog(t, s, "algo-unlimited"), "started") {
+		t.Fatal("entrypoint ran without its limits")
+	}
+}
+
+func TestSupervisorRestartWithBackoff(t *testing.T) {
+	s := newTestSupervisor(t)
+	started := time.Now()
+	spawnScript(t, s, "algo-crash", "echo run\nexit 3\n")
+
+	// 退避 100ms、200ms、400ms 后第 3 次重启
+	waitFor(t, 10*time.Second, "3 restarts", func() bool {
+		return processStatus(s, "algo-crash").Restarts >= 3
+	})
+	if elapsed := time.Since(started); elapsed < 700*time.Millisecond {
+		t.Fatalf("3 restarts after %s, backoff not applied", elapsed)
+	}
+	// 等待重启期间报告崩溃及退避时间
+	waitFor(t, time.Second, "crashed state", func() bool {
+		return processStatus(s, "algo-crash").State == consts.ProcessStateCrashed
+	})
+	status := processStatus(s, "algo-crash")
+	if !strings.Contains(status.LastExit, "exit status 3") || status.Backoff < 100 || status.Backoff > 400 {
+		t.Fatalf("unexpected crashed status %+v", status)
+	}
+	if runs := strings.Count(processLog(t, s, "algo-crash"), "[stdout] run"); runs < 3 {
+		t.Fatalf("%d runs logged, want at least 3", runs)
+	}
+
+	// 退避上限 400ms，之后的重启间隔不再增长
+	restarts := status.Restarts
+	time.Sleep(1300 * time.Millisecond)
+	if n := processStatus(s, "algo-crash").Restarts - restarts; n < 2 {
+		t.Fatalf("%d restarts in 1.3s, backoff exceeds the maximum", n)
+	}
+
+	s.Stop(context.Background(), "algo-crash", time.Second)
+	if state := processStatus(s, "algo-crash").State; state != consts.ProcessStateStopped {
+		t.Fatalf("state %s after stop", state)
+	}
+}
+
+func TestSupervisorCleanExitIsCrash(t *testing.T) {
+	s := newTestSupervisor(t)
+	// 算法不应自行退出，退出码为 0 同样按崩溃重启
+	spawnScript(t, s, "algo-exit", "exit 0\n")
+	waitFor(t, 5*time.Second, "crashed state", func() bool {
+		status := processStatus(s, "algo-exit")
+		return status.State == consts.ProcessStateCrashed && status.LastExit == "exited" && status.Backoff > 0
+	})
+	waitFor(t, 5*time.Second, "restart", func() bool {
+		return processStatus(s, "algo-exit").Restarts >= 1
+	})
+}
+
+func TestSupervisorStopKillsProcessGroup(t *testing.T) {
+	s := newTestSupervisor(t)
+	// 脚本的子进程与其在同一进程组，停止时一起终止
+	spawnScript(t, s, "algo-group", `sleep 30 &
+echo "child $!"
+wait
+`)
+	var child int
+	waitFor(t, 5*time.Second, "child started", func() bool {
+		_, after, ok := strings.Cut(processLog(t, s, "algo-group"), "[stdout] child ")
+		if ok {
+			child, _ = strconv.Atoi(strings.TrimSpace(strings.SplitN(after, "\n", 2)[0]))
+		}
+		return child > 0
+	})
+	pid := processStatus(s, "algo-group").Pid
+
+	s.Stop(context.Background(), "algo-group", time.Second)
+	for _, p := range []int{pid, child} {
+		waitFor(t, 2*time.Second, "process "+strconv.Itoa(p)+" exit", func() bool {
+			return !processAlive(p)
+		})
+	}
+}
+
+func TestSupervisorStopGraceful(t *testing.T) {
+	s := newTestSupervisor(t)
+	spawnScript(t, s, "algo-term", "exec sleep 30\n")
+	waitFor(t, 5*time.Second, "process running", func() bool {
+		return processStatus(s, "algo-term").State == consts.ProcessStateRunning
+	})
+	pid := processStatus(s, "algo-term").Pid
+
+	started := time.Now()
+	s.Stop(context.Background(), "algo-term", 5*time.Second)
+	if elapsed := time.Since(started); elapsed >= 5*time.Second {
+		t.Fatalf("stop took %s, SIGTERM ignored", elapsed)
+	}
+	status := processStatus(s, "algo-term")
+	if status.State != consts.ProcessStateStopped || status.Pid != 0 {
+		t.Fatalf("unexpected status after stop %+v", status)
+	}
+	if processAlive(pid) {
+		t.Fatalf("process %d still alive", pid)
+	}
+}
+
+func TestSupervisorStopKillsAfterGrace(t *testing.T) {
+	s := newTestSupervisor(t)
+	// 忽略 SIGTERM 的进程，超过等待时间后应被 SIGKILL
+	spawnScript(t, s, "algo-stubborn", `trap 'echo got term' TERM
+echo ready
+while true; do sleep 0.1; done
+`)
+	waitFor(t, 5*time.Second, "process ready", func() bool {
+		return strings.Contains(processLog(t, s, "algo-stubborn"), "[stdout] ready")
+	})
+	pid := processStatus(s, "algo-stubborn").Pid
+
+	grace := 500 * time.Millisecond
+	started := time.Now()
+	s.Stop(context.Background(), "algo-stubborn", grace)
+	elapsed := time.Since(started)
+	if elapsed < grace {
+		t.Fatalf("stopped after %s, before the grace period", elapsed)
+	}
+	if elapsed > grace+3*time.Second {
+		t.Fatalf("stop took %s", elapsed)
+	}
+	if !strings.Contains(processLog(t, s, "algo-stubborn"), "[stdout] got term") {
+		t.Fatal("SIGTERM not delivered before SIGKILL")
+	}
+	if processAlive(pid) {
+		t.Fatalf("process %d still alive", pid)
+	}
+	if state := processStatus(s, "algo-stubborn").State; state != consts.ProcessStateStopped {
+		t.Fatalf("state %s after stop", state)
+	}
+}
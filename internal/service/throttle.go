@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/util/gconv"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// 限速设置来源
+const (
+	ThrottleSourceConfig = "config" // 配置文件 download.rateLimit 和 download.throttleWindows
+	ThrottleSourceApi    = "api"    // 通过接口或命令实时调整，重启后恢复配置文件的设置
+	ThrottleSourceRemote = "remote" // 云端下发的设备配置 setDeviceConfig
+)
+
+// throttleMinBurst 令牌桶的最小容量，限速很低时避免每次只读取几个字节
+const throttleMinBurst = 1024
+
+// sThrottle 算法包下载限速服务，所有限速下载共享一个令牌桶，
+// 蜂窝网络等上行受限的站点可在工作时间限速、夜间全速下载。
+// 只限制从 algorithmDataUrl 下载，站点内镜像设备之间的传输不限速。
+type sThrottle struct {
+	mu       sync.Mutex
+	config   model.DownloadThrottle // 配置文件中的设置
+	settings model.DownloadThrottle // 当前设置
+	source   string
+	rate     int64     // 当前生效的限速，0 不限速
+	active   []int     // 当前处于的时间窗
+	valid    bool      // rate 是否已按当前设置计算
+	until    time.Time // rate 的有效期，到达后重新计算，零值为没有会切换的时间窗
+	tokens   float64
+	last     time.Time
+}
+
+var (
+	throttleService *sThrottle
+	throttleOnce    sync.Once
+)
+
+// Throttle 获取下载限速服务单例
+func Throttle() *sThrottle {
+	throttleOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		cfg := g.Cfg()
+		config := model.DownloadThrottle{
+			RateLimit: throttleRate(cfg.MustGet(ctx, "download.rateLimit").String()),
+		}
+		for _, item := range cfg.MustGet(ctx, "download.throttleWindows").Maps() {
+			config.Windows = append(config.Windows, model.ThrottleWindow{
+				Cron:      gconv.String(item["cron"]),
+				Duration:  gconv.String(item["duration"]),
+				Timezone:  gconv.String(item["timezone"]),
+				RateLimit: throttleRate(gconv.String(item["rateLimit"])),
+			})
+		}
+		if err := normalizeThrottle(&config); err != nil {
+			logger(consts.LoggerDownload).Errorf(ctx, "Invalid download throttle config, downloads are not throttled: %v", err)
+			config = model.DownloadThrottle{}
+		}
+		throttleService = &sThrottle{
+			config:   config,
+			settings: config,
+			source:   ThrottleSourceConfig,
+		}
+	})
+	return throttleService
+}
+
+// Status 返回当前设置、生效的限速和进行中的下载
+func (s *sThrottle) Status(ctx context.Context) *model.DownloadThrottleStatus {
+	s.mu.Lock()
+	rate := s.currentRate(ctx, time.Now())
+	status := &model.DownloadThrottleStatus{
+		DownloadThrottle: s.settings,
+		Source:           s.source,
+		CurrentRate:      rate,
+		ActiveWindows:    append([]int{}, s.active...),
+	}
+	s.mu.Unlock()
+	status.Downloads = Download().Active()
+	for _, download := range status.Downloads {
+		status.Throughput += download.Throughput
+	}
+	return status
+}
+
+// Rate 返回当前生效的限速，0 不限速
+func (s *sThrottle) Rate(ctx context.Context) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.currentRate(ctx, time.Now())
+}
+
+// Set 实时调整限速设置，立即对进行中的下载生效
+func (s *sThrottle) Set(ctx context.Context, in model.DownloadThrottle) error {
+	return s.set(ctx, in, ThrottleSourceApi)
+}
+
+// Source 返回当前设置的来源
+func (s *sThrottle) Source() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source
+}
+
+// set 应用限速设置并记录来源
+func (s *sThrottle) set(ctx context.Context, in model.DownloadThrottle, source string) error {
+	if err := normalizeThrottle(&in); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.settings = in
+	s.source = source
+	s.valid = false
+	rate := s.currentRate(ctx, time.Now())
+	s.mu.Unlock()
+	logger(consts.LoggerDownload).Infof(ctx, "Download throttle adjusted: default %s, %d windows, now %s",
+		formatRate(in.RateLimit), len(in.Windows), formatRate(rate))
+	return nil
+}
+
+// Reset 恢复配置文件中的限速设置
+func (s *sThrottle) Reset(ctx context.Context) {
+	s.mu.Lock()
+	s.settings = s.config
+	s.source = ThrottleSourceConfig
+	s.valid = false
+	rate := s.currentRate(ctx, time.Now())
+	s.mu.Unlock()
+	logger(consts.LoggerDownload).Infof(ctx, "Download throttle reset to config, now %s", formatRate(rate))
+}
+
+// Reader 返回按令牌桶限速读取 r 的 Reader
+func (s *sThrottle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &throttledReader{ctx: ctx, r: r, throttle: s}
+}
+
+// Wait 从令牌桶取出 n 个字节的令牌，令牌不足时等待
+func (s *sThrottle) Wait(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	now := time.Now()
+	rate := s.currentRate(ctx, now)
+	if rate <= 0 {
+		s.tokens, s.last = 0, now
+		s.mu.Unlock()
+		return nil
+	}
+	burst := float64(throttleBurst(rate))
+	s.tokens += now.Sub(s.last).Seconds() * float64(rate)
+	if s.tokens > burst {
+		s.tokens = burst
+	}
+	s.last = now
+	s.tokens -= float64(n)
+	var wait time.Duration
+	if s.tokens < 0 {
+		wait = time.Duration(-s.tokens / float64(rate) * float64(time.Second))
+	}
+	s.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// chunk 单次读取的最大字节数，限速时不超过令牌桶容量，使速率平滑
+func (s *sThrottle) chunk(ctx context.Context) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rate := s.currentRate(ctx, time.Now()); rate > 0 {
+		return throttleBurst(rate)
+	}
+	return 0
+}
+
+// currentRate 返回 now 时刻生效的限速，时间窗切换时重新计算，调用方持有锁
+func (s *sThrottle) currentRate(ctx context.Context, now time.Time) int64 {
+	if s.valid && (s.until.IsZero() || now.Before(s.until)) {
+		return s.rate
+	}
+	rate := s.settings.RateLimit
+	active := make([]int, 0)
+	until := time.Time{}
+	for i, item := range s.settings.Windows {
+		window, err := cronWindow(item.Cron, item.Duration, item.Timezone, now)
+		if err != nil {
+			continue
+		}
+		next := window.nextStart
+		if window.active {
+			active = append(active, i)
+			next = window.nextStop
+		}
+		if !next.IsZero() && (until.IsZero() || next.Before(until)) {
+			until = next
+		}
+	}
+	// 时间窗重叠时取最低的限速，时间窗限速为 0 表示该时段不限速
+	if len(active) > 0 {
+		rate = 0
+		for _, i := range active {
+			if limit := s.settings.Windows[i].RateLimit; limit > 0 && (rate == 0 || limit < rate) {
+				rate = limit
+			}
+		}
+	}
+	if rate != s.rate {
+		logger(consts.LoggerDownload).Infof(ctx, "Download rate limit is now %s", formatRate(rate))
+	}
+	s.rate, s.active, s.until, s.valid = rate, active, until, true
+	return rate
+}
+
+// throttledReader 按令牌桶限速的 Reader
+type throttledReader struct {
+	ctx      context.Context
+	r        io.Reader
+	throttle *sThrottle
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if chunk := r.throttle.chunk(r.ctx); chunk > 0 && len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.r.Read(p)
+	if waitErr := r.throttle.Wait(r.ctx, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
+
+// normalizeThrottle 校验限速设置，补全时间窗的默认时区
+func normalizeThrottle(in *model.DownloadThrottle) error {
+	if in.RateLimit < 0 {
+		return gerror.NewCodef(gcode.CodeInvalidParameter, "invalid rateLimit: %d", in.RateLimit)
+	}
+	for i := range in.Windows {
+		window := &in.Windows[i]
+		if window.Cron == "" || window.Duration == "" {
+			return gerror.NewCodef(gcode.CodeMissingParameter, "throttle window %d: cron and duration are required", i)
+		}
+		if window.RateLimit < 0 {
+			return gerror.NewCodef(gcode.CodeInvalidParameter, "throttle window %d: invalid rateLimit: %d", i, window.RateLimit)
+		}
+		if window.Timezone == "" {
+			window.Timezone = time.Local.String()
+		}
+		if _, err := cronWindow(window.Cron, window.Duration, window.Timezone, time.Now()); err != nil {
+			return gerror.WrapCodef(gcode.CodeInvalidParameter, err, "throttle window %d", i)
+		}
+	}
+	return nil
+}
+
+// throttleRate 解析配置中的限速，支持 512KB 等写法，单位为每秒字节数，无法解析时返回 -1
+func throttleRate(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	return gfile.StrToSize(value)
+}
+
+// throttleBurst 令牌桶容量，为 1/4 秒的流量
+func throttleBurst(rate int64) int {
+	if burst := rate / 4; burst > throttleMinBurst {
+		return int(burst)
+	}
+	return throttleMinBurst
+}
+
+// formatRate 格式化限速用于日志
+func formatRate(rate int64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+	return gfile.FormatSize(rate) + "/s"
+}
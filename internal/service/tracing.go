@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/gtrace"
+	"github.com/gogf/gf/v2/util/gconv"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"demo/internal/consts"
+)
+
+// 链路导出方式
+const (
+	TracingExporterNone   = "none"   // 不导出，使用框架默认的 TracerProvider
+	TracingExporterStdout = "stdout" // OTLP JSON 逐行输出到标准输出
+	TracingExporterFile   = "file"   // OTLP JSON 逐行追加到文件，离线站点事后收集
+	TracingExporterOtlp   = "otlp"   // OTLP/HTTP protobuf 上报到采集器
+)
+
+// 命令 JSON 中携带链路上下文的字段，与 W3C Trace Context 请求头同名
+const (
+	traceParentField = "traceparent"
+	traceStateField  = "tracestate"
+)
+
+// sTracing 链路追踪服务，按配置创建 OpenTelemetry TracerProvider，
+// HTTP 请求和数据库语句由框架自动生成 span，命令处理和安装各阶段在业务代码中创建 span
+type sTracing struct {
+	provider   *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+var (
+	tracingService *sTracing
+	tracingOnce    sync.Once
+)
+
+// Tracing 获取链路追踪服务单例
+func Tracing() *sTracing {
+	tracingOnce.Do(func() {
+		tracingService = &sTracing{
+			propagator: propagation.TraceContext{},
+		}
+	})
+	return tracingService
+}
+
+// Setup 按 tracing.exporter 配置导出方式，为 none 时不导出 span
+func (s *sTracing) Setup(ctx context.Context) error {
+	var (
+		cfg      = g.Cfg()
+		exporter = cfg.MustGet(ctx, "tracing.exporter", TracingExporterNone).String()
+		client   otlptrace.Client
+	)
+	switch exporter {
+	case TracingExporterNone, "":
+		return nil
+	case TracingExporterStdout:
+		client = &otlpJsonClient{writer: os.Stdout}
+	case TracingExporterFile:
+		client = &otlpJsonClient{path: cfg.MustGet(ctx, "tracing.file", "data/traces/traces.jsonl").String()}
+	case TracingExporterOtlp:
+		client = &otlpHttpClient{
+			endpoint: cfg.MustGet(ctx, "tracing.endpoint", "http://127.0.0.1:4318/v1/traces").String(),
+			headers:  cfg.MustGet(ctx, "tracing.headers").MapStrStr(),
+			client:   &http.Client{Timeout: cfg.MustGet(ctx, "tracing.timeout", "10s").Duration()},
+		}
+	default:
+		return gerror.NewCodef(gcode.CodeInvalidConfiguration, "invalid tracing.exporter: %s", exporter)
+	}
+
+	spanExporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return gerror.Wrapf(err, "create %s trace exporter failed", exporter)
+	}
+	ratio := cfg.MustGet(ctx, "tracing.sampleRatio", 1).Float64()
+	s.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.MustGet(ctx, "tracing.serviceName", "i800").String()),
+			attribute.String("service.instance.id", Device().Id()),
+		)),
+	)
+	otel.SetTracerProvider(s.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger(consts.LoggerApp).Infof(ctx, "Tracing enabled, exporter %s, sample ratio %v", exporter, ratio)
+	return nil
+}
+
+// Shutdown 导出缓存中剩余的 span 并关闭导出器
+func (s *sTracing) Shutdown(ctx context.Context) {
+	if s.provider == nil {
+		return
+	}
+	if err := s.provider.Shutdown(ctx); err != nil {
+		logger(consts.LoggerApp).Warningf(ctx, "Shutdown tracing failed: %v", err)
+	}
+}
+
+// Extract 从命令 JSON 的 traceparent/tracestate 字段恢复云端链路上下文，字段不存在或无效时返回原上下文
+func (s *sTracing) Extract(ctx context.Context, payload *gjson.Json) context.Context {
+	carrier := propagation.MapCarrier{
+		traceParentField: payload.Get(traceParentField).String(),
+		traceStateField:  payload.Get(traceStateField).String(),
+	}
+	if carrier[traceParentField] == "" {
+		return ctx
+	}
+	return s.propagator.Extract(ctx, carrier)
+}
+
+// TraceParent 返回当前 span 的 W3C traceparent，写入回复消息供云端关联
+func (s *sTracing) TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	s.propagator.Inject(ctx, carrier)
+	return carrier[traceParentField]
+}
+
+// StartSpan 创建子 span，调用方负责 End
+func (s *sTracing) StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *gtrace.Span) {
+	return gtrace.NewSpan(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan 按 err 设置 span 状态后结束
+func (s *sTracing) EndSpan(span *gtrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// remoteSpanContext 以指定 traceId 构造采样的远端父 span，使后续 span 归入同一链路
+func (s *sTracing) remoteSpanContext(ctx context.Context, traceId string) (context.Context, error) {
+	tid, err := trace.TraceIDFromHex(traceId)
+	if err != nil {
+		return ctx, err
+	}
+	var sid trace.SpanID
+	if _, err = rand.Read(sid[:]); err != nil {
+		return ctx, err
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})), nil
+}
+
+// otlpJsonClient 将 span 以 OTLP JSON 格式逐行写入，每行一个 TracesData，
+// 与 OpenTelemetry Collector 的 file exporter/otlpjsonfile receiver 格式一致
+type otlpJsonClient struct {
+	path   string    // 为空时写 writer
+	writer io.Writer // 标准输出
+	mu     sync.Mutex
+	file   *os.File
+}
+
+// Start 打开输出文件
+func (c *otlpJsonClient) Start(ctx context.Context) error {
+	if c.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return gerror.Wrapf(err, "create directory for %s failed", c.path)
+	}
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return gerror.Wrapf(err, "open trace file %s failed", c.path)
+	}
+	c.file = f
+	c.writer = f
+	return nil
+}
+
+// Stop 关闭输出文件
+func (c *otlpJsonClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// UploadTraces 写入一批 span
+func (c *otlpJsonClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	content, err := protojson.Marshal(&tracepb.TracesData{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	// OTLP JSON 规定 traceId/spanId 使用十六进制，protojson 默认按 bytes 输出 base64
+	var data interface{}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+	if content, err = json.Marshal(hexTraceIds(data)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.writer.Write(append(content, '\n'))
+	return err
+}
+
+// hexTraceIds 将 JSON 中的 traceId/spanId/parentSpanId 由 base64 转为十六进制
+func hexTraceIds(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				if raw, err := base64.StdEncoding.DecodeString(gconv.String(item)); err == nil {
+					v[key] = hex.EncodeToString(raw)
+				}
+			default:
+				v[key] = hexTraceIds(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = hexTraceIds(item)
+		}
+	}
+	return value
+}
+
+// otlpHttpClient 以 OTLP/HTTP protobuf 上报 span。
+// 不使用 gclient，避免上报请求本身再产生 span。
+type otlpHttpClient struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// Start 无需初始化
+func (c *otlpHttpClient) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop 无需释放资源
+func (c *otlpHttpClient) Stop(ctx context.Context) error {
+	return nil
+}
+
+// UploadTraces 上报一批 span，TracesData 与 ExportTraceServiceRequest 的编码相同
+func (c *otlpHttpClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	body, err := proto.Marshal(&tracepb.TracesData{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return gerror.Wrapf(err, "export traces to %s failed", c.endpoint)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return gerror.Newf("export traces to %s failed: http status %d", c.endpoint, resp.StatusCode)
+	}
+	return nil
+}
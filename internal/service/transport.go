@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+
+	"demo/internal/model"
+)
+
+// 云端命令通道类型，transport.type 配置取值
+const (
+	TransportMqtt = "mqtt" // MQTT 订阅命令主题，回复发布到 reply 主题或 v5 ResponseTopic
+	TransportHttp = "http" // HTTPS 长轮询或 webhook 接收命令，回复 POST 到云端
+)
+
+// ITransport 云端命令通道，接收命令、交给分发器执行并把结果回复云端，同时承载注册和心跳上报
+type ITransport interface {
+	// Name 通道类型
+	Name() string
+	// Enabled 是否已配置启用
+	Enabled() bool
+	// Start 开始接收命令，每条命令在独立协程中调用 handler
+	Start(ctx context.Context, handler TransportHandler) error
+	// SendHeartbeat 上报心跳
+	SendHeartbeat(ctx context.Context, payload []byte) error
+	// SendRegistration 上报设备注册信息
+	SendRegistration(ctx context.Context, payload []byte) error
+	// IsConnected 与云端的连接是否正常
+	IsConnected() bool
+}
+
+// TransportHandler 执行一条命令，执行结果通过 reply 回复云端
+type TransportHandler func(msg *model.CommandMessage, reply ReplyFunc)
+
+// ReplyFunc 由通道提供的回复函数，按收到命令的方式把结果送回云端
+type ReplyFunc func(ctx context.Context, reply *CommandReply) error
+
+var (
+	transportService ITransport
+	transportOnce    sync.Once
+)
+
+// Transport 获取命令通道单例，按 transport.type 选择 MQTT 或 HTTP
+func Transport() ITransport {
+	transportOnce.Do(func() {
+		ctx := gctx.GetInitCtx()
+		if g.Cfg().MustGet(ctx, "transport.type", TransportMqtt).String() == TransportHttp {
+			transportService = newHttpTransport(ctx)
+		} else {
+			transportService = &mqttTransport{}
+		}
+	})
+	return transportService
+}
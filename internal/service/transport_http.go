@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/gclient"
+	"github.com/gogf/gf/v2/os/gctx"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// HTTP 通道接收命令的方式，transport.http.mode 配置取值
+const (
+	HttpTransportPoll    = "poll"    // 设备长轮询云端命令队列
+	HttpTransportWebhook = "webhook" // 云端调用设备的 /transport/webhook 推送命令
+)
+
+// IWebhook 支持云端推送命令的通道
+type IWebhook interface {
+	// Receive 接收一条推送的命令，校验通过后异步执行
+	Receive(ctx context.Context, payload []byte, header http.Header) error
+}
+
+// httpTransport 基于 HTTPS 的命令通道，用于屏蔽了 MQTT 端口的网络。云端接口：
+//
+//	GET  {baseUrl}/devices/{deviceId}/commands?wait=秒  长轮询，200 返回命令 JSON 数组，204 表示没有命令
+//	POST {baseUrl}/devices/{deviceId}/replies          命令执行结果
+//	POST {baseUrl}/devices/{deviceId}/heartbeat        心跳
+//	POST {baseUrl}/devices/{deviceId}/register         注册信息，启动时上报
+//
+// 请求携带 Authorization: Bearer {token}，webhook 推送的命令同样校验该令牌
+type httpTransport struct {
+	baseUrl       string
+	token         string
+	mode          string
+	pollTimeout   time.Duration // 长轮询等待时间，云端在此时间内没有命令时返回 204
+	pollInterval  time.Duration // 两次没有命令的轮询之间的最小间隔，云端不支持长轮询时避免连续请求
+	retryInterval time.Duration // 请求失败后的重试间隔
+	timeout       time.Duration // 回复和心跳请求超时
+	client        *gclient.Client
+	handler       TransportHandler
+	connected     atomic.Bool
+}
+
+// newHttpTransport 按 transport.http 配置创建 HTTP 通道
+func newHttpTransport(ctx context.Context) *httpTransport {
+	cfg := g.Cfg()
+	t := &httpTransport{
+		baseUrl:       strings.TrimRight(cfg.MustGet(ctx, "transport.http.baseUrl").String(), "/"),
+		token:         cfg.MustGet(ctx, "transport.http.token").String(),
+		mode:          cfg.MustGet(ctx, "transport.http.mode", HttpTransportPoll).String(),
+		pollTimeout:   cfg.MustGet(ctx, "transport.http.pollTimeout", "30s").Duration(),
+		pollInterval:  cfg.MustGet(ctx, "transport.http.pollInterval", "5s").Duration(),
+		retryInterval: cfg.MustGet(ctx, "transport.http.retryInterval", "5s").Duration(),
+		timeout:       cfg.MustGet(ctx, "transport.http.timeout", "10s").Duration(),
+		client:        g.Client(),
+	}
+	if t.token != "" {
+		t.client = t.client.Header(map[string]string{"Authorization": "Bearer " + t.token})
+	}
+	// webhook 模式没有轮询请求，初始视为连通，由回复和心跳请求的结果更新
+	t.connected.Store(t.mode == HttpTransportWebhook)
+	return t
+}
+
+// Name 通道类型
+func (t *httpTransport) Name() string {
+	return TransportHttp
+}
+
+// Enabled 配置了 transport.http.baseUrl 时启用
+func (t *httpTransport) Enabled() bool {
+	return t.baseUrl != ""
+}
+
+// Start 轮询模式下启动长轮询协程，webhook 模式下等待 Receive 调用。
+// webhook 接口对外开放，必须配置 transport.http.token
+func (t *httpTransport) Start(ctx context.Context, handler TransportHandler) error {
+	switch t.mode {
+	case HttpTransportPoll:
+		t.handler = handler
+		go t.poll(context.WithoutCancel(ctx))
+	case HttpTransportWebhook:
+		if t.token == "" {
+			return gerror.NewCode(gcode.CodeInvalidConfiguration, "transport.http.token is required in webhook mode")
+		}
+		t.handler = handler
+	default:
+		return gerror.NewCodef(gcode.CodeInvalidConfiguration, "invalid transport.http.mode: %s", t.mode)
+	}
+	logger(consts.LoggerCommand).Infof(ctx, "HTTP transport started in %s mode, cloud %s", t.mode, t.baseUrl)
+	return nil
+}
+
+// poll 循环长轮询命令队列直到 ctx 结束，失败时按 retryInterval 重试。
+// 没有命令的响应早于 pollInterval 返回时(云端不支持长轮询)，等满 pollInterval 再轮询
+func (t *httpTransport) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		startedAt := time.Now()
+		commands, err := t.fetch(ctx)
+		t.setConnected(err)
+		wait := time.Duration(0)
+		if err != nil {
+			logger(consts.LoggerCommand).Warningf(ctx, "Poll commands failed: %v", err)
+			wait = t.retryInterval
+		} else if len(commands) == 0 {
+			wait = t.pollInterval - time.Since(startedAt)
+		}
+		for _, payload := range commands {
+			go t.dispatch(payload, nil)
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// fetch 执行一次长轮询，返回收到的命令
+func (t *httpTransport) fetch(ctx context.Context) ([]json.RawMessage, error) {
+	url := fmt.Sprintf("%s/devices/%s/commands?wait=%d", t.baseUrl, Device().Id(), int(t.pollTimeout.Seconds()))
+	resp, err := t.client.Timeout(t.pollTimeout+t.timeout).Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK:
+		var commands []json.RawMessage
+		if err = json.Unmarshal(resp.ReadAll(), &commands); err != nil {
+			return nil, gerror.Wrap(err, "invalid commands response")
+		}
+		return commands, nil
+	default:
+		return nil, gerror.Newf("http status %d", resp.StatusCode)
+	}
+}
+
+// Receive webhook 模式下由 /transport/webhook 接口调用，校验令牌后异步执行命令
+func (t *httpTransport) Receive(ctx context.Context, payload []byte, header http.Header) error {
+	if t.mode != HttpTransportWebhook || t.handler == nil {
+		return gerror.NewCode(gcode.CodeNotSupported, "webhook transport is not enabled")
+	}
+	// Start 已保证 webhook 模式配置了令牌，这里仍拒绝空令牌
+	expected := "Bearer " + t.token
+	if t.token == "" || subtle.ConstantTimeCompare([]byte(header.Get("Authorization")), []byte(expected)) != 1 {
+		return gerror.NewCode(gcode.CodeNotAuthorized, "invalid webhook token")
+	}
+	if !json.Valid(payload) {
+		return gerror.NewCode(gcode.CodeInvalidParameter, "invalid command payload")
+	}
+	properties := map[string]string{
+		traceParentField: header.Get(traceParentField),
+		traceStateField:  header.Get(traceStateField),
+	}
+	go t.dispatch(payload, properties)
+	return nil
+}
+
+// dispatch 执行命令并把结果 POST 到云端
+func (t *httpTransport) dispatch(payload []byte, properties map[string]string) {
+	t.handler(&model.CommandMessage{
+		Payload:    payload,
+		Properties: properties,
+	}, func(ctx context.Context, reply *CommandReply) error {
+		content, err := gjson.Encode(reply)
+		if err != nil {
+			return err
+		}
+		return t.post(ctx, "replies", content)
+	})
+}
+
+// SendHeartbeat POST 心跳到云端
+func (t *httpTransport) SendHeartbeat(ctx context.Context, payload []byte) error {
+	return t.post(ctx, "heartbeat", payload)
+}
+
+// SendRegistration POST 注册信息到云端
+func (t *httpTransport) SendRegistration(ctx context.Context, payload []byte) error {
+	return t.post(ctx, "register", payload)
+}
+
+// post 向云端设备接口发送 JSON
+func (t *httpTransport) post(ctx context.Context, path string, content []byte) (err error) {
+	defer func() { t.setConnected(err) }()
+	url := fmt.Sprintf("%s/devices/%s/%s", t.baseUrl, Device().Id(), path)
+	resp, err := t.client.Timeout(t.timeout).ContentJson().Post(ctx, url, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.StatusCode/100 != 2 {
+		return gerror.Newf("post %s failed: http status %d", path, resp.StatusCode)
+	}
+	return nil
+}
+
+// setConnected 按最近一次请求结果更新连接状态
+func (t *httpTransport) setConnected(err error) {
+	connected := err == nil
+	if t.connected.Swap(connected) != connected {
+		if connected {
+			logger(consts.LoggerCommand).Infof(gctx.New(), "HTTP transport connected to %s", t.baseUrl)
+		} else {
+			logger(consts.LoggerCommand).Errorf(gctx.New(), "HTTP transport disconnected from %s: %v", t.baseUrl, err)
+		}
+	}
+}
+
+// IsConnected 最近一次请求云端是否成功
+func (t *httpTransport) IsConnected() bool {
+	return t.connected.Load()
+}
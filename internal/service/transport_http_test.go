@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+
+	"demo/internal/fakecloud"
+	"demo/internal/model"
+)
+
+// newTestHttpTransport 按 transport.http 配置创建 HTTP 通道
+func newTestHttpTransport(t *testing.T, config g.Map) *httpTransport {
+	t.Helper()
+	base := g.Map{
+		"pollTimeout":   "1s",
+		"pollInterval":  "200ms",
+		"retryInterval": "100ms",
+		"timeout":       "2s",
+	}
+	for k, v := range config {
+		base[k] = v
+	}
+	loadTestConfig(t, g.Map{"transport": g.Map{"type": TransportHttp, "http": base}})
+	return newHttpTransport(context.Background())
+}
+
+// startTestPoll 在后台轮询直到测试结束
+func startTestPoll(t *testing.T, transport *httpTransport, handler TransportHandler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	transport.handler = handler
+	go transport.poll(ctx)
+}
+
+// echoHandler 以命令的 cmdId 和 method 回复
+func echoHandler(msg *model.CommandMessage, reply ReplyFunc) {
+	payload := gjson.New(msg.Payload)
+	_ = reply(context.Background(), &CommandReply{
+		CmdId:       payload.Get("cmdId").String(),
+		Method:      payload.Get("method").String(),
+		Message:     "success",
+		TraceParent: msg.Properties[traceParentField],
+	})
+}
+
+// sendCommand 通过模拟云端向设备下发命令，返回云端响应状态码
+func sendCommand(t *testing.T, cloudUrl, cmdId string) int {
+	t.Helper()
+	body := `{"cmdId":"` + cmdId + `","version":"1.0","method":"ping","timestamp":"0"}`
+	resp, err := http.Post(cloudUrl+"/devices/"+Device().Id()+"/commands", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// cloudReplies 返回模拟云端收到的设备回复
+func cloudReplies(t *testing.T, cloudUrl string) []CommandReply {
+	t.Helper()
+	resp, err := http.Get(cloudUrl + "/devices/" + Device().Id() + "/replies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var replies []CommandReply
+	if err = json.NewDecoder(resp.Body).Decode(&replies); err != nil {
+		t.Fatal(err)
+	}
+	return replies
+}
+
+func TestHttpTransportPoll(t *testing.T) {
+	cloud := httptest.NewServer(fakecloud.New("secret", "").Handler())
+	t.Cleanup(cloud.Close)
+	transport := newTestHttpTransport(t, g.Map{"baseUrl": cloud.URL, "token": "secret", "mode": HttpTransportPoll})
+	startTestPoll(t, transport, echoHandler)
+
+	// 长轮询等待中下发的命令立即返回给设备
+	if status := sendCommand(t, cloud.URL, "poll-1"); status != http.StatusAccepted {
+		t.Fatalf("enqueue status %d", status)
+	}
+	waitFor(t, 5*time.Second, "reply", func() bool {
+		return len(cloudReplies(t, cloud.URL)) == 1
+	})
+	if reply := cloudReplies(t, cloud.URL)[0]; reply.CmdId != "poll-1" || reply.Method != "ping" {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+	if !transport.IsConnected() {
+		t.Fatal("transport not connected after a successful poll")
+	}
+
+	if err := transport.SendHeartbeat(context.Background(), []byte(`{"status":"ok"}`)); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+}
+
+func TestHttpTransportPollUnauthorized(t *testing.T) {
+	cloud := httptest.NewServer(fakecloud.New("secret", "").Handler())
+	t.Cleanup(cloud.Close)
+	transport := newTestHttpTransport(t, g.Map{"baseUrl": cloud.URL, "token": "wrong", "mode": HttpTransportPoll})
+
+	_, err := transport.fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("fetch with a wrong token: %v", err)
+	}
+	if err = transport.SendHeartbeat(context.Background(), []byte(`{}`)); err == nil {
+		t.Fatal("heartbeat with a wrong token should fail")
+	}
+	if transport.IsConnected() {
+		t.Fatal("transport connected with a wrong token")
+	}
+}
+
+func TestHttpTransportPollInterval(t *testing.T) {
+	// 不支持长轮询的云端立即返回空列表
+	var polls atomic.Int32
+	cloud := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, "[]")
+	}))
+	t.Cleanup(cloud.Close)
+	transport := newTestHttpTransport(t, g.Map{"baseUrl": cloud.URL, "token": "secret", "mode": HttpTransportPoll})
+	startTestPoll(t, transport, echoHandler)
+
+	time.Sleep(time.Second)
+	// pollInterval 200ms，1 秒内约 5 次
+	if n := polls.Load(); n < 3 || n > 7 {
+		t.Fatalf("%d polls in 1s with a 200ms poll interval", n)
+	}
+}
+
+func TestHttpTransportWebhook(t *testing.T) {
+	var transport *httpTransport
+	// 设备的 /transport/webhook 接口
+	device := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := transport.Receive(r.Context(), body, r.Header); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(device.Close)
+	cloud := httptest.NewServer(fakecloud.New("secret", device.URL).Handler())
+	t.Cleanup(cloud.Close)
+
+	transport = newTestHttpTransport(t, g.Map{"baseUrl": cloud.URL, "token": "secret", "mode": HttpTransportWebhook})
+	if err := transport.Start(context.Background(), echoHandler); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if status := sendCommand(t, cloud.URL, "push-1"); status != http.StatusOK {
+		t.Fatalf("push status %d", status)
+	}
+	waitFor(t, 5*time.Second, "reply", func() bool {
+		return len(cloudReplies(t, cloud.URL)) == 1
+	})
+	if reply := cloudReplies(t, cloud.URL)[0]; reply.CmdId != "push-1" {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+
+	payload := []byte(`{"cmdId":"push-2","method":"ping"}`)
+	for name, header := range map[string]http.Header{
+		"missing": {},
+		"wrong":   {"Authorization": {"Bearer wrong"}},
+		"empty":   {"Authorization": {"Bearer "}},
+	} {
+		err := transport.Receive(context.Background(), payload, header)
+		if gerror.Code(err) != gcode.CodeNotAuthorized {
+			t.Fatalf("%s token: %v", name, err)
+		}
+	}
+}
+
+func TestHttpTransportWebhookRequiresToken(t *testing.T) {
+	transport := newTestHttpTransport(t, g.Map{"baseUrl": "http://127.0.0.1:1", "mode": HttpTransportWebhook})
+	if err := transport.Start(context.Background(), echoHandler); gerror.Code(err) != gcode.CodeInvalidConfiguration {
+		t.Fatalf("start webhook without a token: %v", err)
+	}
+	// 未启动时不接收推送，即使请求不带令牌
+	err := transport.Receive(context.Background(), []byte(`{}`), http.Header{"Authorization": {"Bearer "}})
+	if gerror.Code(err) != gcode.CodeNotSupported {
+		t.Fatalf("receive before start: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// mqttTransport 基于 MQTT 的命令通道，使用 Mqtt() 客户端(v3.1.1 或 v5)收发消息
+type mqttTransport struct{}
+
+// Name 通道类型
+func (t *mqttTransport) Name() string {
+	return TransportMqtt
+}
+
+// Enabled mqtt.enabled 为 true 时启用
+func (t *mqttTransport) Enabled() bool {
+	return Config().Get().Mqtt.Enabled
+}
+
+// Start 订阅设备命令主题
+func (t *mqttTransport) Start(ctx context.Context, handler TransportHandler) error {
+	topic := fmt.Sprintf(consts.TopicCommand, Device().Id())
+	return Mqtt().Subscribe(topic, 1, func(msg *model.MqttMessage) {
+		// 安装等命令耗时较长，放到独立协程避免阻塞后续消息
+		go handler(&model.CommandMessage{
+			Payload:    msg.Payload,
+			Properties: msg.UserProperties,
+		}, func(ctx context.Context, reply *CommandReply) error {
+			return t.reply(msg, reply)
+		})
+	})
+}
+
+// reply 发布命令执行结果。
+// MQTT v5 命令带有 ResponseTopic 时回复到该主题，CorrelationData 为 cmdId。
+func (t *mqttTransport) reply(msg *model.MqttMessage, reply *CommandReply) error {
+	content, err := gjson.Encode(reply)
+	if err != nil {
+		return err
+	}
+	topic := msg.ResponseTopic
+	if topic == "" {
+		topic = fmt.Sprintf(consts.TopicReply, Device().Id())
+	}
+	out := &model.MqttMessage{
+		Topic:           topic,
+		Qos:             1,
+		Payload:         content,
+		CorrelationData: []byte(reply.CmdId),
+	}
+	if reply.TraceParent != "" {
+		out.UserProperties = map[string]string{traceParentField: reply.TraceParent}
+	}
+	return Mqtt().PublishMessage(out)
+}
+
+// SendHeartbeat 发布心跳到 heartbeat 主题，断线期间跳过
+func (t *mqttTransport) SendHeartbeat(ctx context.Context, payload []byte) error {
+	if !Mqtt().IsConnected() {
+		return nil
+	}
+	return Mqtt().Publish(fmt.Sprintf(consts.TopicHeartbeat, Device().Id()), 0, false, payload)
+}
+
+// SendRegistration 以保留消息发布注册信息，云端订阅后即可获得设备最近一次注册内容
+func (t *mqttTransport) SendRegistration(ctx context.Context, payload []byte) error {
+	if !Mqtt().IsConnected() {
+		return gerror.NewCode(gcode.CodeInternalError, "mqtt is not connected")
+	}
+	return Mqtt().Publish(fmt.Sprintf(consts.TopicRegister, Device().Id()), 1, true, payload)
+}
+
+// IsConnected MQTT 是否与 broker 保持连接
+func (t *mqttTransport) IsConnected() bool {
+	return Mqtt().IsConnected()
+}
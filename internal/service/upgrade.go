@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gtime"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+const (
+	// upgradeStateFile 升级状态文件，重启后据此检查新程序或回滚
+	upgradeStateFile = "data/upgrade.json"
+	// upgradeRestartDelay 替换程序后等待回复发出再重启
+	upgradeRestartDelay = 2 * time.Second
+	// upgradeCheckInterval 重启后健康检查的间隔
+	upgradeCheckInterval = time.Second
+	// upgradeProbeTimeout 替换前试运行新程序 version 命令的超时
+	upgradeProbeTimeout = 10 * time.Second
+)
+
+// sUpgrade 程序自升级服务。新版本程序下载校验后放在当前程序旁(.new)，
+// 当前程序硬链接保留为 .prev，再原子重命名替换并重新执行。
+// 新程序启动后在时限内需通过就绪检查并连上云端，否则恢复 .prev 并重新执行旧程序；
+// 新程序在通过检查前退出时，由 systemd 等重新拉起后在启动时回滚
+type sUpgrade struct {
+	mu         sync.Mutex
+	running    bool                             // 正在下载或替换
+	state      *model.FirmwareUpgradeStatus     // 最近一次升级，没有时为 nil
+	stateFile  string                           // 升级状态文件
+	timeout    time.Duration                    // 默认健康检查时限，upgrade.healthTimeout
+	started    time.Time                        // 新程序启动时间，健康检查时限从此开始计算
+	executable func() (string, error)           // 返回当前程序的真实路径
+	restart    func(exe string) error           // 在当前进程中重新执行程序
+	check      func(ctx context.Context) string // 返回未通过健康检查的原因
+}
+
+var (
+	upgradeService *sUpgrade
+	upgradeOnce    sync.Once
+)
+
+// Upgrade 获取程序自升级服务单例
+func Upgrade() *sUpgrade {
+	upgradeOnce.Do(func() {
+		upgradeService = newUpgrade(upgradeStateFile, Config().Get().Upgrade.HealthTimeout)
+	})
+	return upgradeService
+}
+
+// newUpgrade 创建升级服务并读取 stateFile 中最近一次升级的状态
+func newUpgrade(stateFile string, timeout time.Duration) *sUpgrade {
+	s := &sUpgrade{
+		stateFile:  stateFile,
+		timeout:    timeout,
+		executable: executable,
+		restart:    restartProcess,
+	}
+	s.check = s.unhealthy
+	if content := gfile.GetBytes(stateFile); len(content) > 0 {
+		var state model.FirmwareUpgradeStatus
+		if err := json.Unmarshal(content, &state); err == nil {
+			s.state = &state
+		}
+	}
+	return s
+}
+
+// Status 返回最近一次升级的状态，没有时返回 nil
+func (s *sUpgrade) Status() *model.FirmwareUpgradeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return nil
+	}
+	state := *s.state
+	return &state
+}
+
+// Check 启动时检查上次升级：刚替换的新程序进入待检查状态；
+// 上次启动的新程序未通过检查就退出时回滚并重新执行旧程序。需在读取配置等可能失败的初始化之前调用
+func (s *sUpgrade) Check(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return
+	}
+	switch s.state.Status {
+	case consts.UpgradeRestarting:
+		logger(consts.LoggerApp).Infof(ctx, "Started upgraded binary %s, waiting for health check", s.state.ToVersion)
+		s.started = time.Now()
+		s.setStatus(ctx, consts.UpgradeVerifying, "")
+	case consts.UpgradeVerifying:
+		// 尚未启动算法，直接重新执行
+		if exe, ok := s.rollback(ctx, "upgraded binary exited before passing health check"); ok {
+			if err := s.restart(exe); err != nil {
+				logger(consts.LoggerApp).Errorf(ctx, "Restart %s failed: %v", exe, err)
+			}
+		}
+	}
+}
+
+// Verify 新程序启动后在时限内检查就绪状态和云端连接，通过后确认升级，超时回滚。需在连接云端之后调用
+func (s *sUpgrade) Verify(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil || s.state.Status != consts.UpgradeVerifying {
+		return
+	}
+	timeout, err := time.ParseDuration(s.state.HealthTimeout)
+	if err != nil || timeout <= 0 {
+		timeout = s.timeout
+	}
+	deadline := s.started.Add(timeout)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(upgradeCheckInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			reason := s.check(ctx)
+			if reason != "" && now.Before(deadline) {
+				continue
+			}
+			s.mu.Lock()
+			if reason == "" {
+				s.setStatus(ctx, consts.UpgradeSucceeded, "")
+				logger(consts.LoggerApp).Infof(ctx, "Upgrade to %s succeeded", s.state.ToVersion)
+				s.mu.Unlock()
+				return
+			}
+			exe, ok := s.rollback(ctx, "health check failed within "+timeout.String()+": "+reason)
+			s.mu.Unlock()
+			if ok {
+				s.restartService(ctx, exe)
+			}
+			return
+		}
+	}()
+}
+
+// Start 下载并校验新版本程序，替换当前程序后稍后重新执行，返回升级状态。
+// 下载或替换失败时不重启，当前程序保持不变
+func (s *sUpgrade) Start(ctx context.Context, cmdId string, in model.FirmwareUpgradeInput) (*model.FirmwareUpgradeStatus, error) {
+	digest, err := StrongestDigest(in.Md5, in.Sha256, in.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if in.HealthTimeout != "" {
+		if timeout, err := time.ParseDuration(in.HealthTimeout); err != nil || timeout <= 0 {
+			return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "invalid healthTimeout: %s", in.HealthTimeout)
+		}
+	}
+	exe, err := s.executable()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.running || (s.state != nil && (s.state.Status == consts.UpgradeRestarting || s.state.Status == consts.UpgradeVerifying)) {
+		s.mu.Unlock()
+		return nil, gerror.NewCode(gcode.CodeOperationFailed, "another upgrade is in progress")
+	}
+	s.running = true
+	s.state = &model.FirmwareUpgradeStatus{
+		CmdId:         cmdId,
+		FromVersion:   consts.Version,
+		ToVersion:     in.FirmwareVersion,
+		Url:           in.Url,
+		Digest:        digest.String(),
+		Status:        consts.UpgradeDownloading,
+		HealthTimeout: in.HealthTimeout,
+		StartedAt:     gtime.Now(),
+		UpdatedAt:     gtime.Now(),
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	logger(consts.LoggerApp).Infof(ctx, "Upgrading from %s to %s", consts.Version, in.FirmwareVersion)
+	if err = s.stage(ctx, exe, in, digest); err != nil {
+		s.mu.Lock()
+		s.setStatus(ctx, consts.UpgradeFailed, err.Error())
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.mu.Lock()
+	s.setStatus(ctx, consts.UpgradeRestarting, "")
+	s.mu.Unlock()
+	logger(consts.LoggerApp).Infof(ctx, "Binary replaced with %s, restarting in %s", in.FirmwareVersion, upgradeRestartDelay)
+	go func() {
+		time.Sleep(upgradeRestartDelay)
+		s.restartService(context.WithoutCancel(ctx), exe)
+	}()
+	return s.Status(), nil
+}
+
+// stage 下载新程序到 .new，保留当前程序为 .prev 后原子替换
+func (s *sUpgrade) stage(ctx context.Context, exe string, in model.FirmwareUpgradeInput, digest Digest) error {
+	staged, previous := exe+".new", exe+".prev"
+	if err := Download().Fetch(ctx, in.Url, staged, in.FileSize, digest); err != nil {
+		return err
+	}
+	if err := os.Chmod(staged, 0o755); err != nil {
+		_ = os.Remove(staged)
+		return gerror.Wrapf(err, "chmod %s failed", staged)
+	}
+	if err := s.probe(ctx, staged, in.FirmwareVersion); err != nil {
+		_ = os.Remove(staged)
+		return err
+	}
+	// 硬链接保留当前程序，替换过程中程序路径始终存在；不支持硬链接时复制
+	_ = os.Remove(previous)
+	if err := os.Link(exe, previous); err != nil {
+		if err = gfile.CopyFile(exe, previous); err != nil {
+			_ = os.Remove(staged)
+			return gerror.Wrapf(err, "keep previous binary %s failed", previous)
+		}
+	}
+	if err := os.Rename(staged, exe); err != nil {
+		_ = os.Remove(staged)
+		return gerror.Wrapf(err, "replace %s failed", exe)
+	}
+	return nil
+}
+
+// probe 替换前试运行新程序的 version 命令，确认能在本机执行且版本号与下发的一致，
+// 避免替换为无法启动的程序后无法回滚
+func (s *sUpgrade) probe(ctx context.Context, staged, version string) error {
+	ctx, cancel := context.WithTimeout(ctx, upgradeProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, staged, "version").Output()
+	if err != nil {
+		return gerror.WrapCodef(gcode.CodeOperationFailed, err, "run %s version failed", staged)
+	}
+	if actual := strings.TrimSpace(string(output)); actual != version {
+		return gerror.NewCodef(gcode.CodeOperationFailed, "binary reports version %s, expected %s", actual, version)
+	}
+	return nil
+}
+
+// rollback 恢复 .prev 为当前程序，返回程序路径，恢复失败时继续运行新程序。调用方持有锁
+func (s *sUpgrade) rollback(ctx context.Context, reason string) (string, bool) {
+	logger(consts.LoggerApp).Errorf(ctx, "Upgrade to %s failed, rolling back to %s: %s", s.state.ToVersion, s.state.FromVersion, reason)
+	exe, err := s.executable()
+	if err == nil {
+		err = os.Rename(exe+".prev", exe)
+	}
+	if err != nil {
+		// 无法恢复旧程序时继续运行新程序
+		s.setStatus(ctx, consts.UpgradeFailed, reason+"; rollback failed: "+err.Error())
+		logger(consts.LoggerApp).Errorf(ctx, "Rollback failed, keep running %s: %v", s.state.ToVersion, err)
+		return "", false
+	}
+	s.setStatus(ctx, consts.UpgradeRolledBack, reason)
+	return exe, true
+}
+
+// restartService 停止全部算法后重新执行程序
+func (s *sUpgrade) restartService(ctx context.Context, exe string) {
+	Supervisor().StopAll(ctx)
+	if err := s.restart(exe); err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Restart %s failed: %v", exe, err)
+	}
+}
+
+// unhealthy 返回未通过检查的原因，通过时返回空字符串
+func (s *sUpgrade) unhealthy(ctx context.Context) string {
+	report := Health().Readiness(ctx)
+	if report.Status == consts.HealthStatusFail {
+		for _, check := range report.Checks {
+			if check.Status == consts.HealthStatusFail {
+				return check.Name + ": " + check.Message
+			}
+		}
+	}
+	if Transport().Enabled() && !Transport().IsConnected() {
+		return Transport().Name() + " not connected"
+	}
+	return ""
+}
+
+// setStatus 更新并保存升级状态，调用方持有锁
+func (s *sUpgrade) setStatus(ctx context.Context, status, reason string) {
+	s.state.Status = status
+	s.state.Reason = reason
+	s.state.UpdatedAt = gtime.Now()
+	// 先写临时文件再重命名，重启过程中不会留下写了一半的状态
+	content, err := json.Marshal(s.state)
+	if err == nil {
+		err = gfile.PutBytes(s.stateFile+".tmp", content)
+	}
+	if err == nil {
+		err = os.Rename(s.stateFile+".tmp", s.stateFile)
+	}
+	if err != nil {
+		logger(consts.LoggerApp).Errorf(ctx, "Save upgrade state failed: %v", err)
+	}
+}
+
+// executable 返回当前程序的真实路径
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return "", gerror.Wrap(err, "locate executable failed")
+	}
+	return exe, nil
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package service
+
+import (
+	"os"
+	"syscall"
+)
+
+// restartProcess 以相同的参数和环境变量在当前进程中执行 exe，进程ID不变
+func restartProcess(exe string) error {
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package service
+
+import (
+	"os"
+	"os/exec"
+)
+
+// restartProcess 非 Linux 平台无法替换当前进程，以相同的参数启动 exe 后退出
+func restartProcess(exe string) error {
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
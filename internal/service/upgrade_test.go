@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gfile"
+
+	"demo/internal/consts"
+	"demo/internal/model"
+)
+
+// newTestUpgrade 返回使用临时目录的升级服务，当前程序为内容 current 的文件，
+// 重新执行时把程序路径发送到返回的 channel
+func newTestUpgrade(t *testing.T, state *model.FirmwareUpgradeStatus) (*sUpgrade, string, chan string) {
+	t.Helper()
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "i800")
+	if err := os.WriteFile(exe, []byte("current"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stateFile := filepath.Join(dir, "upgrade.json")
+	if state != nil {
+		content, err := json.Marshal(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(stateFile, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restarted := make(chan string, 1)
+	s := newUpgrade(stateFile, time.Minute)
+	s.executable = func() (string, error) { return exe, nil }
+	s.restart = func(exe string) error {
+		restarted <- exe
+		return nil
+	}
+	return s, exe, restarted
+}
+
+// savedUpgradeStatus 返回状态文件中保存的升级状态
+func savedUpgradeStatus(t *testing.T, s *sUpgrade) string {
+	t.Helper()
+	var state model.FirmwareUpgradeStatus
+	if err := json.Unmarshal(gfile.GetBytes(s.stateFile), &state); err != nil {
+		t.Fatal(err)
+	}
+	return state.Status
+}
+
+func TestUpgradeStage(t *testing.T) {
+	loadTestConfig(t, g.Map{})
+	tests := []struct {
+		name       string
+		script     string
+		wantStatus string
+	}{
+		{name: "version fails", script: "#!/bin/sh\nexit 1\n", wantStatus: consts.UpgradeFailed},
+		{name: "version mismatch", script: "#!/bin/sh\necho 9.9.8\n", wantStatus: consts.UpgradeFailed},
+		{name: "ok", script: "#!/bin/sh\necho 9.9.9\n", wantStatus: consts.UpgradeRestarting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.script))
+			}))
+			defer server.Close()
+			sum := sha256.Sum256([]byte(tt.script))
+			s, exe, restarted := newTestUpgrade(t, nil)
+
+			_, err := s.Start(context.Background(), "upgrade-1", model.FirmwareUpgradeInput{
+				FirmwareVersion: "9.9.9",
+				Url:             server.URL,
+				FileSize:        int64(len(tt.script)),
+				Sha256:          hex.EncodeToString(sum[:]),
+			})
+			if status := s.Status().Status; status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s (err %v)", status, tt.wantStatus, err)
+			}
+			if saved := savedUpgradeStatus(t, s); saved != tt.wantStatus {
+				t.Fatalf("saved status = %s, want %s", saved, tt.wantStatus)
+			}
+			if gfile.Exists(exe + ".new") {
+				t.Fatal("staged binary left behind")
+			}
+			if tt.wantStatus == consts.UpgradeFailed {
+				// 新程序无法运行时不替换也不重启
+				if err == nil {
+					t.Fatal("Start succeeded, want error")
+				}
+				if content := gfile.GetContents(exe); content != "current" {
+					t.Fatalf("binary replaced with %q", content)
+				}
+				select {
+				case <-restarted:
+					t.Fatal("restarted after failed staging")
+				default:
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gfile.GetContents(exe) != tt.script || gfile.GetContents(exe+".prev") != "current" {
+				t.Fatal("binary not replaced or previous binary not kept")
+			}
+			select {
+			case got := <-restarted:
+				if got != exe {
+					t.Fatalf("restarted %s, want %s", got, exe)
+				}
+			case <-time.After(upgradeRestartDelay + 5*time.Second):
+				t.Fatal("not restarted")
+			}
+		})
+	}
+}
+
+func TestUpgradeHealthTimeoutRollback(t *testing.T) {
+	ctx := context.Background()
+	s, exe, restarted := newTestUpgrade(t, &model.FirmwareUpgradeStatus{
+		FromVersion:   "1.0.0",
+		ToVersion:     "9.9.9",
+		Status:        consts.UpgradeRestarting,
+		HealthTimeout: "1s",
+	})
+	if err := os.WriteFile(exe+".prev", []byte("previous"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// 健康检查始终不通过
+	s.check = func(ctx context.Context) string { return "mqtt not connected" }
+
+	s.Check(ctx)
+	if status := s.Status().Status; status != consts.UpgradeVerifying {
+		t.Fatalf("status after restart = %s, want %s", status, consts.UpgradeVerifying)
+	}
+	s.Verify(ctx)
+	select {
+	case got := <-restarted:
+		if got != exe {
+			t.Fatalf("restarted %s, want %s", got, exe)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("not rolled back after health timeout")
+	}
+	if status := s.Status().Status; status != consts.UpgradeRolledBack {
+		t.Fatalf("status = %s, want %s", status, consts.UpgradeRolledBack)
+	}
+	if saved := savedUpgradeStatus(t, s); saved != consts.UpgradeRolledBack {
+		t.Fatalf("saved status = %s, want %s", saved, consts.UpgradeRolledBack)
+	}
+	if content := gfile.GetContents(exe); content != "previous" {
+		t.Fatalf("binary = %q, want previous binary restored", content)
+	}
+}
+
+func TestUpgradeCheckVerifying(t *testing.T) {
+	tests := []struct {
+		name        string
+		previous    bool // 是否保留了旧程序
+		wantStatus  string
+		wantRestart bool
+		wantBinary  string
+	}{
+		{name: "rolled back", previous: true, wantStatus: consts.UpgradeRolledBack, wantRestart: true, wantBinary: "previous"},
+		{name: "previous binary missing", wantStatus: consts.UpgradeFailed, wantBinary: "current"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 上次启动的新程序未通过检查就退出
+			s, exe, restarted := newTestUpgrade(t, &model.FirmwareUpgradeStatus{
+				FromVersion: "1.0.0",
+				ToVersion:   "9.9.9",
+				Status:      consts.UpgradeVerifying,
+			})
+			if tt.previous {
+				if err := os.WriteFile(exe+".prev", []byte("previous"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Check(context.Background())
+			if status := s.Status().Status; status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", status, tt.wantStatus)
+			}
+			if saved := savedUpgradeStatus(t, s); saved != tt.wantStatus {
+				t.Fatalf("saved status = %s, want %s", saved, tt.wantStatus)
+			}
+			if content := gfile.GetContents(exe); content != tt.wantBinary {
+				t.Fatalf("binary = %q, want %q", content, tt.wantBinary)
+			}
+			select {
+			case got := <-restarted:
+				if !tt.wantRestart || got != exe {
+					t.Fatalf("restarted %s, want restart %v", got, tt.wantRestart)
+				}
+			default:
+				if tt.wantRestart {
+					t.Fatal("not restarted")
+				}
+			}
+		})
+	}
+}
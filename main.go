@@ -8,8 +8,11 @@ import (
 	"github.com/gogf/gf/v2/os/gctx"
 
 	"demo/internal/cmd"
+	"demo/internal/service"
 )
 
 func main() {
+	// 作为算法启动器运行时设置资源限制后执行算法入口，不启动服务
+	service.RunProcessLauncher()
 	cmd.Main.Run(gctx.GetInitCtx())
 }
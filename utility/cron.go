@@ -0,0 +1,207 @@
+package utility
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// CronSchedule 标准5段 cron 表达式: 分 时 日 月 周。
+// 支持 *、列表(1,2)、范围(1-5)和步长(*/15、0-30/5)，周取值 0-7，0 和 7 均表示周日。
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// cronField 各段取值范围
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron 解析 cron 表达式
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "cron expression must have 5 fields: %s", expr)
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, gerror.WrapCodef(gcode.CodeInvalidParameter, err, "invalid %s field in %s", cronFields[i].name, expr)
+		}
+		bits[i] = b
+	}
+	// 周日统一为 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	c := &CronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+	// 只限制日时，所选日期需在所选月份中存在，如 2 月 31 日永远不会触发
+	if c.dowStar && !c.possibleDay() {
+		return nil, gerror.NewCodef(gcode.CodeInvalidParameter, "day of month never occurs in the selected months: %s", expr)
+	}
+	return c, nil
+}
+
+// cronMonthDays 各月最多天数，2 月按闰年计
+var cronMonthDays = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// possibleDay 判断所选月份中是否存在所选的日
+func (c *CronSchedule) possibleDay() bool {
+	for month := 1; month <= 12; month++ {
+		if c.month&(1<<uint(month)) == 0 {
+			continue
+		}
+		for day := 1; day <= cronMonthDays[month]; day++ {
+			if c.dom&(1<<uint(day)) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseCronField 解析单段，返回取值位图
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, gerror.Newf("invalid step: %s", part)
+			}
+			rangePart = part[:i]
+		}
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, gerror.Newf("invalid value: %s", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, gerror.Newf("invalid value: %s", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, gerror.Newf("value out of range %d-%d: %s", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回 t 之后(不含 t)的下一个触发时间，按 t 的时区计算；五年内无触发时返回零值。
+// 夏令时跳过的时刻不触发，回拨重复的时刻只在第一次触发
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = cronForward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = cronForward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = cronForward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 || repeatedWallClock(t) {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Prev 返回 t 及之前(含 t 所在分钟)最近的触发时间，按 t 的时区计算；五年内无触发时返回零值。
+// 夏令时的处理与 Next 相同
+func (c *CronSchedule) Prev(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	limit := t.AddDate(-5, 0, 0)
+	for t.After(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = cronBackward(t, time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute))
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = cronBackward(t, time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute))
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = cronBackward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute))
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 || repeatedWallClock(t) {
+			t = t.Add(-time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// cronForward 跳到 next；夏令时切换处 time.Date 归一化后可能不前进，此时退回按分钟前进
+func cronForward(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+// cronBackward 跳到 prev，不后退时按分钟后退
+func cronBackward(t, prev time.Time) time.Time {
+	if prev.Before(t) {
+		return prev
+	}
+	return t.Add(-time.Minute)
+}
+
+// repeatedWallClock 判断 t 是否为夏令时回拨后第二次出现的时刻
+func repeatedWallClock(t time.Time) bool {
+	earlier := t.Add(-time.Hour)
+	return earlier.Day() == t.Day() && earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute()
+}
+
+// dayMatches 按 cron 语义匹配日期：日和周都有限制时满足其一即可
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
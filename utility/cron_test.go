@@ -0,0 +1,214 @@
+package utility
+
+import (
+	"testing"
+	"time"
+)
+
+// mustLocation 加载时区
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"*/15 0-6 1,15 * 1-5",
+		"0-30/10 22 * 1-12/3 0,7",
+		"0 0 29 2 *", // 闰年才触发
+		"0 0 31 2 1", // 日和周都有限制时满足其一即可
+	}
+	for _, expr := range valid {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q): %v", expr, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"0 0 31 2 *",
+		"0 0 30,31 2 *",
+		"0 0 31 4,6,9,11 *",
+	}
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	utc := time.UTC
+	newYork := mustLocation(t, "America/New_York")
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time // 从 from 开始依次的触发时间
+	}{
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			from: time.Date(2026, 1, 1, 10, 7, 30, 0, utc),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 10, 15, 0, 0, utc),
+				time.Date(2026, 1, 1, 10, 30, 0, 0, utc),
+			},
+		},
+		{
+			name: "exclusive of from",
+			expr: "0 10 * * *",
+			from: time.Date(2026, 1, 1, 10, 0, 0, 0, utc),
+			want: []time.Time{time.Date(2026, 1, 2, 10, 0, 0, 0, utc)},
+		},
+		{
+			name: "range with step",
+			expr: "0 8-18/5 * * *",
+			from: time.Date(2026, 1, 1, 9, 0, 0, 0, utc),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 13, 0, 0, 0, utc),
+				time.Date(2026, 1, 1, 18, 0, 0, 0, utc),
+				time.Date(2026, 1, 2, 8, 0, 0, 0, utc),
+			},
+		},
+		{
+			name: "day of month or day of week",
+			// 2026-03-01 是周日
+			expr: "0 0 15 * 1",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, utc),
+			want: []time.Time{
+				time.Date(2026, 3, 2, 0, 0, 0, 0, utc),
+				time.Date(2026, 3, 9, 0, 0, 0, 0, utc),
+				time.Date(2026, 3, 15, 0, 0, 0, 0, utc),
+				time.Date(2026, 3, 16, 0, 0, 0, 0, utc),
+			},
+		},
+		{
+			name: "day of month with any weekday",
+			expr: "0 0 15 * *",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, utc),
+			want: []time.Time{
+				time.Date(2026, 3, 15, 0, 0, 0, 0, utc),
+				time.Date(2026, 4, 15, 0, 0, 0, 0, utc),
+			},
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 12 * * 7",
+			from: time.Date(2026, 3, 2, 0, 0, 0, 0, utc),
+			want: []time.Time{time.Date(2026, 3, 8, 12, 0, 0, 0, utc)},
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			want: []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		},
+		{
+			name: "skipped by spring forward",
+			// 2026-03-08 02:00 EST 跳到 03:00 EDT
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name: "hourly across spring forward",
+			expr: "0 * * * *",
+			from: time.Date(2026, 3, 8, 0, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+				time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+			},
+		},
+		{
+			name: "once on fall back",
+			// 2026-11-01 02:00 EDT 回拨到 01:00 EST，01:30 出现两次
+			expr: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 30, 0, 0, utc),
+				time.Date(2026, 11, 2, 6, 30, 0, 0, utc),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := tt.from
+			for _, want := range tt.want {
+				got := c.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", from, got, want)
+				}
+				if got.Location() != from.Location() {
+					t.Fatalf("Next(%s) in %s, want %s", from, got.Location(), from.Location())
+				}
+				// Prev 是 Next 的逆运算
+				if prev := c.Prev(got.Add(59 * time.Second)); !prev.Equal(got) {
+					t.Fatalf("Prev(%s) = %s, want %s", got.Add(59*time.Second), prev, got)
+				}
+				if prev := c.Prev(got.Add(-time.Second)); !prev.Before(got) {
+					t.Fatalf("Prev(%s) = %s, want before %s", got.Add(-time.Second), prev, got)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	c, err := ParseCron("0 22 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-03-09 是周一，上一个工作日 22:00 是 03-06 周五
+	from := time.Date(2026, 3, 9, 21, 59, 0, 0, time.UTC)
+	if got, want := c.Prev(from), time.Date(2026, 3, 6, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Prev(%s) = %s, want %s", from, got, want)
+	}
+	// 回拨重复的 01:00 只算第一次
+	newYork := mustLocation(t, "America/New_York")
+	hourly, err := ParseCron("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from = time.Date(2026, 11, 1, 6, 59, 0, 0, time.UTC).In(newYork)
+	if got, want := hourly.Prev(from), time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Prev(%s) = %s, want %s", from, got, want)
+	}
+	leap, err := ParseCron("0 0 29 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := leap.Prev(time.Date(2028, 2, 28, 0, 0, 0, 0, time.UTC)); !got.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Prev leap day = %s", got)
+	}
+	// 五年内没有触发时返回零值
+	never, err := ParseCron("0 0 31 2 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	never.dow = 0
+	if got := never.Prev(from); !got.IsZero() {
+		t.Fatalf("Prev = %s, want zero", got)
+	}
+}